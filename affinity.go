@@ -0,0 +1,25 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import "github.com/pkg/errors"
+
+// errAffinityUnsupported is returned by pinThread on platforms without CPU affinity.
+var errAffinityUnsupported = errors.New("CPU affinity is only supported on Linux")
+
+// pinGrabber pins the OS thread of the calling goroutine to grabber_cpus, if configured, and returns the function
+// undoing it. The SDK's thread reading the serial port is started along with scanning and inherits the affinity of
+// the thread starting it, so both setting up the rplidar and the scan loop are pinned. Failing to pin the thread,
+// e.g. on a platform without CPU affinity, is logged once and leaves the thread unpinned.
+func (rp *rplidar) pinGrabber() func() {
+	if len(rp.grabberCPUs) == 0 {
+		return func() {}
+	}
+	restore, err := pinThread(rp.grabberCPUs)
+	if err != nil {
+		rp.affinityWarning.Do(func() {
+			rp.logger.Warnf("not pinning the scan loop to CPUs %v: %v", rp.grabberCPUs, err)
+		})
+		return func() {}
+	}
+	return restore
+}
@@ -0,0 +1,37 @@
+//go:build linux
+// +build linux
+
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// pinThread locks the calling goroutine to its OS thread and restricts that thread to cpus. It returns a function
+// restoring the previous affinity of the thread and unlocking it again. If restoring fails, the thread stays
+// locked, so that it ends with the goroutine instead of running other goroutines on the pinned CPUs.
+func pinThread(cpus []int) (func(), error) {
+	runtime.LockOSThread()
+	var previous unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &previous); err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	var pinned unix.CPUSet
+	for _, cpu := range cpus {
+		pinned.Set(cpu)
+	}
+	if err := unix.SchedSetaffinity(0, &pinned); err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	return func() {
+		if err := unix.SchedSetaffinity(0, &previous); err != nil {
+			return
+		}
+		runtime.UnlockOSThread()
+	}, nil
+}
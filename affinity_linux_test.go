@@ -0,0 +1,49 @@
+//go:build linux
+// +build linux
+
+package rplidar
+
+import (
+	"testing"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+	"golang.org/x/sys/unix"
+)
+
+// The last CPU a unix.CPUSet can hold, which no test machine has.
+const missingCPU = 1023
+
+func TestPinThread(t *testing.T) {
+	var original unix.CPUSet
+	test.That(t, unix.SchedGetaffinity(0, &original), test.ShouldBeNil)
+	cpu := 0
+	for !original.IsSet(cpu) {
+		cpu++
+	}
+
+	restore, err := pinThread([]int{cpu})
+	test.That(t, err, test.ShouldBeNil)
+	var pinned unix.CPUSet
+	test.That(t, unix.SchedGetaffinity(0, &pinned), test.ShouldBeNil)
+	test.That(t, pinned.Count(), test.ShouldEqual, 1)
+	test.That(t, pinned.IsSet(cpu), test.ShouldBeTrue)
+
+	restore()
+	var restored unix.CPUSet
+	test.That(t, unix.SchedGetaffinity(0, &restored), test.ShouldBeNil)
+	test.That(t, restored, test.ShouldResemble, original)
+
+	t.Run("fails without an existing CPU", func(t *testing.T) {
+		_, err := pinThread([]int{missingCPU})
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("pinGrabber warns once and keeps going", func(t *testing.T) {
+		logger, logs := logging.NewObservedTestLogger(t)
+		rp := &rplidar{logger: logger, grabberCPUs: []int{missingCPU}}
+		rp.pinGrabber()()
+		rp.pinGrabber()()
+		test.That(t, logs.FilterMessageSnippet("not pinning the scan loop").Len(), test.ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+// pinThread fails with errAffinityUnsupported, since setting the CPU affinity of a thread is only supported on
+// Linux.
+func pinThread(cpus []int) (func(), error) {
+	return nil, errAffinityUnsupported
+}
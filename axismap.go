@@ -0,0 +1,79 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/geo/r3"
+)
+
+// axisMap remaps the axes of converted points as configured by axis_map, a signed permutation of x, y and z. A nil
+// axisMap leaves points unchanged.
+type axisMap struct {
+	// Coordinate i of a point becomes coordinate axes[i] of the mapped point, multiplied by signs[i].
+	axes  [3]int
+	signs [3]float64
+}
+
+// parseAxisMap parses an axis_map, the axes the point's x, y and z become, separated by commas and each optionally
+// negated, e.g. "y,-x,z" for x to become y and y to become -x. An empty spec parses to nil.
+func parseAxisMap(spec string) (*axisMap, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	entries := strings.Split(spec, ",")
+	if len(entries) != 3 {
+		return nil, fmt.Errorf("axis_map must list the axes x, y and z become, ex. \"y,-x,z\", got %q", spec)
+	}
+	var m axisMap
+	var used [3]bool
+	for i, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		m.signs[i] = 1
+		switch {
+		case strings.HasPrefix(entry, "-"):
+			m.signs[i] = -1
+			entry = entry[1:]
+		case strings.HasPrefix(entry, "+"):
+			entry = entry[1:]
+		}
+		axis := strings.Index("xyz", entry)
+		if len(entry) != 1 || axis < 0 {
+			return nil, fmt.Errorf("axis_map entries must be x, y or z, optionally negated, got %q", spec)
+		}
+		if used[axis] {
+			return nil, fmt.Errorf("axis_map must use each of x, y and z once, got %q", spec)
+		}
+		used[axis] = true
+		m.axes[i] = axis
+	}
+	return &m, nil
+}
+
+// apply returns p with its axes remapped.
+func (m *axisMap) apply(p r3.Vector) r3.Vector {
+	if m == nil {
+		return p
+	}
+	in := [3]float64{p.X, p.Y, p.Z}
+	var out [3]float64
+	for i, axis := range m.axes {
+		out[axis] = m.signs[i] * in[i]
+	}
+	return r3.Vector{X: out[0], Y: out[1], Z: out[2]}
+}
+
+// mirrorsXY reports whether the map mirrors the XY plane, i.e. turns counterclockwise directions in it clockwise.
+// A map moving x or y out of the XY plane does not keep directions in it at all and reports false.
+func (m *axisMap) mirrorsXY() bool {
+	if m == nil || m.axes[2] != 2 {
+		return false
+	}
+	// Swapping x and y mirrors, and so does negating one of them.
+	mirrored := m.axes[0] == 1
+	if m.signs[0] != m.signs[1] {
+		mirrored = !mirrored
+	}
+	return mirrored
+}
@@ -0,0 +1,52 @@
+package rplidar
+
+import (
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestParseAxisMap(t *testing.T) {
+	identity, err := parseAxisMap("")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, identity, test.ShouldBeNil)
+
+	p := r3.Vector{X: 1, Y: 2, Z: 3}
+	for spec, mapped := range map[string]r3.Vector{
+		"":              p,
+		"x,y,z":         p,
+		"y,-x,z":        {X: -2, Y: 1, Z: 3},
+		" +Y , -X , Z ": {X: -2, Y: 1, Z: 3},
+		"-x,-y,z":       {X: -1, Y: -2, Z: 3},
+		"z,x,y":         {X: 2, Y: 3, Z: 1},
+	} {
+		m, err := parseAxisMap(spec)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, m.apply(p), test.ShouldResemble, mapped)
+	}
+
+	t.Run("only signed permutations", func(t *testing.T) {
+		for _, spec := range []string{"y,-x", "x,y,z,x", "x,x,z", "y,-y,z", "x,y,w", "--x,y,z", "xy,z,"} {
+			_, err := parseAxisMap(spec)
+			test.That(t, err, test.ShouldNotBeNil)
+		}
+	})
+}
+
+func TestAxisMapMirrorsXY(t *testing.T) {
+	for spec, mirrors := range map[string]bool{
+		"":        false,
+		"y,-x,z":  false,
+		"-x,-y,z": false,
+		"x,y,-z":  false,
+		"y,x,z":   true,
+		"-x,y,z":  true,
+		"x,-y,-z": true,
+		"x,z,y":   false,
+	} {
+		m, err := parseAxisMap(spec)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, m.mirrorsXY(), test.ShouldEqual, mirrors)
+	}
+}
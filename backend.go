@@ -0,0 +1,28 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import "go.viam.com/rdk/pointcloud"
+
+// The point cloud implementations the scans can be built with.
+const (
+	// pointCloudBackendBasic is the RDK's default point cloud, storing every point as it was measured.
+	pointCloudBackendBasic = "basic"
+	// pointCloudBackendKDTree indexes the points in a k-d tree, for fast nearest neighbor queries at the cost of a
+	// slower construction and more memory.
+	pointCloudBackendKDTree = "kdtree"
+	// pointCloudBackendRounding rounds every point to the nearest millimeter, merging returns that round to the
+	// same position.
+	pointCloudBackendRounding = "rounding"
+)
+
+// newPointCloud creates an empty point cloud of the given backend, defaulting to the basic one.
+func newPointCloud(backend string) pointcloud.PointCloud {
+	switch backend {
+	case pointCloudBackendKDTree:
+		return pointcloud.NewKDTree()
+	case pointCloudBackendRounding:
+		return pointcloud.NewRoundingPointCloud()
+	default:
+		return pointcloud.New()
+	}
+}
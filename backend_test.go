@@ -0,0 +1,29 @@
+package rplidar
+
+import (
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestNewPointCloud(t *testing.T) {
+	for _, backend := range []string{"", pointCloudBackendBasic, pointCloudBackendKDTree} {
+		pc := newPointCloud(backend)
+		test.That(t, pc.Set(r3.Vector{X: 1.2}, nil), test.ShouldBeNil)
+		test.That(t, pc.Set(r3.Vector{X: 1.4}, nil), test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+	}
+	_, ok := newPointCloud(pointCloudBackendKDTree).(*pointcloud.KDTree)
+	test.That(t, ok, test.ShouldBeTrue)
+
+	t.Run("rounding merges returns within a millimeter", func(t *testing.T) {
+		pc := newPointCloud(pointCloudBackendRounding)
+		test.That(t, pc.Set(r3.Vector{X: 1.2}, nil), test.ShouldBeNil)
+		test.That(t, pc.Set(r3.Vector{X: 1.4}, nil), test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 1)
+		_, got := pc.At(1, 0, 0)
+		test.That(t, got, test.ShouldBeTrue)
+	})
+}
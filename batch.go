@@ -0,0 +1,90 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.viam.com/rdk/pointcloud"
+)
+
+// ErrPartialBatch is wrapped by the error NextN returns when it stopped before collecting all requested scans,
+// along with the scans it did collect.
+var ErrPartialBatch = errors.New("partial batch of scans")
+
+// cachedScan is a scan as it was stored in the cache.
+type cachedScan struct {
+	pointCloud pointcloud.PointCloud
+	meta       ScanMeta
+}
+
+// subscribe returns a channel receiving every scan stored in the cache from now on, buffering up to size of them.
+// Scans arriving while the buffer is full are dropped for this subscriber.
+func (c *dataCache) subscribe(size int) chan cachedScan {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	ch := make(chan cachedScan, size)
+	if c.subscribers == nil {
+		c.subscribers = map[chan cachedScan]struct{}{}
+	}
+	c.subscribers[ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe stops delivering scans to a channel returned by subscribe.
+func (c *dataCache) unsubscribe(ch chan cachedScan) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.subscribers, ch)
+}
+
+// publish delivers the scan just stored in the cache to every subscriber. It must be called with the cache's
+// mutex held.
+func (c *dataCache) publish() {
+	for ch := range c.subscribers {
+		select {
+		case ch <- cachedScan{pointCloud: c.pointCloud, meta: c.meta}:
+		default:
+		}
+	}
+}
+
+// NextN returns the next n consecutive scans along with their metadata, starting with the first scan stored
+// after the call. The scans come straight from the background scan loop, so no revolution is skipped or returned
+// twice. If ctx is done first, the scans collected so far are returned together with an error wrapping
+// ErrPartialBatch.
+func (rp *rplidar) NextN(ctx context.Context, n int) ([]pointcloud.PointCloud, []ScanMeta, error) {
+	if n <= 0 {
+		return nil, nil, errors.New("n must be positive")
+	}
+
+	if err := rp.ensureStarted(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	scans := rp.cache.subscribe(n)
+	defer rp.cache.unsubscribe(scans)
+
+	clouds := make([]pointcloud.PointCloud, 0, n)
+	metas := make([]ScanMeta, 0, n)
+	for len(clouds) < n {
+		select {
+		case <-ctx.Done():
+			// Keep the scans that already arrived.
+			for len(clouds) < n && len(scans) > 0 {
+				scan := <-scans
+				clouds = append(clouds, scan.pointCloud)
+				metas = append(metas, scan.meta)
+			}
+			if len(clouds) == n {
+				return clouds, metas, nil
+			}
+			return clouds, metas, fmt.Errorf("%w: got %d of %d scans: %v", ErrPartialBatch, len(clouds), n, ctx.Err())
+		case scan := <-scans:
+			clouds = append(clouds, scan.pointCloud)
+			metas = append(metas, scan.meta)
+		}
+	}
+	return clouds, metas, nil
+}
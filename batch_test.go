@@ -0,0 +1,83 @@
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestNextN(t *testing.T) {
+	ctx := context.Background()
+
+	storeScans := func(cache *dataCache, count int) {
+		for i := 0; i < count; i++ {
+			pc := pointcloud.New()
+			test.That(t, pc.Set(r3.Vector{X: float64(i + 1)}, nil), test.ShouldBeNil)
+			cache.mutex.Lock()
+			cache.pointCloud = pc
+			cache.meta = ScanMeta{Seq: cache.meta.Seq + 1, PointCount: 1}
+			cache.notifyUpdated()
+			cache.mutex.Unlock()
+		}
+	}
+
+	// waitForSubscriber waits until NextN subscribed to the cache, so no scan is stored before it listens.
+	waitForSubscriber := func(cache *dataCache) {
+		for {
+			cache.mutex.RLock()
+			subscribed := len(cache.subscribers) > 0
+			cache.mutex.RUnlock()
+			if subscribed {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	t.Run("returns consecutive scans", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{}}
+		// A scan stored before the call is not part of the batch.
+		storeScans(rp.cache, 1)
+
+		go func() {
+			waitForSubscriber(rp.cache)
+			storeScans(rp.cache, 5)
+		}()
+		clouds, metas, err := rp.NextN(ctx, 3)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(clouds), test.ShouldEqual, 3)
+		test.That(t, len(metas), test.ShouldEqual, 3)
+		for i, meta := range metas {
+			test.That(t, meta.Seq, test.ShouldEqual, uint64(i+2))
+			_, found := clouds[i].At(float64(i+1), 0, 0)
+			test.That(t, found, test.ShouldBeTrue)
+		}
+		test.That(t, rp.cache.subscribers, test.ShouldBeEmpty)
+	})
+
+	t.Run("returns a partial batch when the context is done", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{}}
+		cancelCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			waitForSubscriber(rp.cache)
+			storeScans(rp.cache, 2)
+			cancel()
+		}()
+		clouds, metas, err := rp.NextN(cancelCtx, 3)
+		test.That(t, errors.Is(err, ErrPartialBatch), test.ShouldBeTrue)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "got 2 of 3 scans")
+		test.That(t, len(clouds), test.ShouldEqual, 2)
+		test.That(t, len(metas), test.ShouldEqual, 2)
+	})
+
+	t.Run("n must be positive", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{}}
+		_, _, err := rp.NextN(ctx, 0)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,280 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/logging"
+	goutils "go.viam.com/utils"
+)
+
+const (
+	// The size of the black box ring unless configured otherwise, enough for more than a minute of raw scans
+	// of any supported model.
+	defaultBlackBoxSizeMB = 16
+	// The number of revolutions that can be queued for the black box writer before new ones are dropped.
+	defaultBlackBoxQueueSize = 8
+
+	blackBoxVersion = 1
+	// The file header holds the magic, the version, the capacity of the ring, the offset the next frame is
+	// written at and the sequence number of the next frame. It is little endian regardless of the host, like
+	// every frame.
+	blackBoxHeaderSize = 32
+	// Every frame starts with a magic, the length and the CRC-32 of its measurements, its sequence number and
+	// its timestamp in nanoseconds since the Unix epoch.
+	blackBoxFrameHeaderSize = 28
+	// Every measurement is stored as its angle and distance as 4 byte floats followed by its quality.
+	blackBoxMeasurementSize = 9
+	blackBoxFrameMagic      = uint32(0x52504652)
+)
+
+var blackBoxMagic = []byte("RPBB")
+
+// BlackBoxFrame is a single revolution of raw measurements as recorded in a black box ring.
+type BlackBoxFrame struct {
+	// Seq counts the frames written to the ring, across restarts of the camera.
+	Seq       uint64
+	Timestamp time.Time
+	// Measurements holds every valid return of the revolution, before any filtering.
+	Measurements []Measurement
+}
+
+// blackBox continuously writes the raw measurements of every revolution to a fixed size ring file, overwriting
+// the oldest frames. Frames are written from a goroutine of their own so that a slow disk never blocks the scan
+// loop; frames arriving while the queue is full are dropped and counted.
+type blackBox struct {
+	frames *asyncNotifier[BlackBoxFrame]
+	logger logging.Logger
+
+	// Guarded by mutex, since close may run while a frame is written.
+	mutex    sync.Mutex
+	file     *os.File
+	capacity int64
+	head     int64
+	nextSeq  uint64
+	failed   bool
+}
+
+// openBlackBox opens the ring file at path with room for sizeBytes of frames, creating it if needed. An existing
+// ring of the same size is continued after its newest frame, any other file at path is replaced.
+func openBlackBox(path string, sizeBytes int64, logger logging.Logger) (*blackBox, error) {
+	//nolint:gosec
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the black box file")
+	}
+	b := &blackBox{
+		frames:   newAsyncNotifier[BlackBoxFrame](defaultBlackBoxQueueSize, bufferPolicyOldest),
+		logger:   logger,
+		file:     file,
+		capacity: sizeBytes - blackBoxHeaderSize,
+	}
+
+	header := make([]byte, blackBoxHeaderSize)
+	if _, err := file.ReadAt(header, 0); err == nil && bytes.Equal(header[:4], blackBoxMagic) &&
+		binary.LittleEndian.Uint32(header[4:]) == blackBoxVersion &&
+		int64(binary.LittleEndian.Uint64(header[8:])) == b.capacity {
+		b.head = int64(binary.LittleEndian.Uint64(header[16:]))
+		b.nextSeq = binary.LittleEndian.Uint64(header[24:])
+	} else {
+		if err := file.Truncate(0); err != nil {
+			goutils.UncheckedErrorFunc(file.Close)
+			return nil, errors.Wrap(err, "failed to reset the black box file")
+		}
+		if err := file.Truncate(sizeBytes); err != nil {
+			goutils.UncheckedErrorFunc(file.Close)
+			return nil, errors.Wrap(err, "failed to size the black box file")
+		}
+	}
+	if b.head < 0 || b.head > b.capacity {
+		b.head = 0
+	}
+	if err := b.writeHeader(); err != nil {
+		goutils.UncheckedErrorFunc(file.Close)
+		return nil, err
+	}
+	b.frames.register(b.write)
+	return b, nil
+}
+
+// record queues the measurements of a revolution completed at t for writing, without blocking.
+func (b *blackBox) record(measurements []measurement, t time.Time) {
+	if b == nil {
+		return
+	}
+	b.frames.notify(BlackBoxFrame{Timestamp: t, Measurements: exportMeasurements(measurements)})
+}
+
+// droppedCount returns the number of revolutions dropped because the writer could not keep up.
+func (b *blackBox) droppedCount() int {
+	if b == nil {
+		return 0
+	}
+	return b.frames.droppedCount()
+}
+
+// run writes queued frames until ctx is done.
+func (b *blackBox) run(ctx context.Context) {
+	b.frames.run(ctx)
+}
+
+// write appends frame to the ring, wrapping around to the start of the ring when it does not fit before the end.
+// A failed write is logged once and stops further writes, keeping what is on disk intact.
+func (b *blackBox) write(frame BlackBoxFrame) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.failed || b.file == nil {
+		return
+	}
+
+	frame.Seq = b.nextSeq
+	encoded := encodeBlackBoxFrame(frame)
+	if int64(len(encoded)) > b.capacity {
+		b.logger.Debugf("dropping a black box frame of %d bytes, larger than the ring", len(encoded))
+		return
+	}
+	if b.head+int64(len(encoded)) > b.capacity {
+		b.head = 0
+	}
+	if _, err := b.file.WriteAt(encoded, blackBoxHeaderSize+b.head); err != nil {
+		b.fail(err)
+		return
+	}
+	b.head += int64(len(encoded))
+	b.nextSeq++
+	if err := b.writeHeader(); err != nil {
+		b.fail(err)
+	}
+}
+
+// fail stops writing after err. It must be called with the mutex held.
+func (b *blackBox) fail(err error) {
+	b.failed = true
+	b.logger.Errorf("stopped writing the black box: %v", err)
+}
+
+// writeHeader stores the header of the ring. It must be called with the mutex held.
+func (b *blackBox) writeHeader() error {
+	header := make([]byte, blackBoxHeaderSize)
+	copy(header, blackBoxMagic)
+	binary.LittleEndian.PutUint32(header[4:], blackBoxVersion)
+	binary.LittleEndian.PutUint64(header[8:], uint64(b.capacity))
+	binary.LittleEndian.PutUint64(header[16:], uint64(b.head))
+	binary.LittleEndian.PutUint64(header[24:], b.nextSeq)
+	if _, err := b.file.WriteAt(header, 0); err != nil {
+		return errors.Wrap(err, "failed to write the black box header")
+	}
+	return nil
+}
+
+// close closes the ring file. Frames still queued are not written.
+func (b *blackBox) close() error {
+	if b == nil {
+		return nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	b.file = nil
+	return err
+}
+
+// encodeBlackBoxFrame returns the frame as it is stored in the ring, in little endian byte order.
+func encodeBlackBoxFrame(frame BlackBoxFrame) []byte {
+	payload := make([]byte, len(frame.Measurements)*blackBoxMeasurementSize)
+	for i, m := range frame.Measurements {
+		record := payload[i*blackBoxMeasurementSize:]
+		binary.LittleEndian.PutUint32(record, math.Float32bits(float32(m.AngleDeg)))
+		binary.LittleEndian.PutUint32(record[4:], math.Float32bits(float32(m.DistanceMM)))
+		record[8] = m.Quality
+	}
+
+	encoded := make([]byte, blackBoxFrameHeaderSize, blackBoxFrameHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(encoded, blackBoxFrameMagic)
+	binary.LittleEndian.PutUint32(encoded[4:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(encoded[8:], crc32.ChecksumIEEE(payload))
+	binary.LittleEndian.PutUint64(encoded[12:], frame.Seq)
+	binary.LittleEndian.PutUint64(encoded[20:], uint64(frame.Timestamp.UnixNano()))
+	return append(encoded, payload...)
+}
+
+// ReadBlackBox reads every intact frame of the black box ring at path, oldest first. Frames that were partially
+// overwritten, or torn by a crash while being written, fail their checksum and are skipped.
+func ReadBlackBox(path string) ([]BlackBoxFrame, error) {
+	//nolint:gosec
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer goutils.UncheckedErrorFunc(file.Close)
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < blackBoxHeaderSize || !bytes.Equal(data[:4], blackBoxMagic) {
+		return nil, errors.Errorf("%v is not a black box file", path)
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != blackBoxVersion {
+		return nil, errors.Errorf("unsupported black box version %d", version)
+	}
+	ring := data[blackBoxHeaderSize:]
+
+	var frames []BlackBoxFrame
+	for offset := 0; offset+blackBoxFrameHeaderSize <= len(ring); {
+		frame, size, ok := decodeBlackBoxFrame(ring[offset:])
+		if !ok {
+			offset++
+			continue
+		}
+		frames = append(frames, frame)
+		offset += size
+	}
+	sort.SliceStable(frames, func(i, j int) bool {
+		return frames[i].Seq < frames[j].Seq
+	})
+	return frames, nil
+}
+
+// decodeBlackBoxFrame decodes the frame at the start of data, returning its size in bytes, or false if data does
+// not start with an intact frame.
+func decodeBlackBoxFrame(data []byte) (BlackBoxFrame, int, bool) {
+	if binary.LittleEndian.Uint32(data) != blackBoxFrameMagic {
+		return BlackBoxFrame{}, 0, false
+	}
+	length := int64(binary.LittleEndian.Uint32(data[4:]))
+	if length%blackBoxMeasurementSize != 0 || blackBoxFrameHeaderSize+length > int64(len(data)) {
+		return BlackBoxFrame{}, 0, false
+	}
+	payload := data[blackBoxFrameHeaderSize : blackBoxFrameHeaderSize+length]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(data[8:]) {
+		return BlackBoxFrame{}, 0, false
+	}
+
+	frame := BlackBoxFrame{
+		Seq:          binary.LittleEndian.Uint64(data[12:]),
+		Timestamp:    time.Unix(0, int64(binary.LittleEndian.Uint64(data[20:]))),
+		Measurements: make([]Measurement, length/blackBoxMeasurementSize),
+	}
+	for i := range frame.Measurements {
+		record := payload[i*blackBoxMeasurementSize:]
+		frame.Measurements[i] = Measurement{
+			AngleDeg:   float64(math.Float32frombits(binary.LittleEndian.Uint32(record))),
+			DistanceMM: float64(math.Float32frombits(binary.LittleEndian.Uint32(record[4:]))),
+			Quality:    record[8],
+		}
+	}
+	return frame, blackBoxFrameHeaderSize + int(length), true
+}
@@ -0,0 +1,183 @@
+package rplidar
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+func TestBlackBox(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	start := time.Unix(1700000000, 0)
+	// revolution returns the measurements of a revolution whose distances identify it.
+	revolution := func(i int) []measurement {
+		return []measurement{
+			{angleDeg: 0.5, distanceMM: float64(1000 + i), quality: 47},
+			{angleDeg: 180.25, distanceMM: float64(2000 + i), quality: 12},
+		}
+	}
+	// Every frame of two measurements takes 28 + 2 * 9 = 46 bytes, so a ring of 32 + 200 bytes holds four.
+	const frameSize, ringSize = 46, blackBoxHeaderSize + 200
+
+	writeRevolutions := func(b *blackBox, from, to int) {
+		for i := from; i < to; i++ {
+			b.write(BlackBoxFrame{
+				Timestamp:    start.Add(time.Duration(i) * 100 * time.Millisecond),
+				Measurements: exportMeasurements(revolution(i)),
+			})
+		}
+	}
+	distancesOf := func(frames []BlackBoxFrame) []float64 {
+		var distances []float64
+		for _, frame := range frames {
+			distances = append(distances, frame.Measurements[0].DistanceMM)
+		}
+		return distances
+	}
+
+	t.Run("frames are read back in order", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "blackbox.bin")
+		b, err := openBlackBox(path, ringSize, logger)
+		test.That(t, err, test.ShouldBeNil)
+		writeRevolutions(b, 0, 3)
+		test.That(t, b.close(), test.ShouldBeNil)
+
+		frames, err := ReadBlackBox(path)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(frames), test.ShouldEqual, 3)
+		test.That(t, frames[1].Seq, test.ShouldEqual, 1)
+		test.That(t, frames[1].Timestamp.Equal(start.Add(100*time.Millisecond)), test.ShouldBeTrue)
+		test.That(t, frames[1].Measurements, test.ShouldResemble, exportMeasurements(revolution(1)))
+
+		info, err := os.Stat(path)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, info.Size(), test.ShouldEqual, ringSize)
+	})
+
+	t.Run("the oldest frames are overwritten, also across restarts", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "blackbox.bin")
+		b, err := openBlackBox(path, ringSize, logger)
+		test.That(t, err, test.ShouldBeNil)
+		writeRevolutions(b, 0, 6)
+		test.That(t, b.close(), test.ShouldBeNil)
+
+		frames, err := ReadBlackBox(path)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, distancesOf(frames), test.ShouldResemble, []float64{1002, 1003, 1004, 1005})
+
+		b, err = openBlackBox(path, ringSize, logger)
+		test.That(t, err, test.ShouldBeNil)
+		writeRevolutions(b, 6, 7)
+		test.That(t, b.close(), test.ShouldBeNil)
+
+		frames, err = ReadBlackBox(path)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, distancesOf(frames), test.ShouldResemble, []float64{1003, 1004, 1005, 1006})
+		test.That(t, frames[3].Seq, test.ShouldEqual, 6)
+	})
+
+	t.Run("torn frames are skipped", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "blackbox.bin")
+		b, err := openBlackBox(path, ringSize, logger)
+		test.That(t, err, test.ShouldBeNil)
+		writeRevolutions(b, 0, 3)
+		test.That(t, b.close(), test.ShouldBeNil)
+
+		data, err := os.ReadFile(path)
+		test.That(t, err, test.ShouldBeNil)
+		data[blackBoxHeaderSize+frameSize+blackBoxFrameHeaderSize]++
+		test.That(t, os.WriteFile(path, data, 0o600), test.ShouldBeNil)
+
+		frames, err := ReadBlackBox(path)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, distancesOf(frames), test.ShouldResemble, []float64{1000, 1002})
+	})
+
+	t.Run("a ring of another size is replaced", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "blackbox.bin")
+		b, err := openBlackBox(path, ringSize, logger)
+		test.That(t, err, test.ShouldBeNil)
+		writeRevolutions(b, 0, 3)
+		test.That(t, b.close(), test.ShouldBeNil)
+
+		b, err = openBlackBox(path, 2*ringSize, logger)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, b.close(), test.ShouldBeNil)
+		frames, err := ReadBlackBox(path)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, frames, test.ShouldBeEmpty)
+	})
+
+	t.Run("frames are written in the background and dropped when the queue is full", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "blackbox.bin")
+		b, err := openBlackBox(path, ringSize, logger)
+		test.That(t, err, test.ShouldBeNil)
+		for i := 0; i < defaultBlackBoxQueueSize+2; i++ {
+			b.record(revolution(i), start)
+		}
+		test.That(t, b.droppedCount(), test.ShouldEqual, 2)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			b.run(ctx)
+			close(done)
+		}()
+		for len(b.frames.queue) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		<-done
+		test.That(t, b.close(), test.ShouldBeNil)
+
+		frames, err := ReadBlackBox(path)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(frames), test.ShouldBeGreaterThan, 0)
+	})
+
+	t.Run("frames are encoded little endian on any host", func(t *testing.T) {
+		frame := BlackBoxFrame{
+			Seq:          3,
+			Timestamp:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			Measurements: []Measurement{{AngleDeg: 90, DistanceMM: 1500, Quality: 47}},
+		}
+		encoded := []byte{
+			0x52, 0x46, 0x50, 0x52, // magic
+			0x09, 0x00, 0x00, 0x00, // length
+			0xe8, 0xc4, 0x35, 0x6b, // CRC-32
+			0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // seq
+			0x00, 0x80, 0x63, 0xf8, 0x99, 0x5b, 0xcb, 0x17, // timestamp
+			0x00, 0x00, 0xb4, 0x42, // 90
+			0x00, 0x80, 0xbb, 0x44, // 1500
+			0x2f, // 47
+		}
+		test.That(t, encodeBlackBoxFrame(frame), test.ShouldResemble, encoded)
+
+		decoded, size, ok := decodeBlackBoxFrame(encoded)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, size, test.ShouldEqual, len(encoded))
+		test.That(t, decoded.Seq, test.ShouldEqual, frame.Seq)
+		test.That(t, decoded.Timestamp.Equal(frame.Timestamp), test.ShouldBeTrue)
+		test.That(t, decoded.Measurements, test.ShouldResemble, frame.Measurements)
+	})
+
+	t.Run("not a black box file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "scan.pcd")
+		test.That(t, os.WriteFile(path, []byte("VERSION .7\nFIELDS x y z\n"), 0o600), test.ShouldBeNil)
+		_, err := ReadBlackBox(path)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "is not a black box file")
+	})
+
+	t.Run("nil black boxes do nothing", func(t *testing.T) {
+		var b *blackBox
+		b.record(revolution(0), start)
+		test.That(t, b.droppedCount(), test.ShouldEqual, 0)
+		test.That(t, b.close(), test.ShouldBeNil)
+	})
+}
@@ -0,0 +1,52 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// ErrDeviceBusy is returned when the rplidar's serial port is held by another process. Closing the camera in that
+// process releases the port, after which the camera can be constructed again.
+var ErrDeviceBusy = errors.New("device busy")
+
+// procDir is where the open file descriptors of running processes are listed.
+const procDir = "/proc"
+
+// processesHoldingDevice returns the IDs of the processes, other than the current one, that hold devicePath open,
+// found by looking through their file descriptors in procDir. Processes whose file descriptors cannot be read,
+// e.g. those of other users when not running as root, are skipped, as are systems without procDir.
+func processesHoldingDevice(procDir, devicePath string) []int {
+	target := devicePath
+	if resolved, err := filepath.EvalSymlinks(devicePath); err == nil {
+		target = resolved
+	}
+
+	processes, err := os.ReadDir(procDir)
+	if err != nil {
+		return nil
+	}
+	var pids []int
+	for _, process := range processes {
+		pid, err := strconv.Atoi(process.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		fdDir := filepath.Join(procDir, process.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join(fdDir, fd.Name())); err == nil && link == target {
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+	sort.Ints(pids)
+	return pids
+}
@@ -0,0 +1,52 @@
+package rplidar
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestProcessesHoldingDevice(t *testing.T) {
+	dir := t.TempDir()
+	device := filepath.Join(dir, "ttyUSB0")
+	other := filepath.Join(dir, "ttyUSB1")
+	for _, path := range []string{device, other} {
+		f, err := os.Create(path)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, f.Close(), test.ShouldBeNil)
+	}
+	stableLink := filepath.Join(dir, "usb-Silicon_Labs_CP2102")
+	test.That(t, os.Symlink(device, stableLink), test.ShouldBeNil)
+
+	proc := filepath.Join(dir, "proc")
+	openFile := func(pid int, fd int, path string) {
+		fdDir := filepath.Join(proc, strconv.Itoa(pid), "fd")
+		test.That(t, os.MkdirAll(fdDir, 0o700), test.ShouldBeNil)
+		test.That(t, os.Symlink(path, filepath.Join(fdDir, strconv.Itoa(fd))), test.ShouldBeNil)
+	}
+	openFile(200, 3, device)
+	openFile(200, 4, device)
+	openFile(100, 5, device)
+	openFile(300, 3, other)
+	openFile(os.Getpid(), 3, device)
+	test.That(t, os.MkdirAll(filepath.Join(proc, "self"), 0o700), test.ShouldBeNil)
+
+	t.Run("other processes holding the device", func(t *testing.T) {
+		test.That(t, processesHoldingDevice(proc, device), test.ShouldResemble, []int{100, 200})
+	})
+
+	t.Run("device given by a symlink", func(t *testing.T) {
+		test.That(t, processesHoldingDevice(proc, stableLink), test.ShouldResemble, []int{100, 200})
+	})
+
+	t.Run("device not held", func(t *testing.T) {
+		test.That(t, processesHoldingDevice(proc, filepath.Join(dir, "ttyUSB2")), test.ShouldBeEmpty)
+	})
+
+	t.Run("no proc directory", func(t *testing.T) {
+		test.That(t, processesHoldingDevice(filepath.Join(dir, "missing"), device), test.ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,180 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+)
+
+const (
+	// The number of consecutive revolutions whose fitted feature must agree for the calibration to be stable.
+	calibrationStableScans = 5
+	// The most the fitted directions of the stable revolutions may deviate from their mean.
+	calibrationStableDeg = 0.5
+	// The number of batches of revolutions collected before giving up on finding a stable feature.
+	calibrationMaxBatches = 10
+	// The fewest returns a revolution needs within the hint's tolerance to fit the feature.
+	calibrationMinPoints = 10
+	// The largest root mean square distance of the returns to the fitted line for the feature to count as flat.
+	calibrationMaxResidualMM = 25.
+	// The tolerance used when the hint does not specify one.
+	defaultCalibrationToleranceDeg = 30.
+)
+
+// ErrNoStableFeature is wrapped by the error CalibrateAngleOffset returns when it could not find a flat feature
+// whose direction stays stable over consecutive revolutions.
+var ErrNoStableFeature = errors.New("no stable feature found")
+
+// AngularHint describes a flat feature, like a wall, in front of the sensor that CalibrateAngleOffset aligns the
+// scans to.
+type AngularHint struct {
+	// DirectionDeg is the expected direction of the perpendicular from the sensor to the feature, in degrees, using
+	// the convention of AngularSector.
+	DirectionDeg float64
+	// ToleranceDeg limits the returns used to fit the feature to those within this many degrees of DirectionDeg.
+	// Defaults to 30.
+	ToleranceDeg float64
+}
+
+// CalibrateAngleOffset collects revolutions until a flat feature near the direction of expected is found in
+// consecutive revolutions at a stable direction, then returns the angle_offset_deg to configure so that the
+// feature appears at the expected direction. The returned offset includes the currently configured one. It returns
+// an error wrapping ErrNoStableFeature if no stable feature is found.
+func (rp *rplidar) CalibrateAngleOffset(ctx context.Context, expected AngularHint) (float64, error) {
+	if expected.ToleranceDeg < 0 || expected.ToleranceDeg >= 90 {
+		return 0, errors.New("ToleranceDeg must be between 0 and 90")
+	}
+	if expected.ToleranceDeg == 0 {
+		expected.ToleranceDeg = defaultCalibrationToleranceDeg
+	}
+
+	var lastErr error
+	for batch := 0; batch < calibrationMaxBatches; batch++ {
+		scans, _, err := rp.NextN(ctx, calibrationStableScans)
+		if err != nil {
+			return 0, err
+		}
+
+		measured, err := stableFeatureDeg(scans, expected, rp.originOffset)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		correctionDeg := SignedAngleDeg(expected.DirectionDeg - measured)
+		// The offset is added to the rplidar's clockwise angles, which turns a right-handed point cloud clockwise
+		// and a left-handed one counterclockwise, unless the axis map mirrors it once more.
+		if (rp.handedness == leftHanded) != rp.axisMap.mirrorsXY() {
+			return SignedAngleDeg(rp.angleOffsetDeg + correctionDeg), nil
+		}
+		return SignedAngleDeg(rp.angleOffsetDeg - correctionDeg), nil
+	}
+	return 0, fmt.Errorf("%w after %d revolutions: %v", ErrNoStableFeature,
+		calibrationMaxBatches*calibrationStableScans, lastErr)
+}
+
+// stableFeatureDeg fits the feature described by hint in every scan, as seen from the sensor at origin, and returns
+// the mean of the fitted directions, or an error if the feature cannot be fitted in one of the scans or the
+// directions deviate too much.
+func stableFeatureDeg(scans []pointcloud.PointCloud, hint AngularHint, origin r3.Vector) (float64, error) {
+	var sumSin, sumCos float64
+	directions := make([]float64, 0, len(scans))
+	for _, scan := range scans {
+		direction, err := fitFlatFeatureDeg(scan, hint, origin)
+		if err != nil {
+			return 0, err
+		}
+		directions = append(directions, direction)
+		sumSin += math.Sin(direction * math.Pi / 180)
+		sumCos += math.Cos(direction * math.Pi / 180)
+	}
+
+	mean := math.Atan2(sumSin, sumCos) * 180 / math.Pi
+	for _, direction := range directions {
+		if math.Abs(SignedAngleDeg(direction-mean)) > calibrationStableDeg {
+			return 0, errors.New("the direction of the feature is not stable")
+		}
+	}
+	return mean, nil
+}
+
+// fitFlatFeatureDeg fits a line to the returns of scan within the hint's tolerance and returns the direction of
+// the perpendicular from the sensor at origin to it, in degrees, using the convention of AngularSector.
+func fitFlatFeatureDeg(scan pointcloud.PointCloud, hint AngularHint, origin r3.Vector) (float64, error) {
+	sector := AngularSector{StartDeg: hint.DirectionDeg - hint.ToleranceDeg, EndDeg: hint.DirectionDeg + hint.ToleranceDeg}
+	var points []r3.Vector
+	scan.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		p = p.Sub(origin)
+		if (p.X != 0 || p.Y != 0) && sector.contains(math.Atan2(p.Y, p.X)*180/math.Pi) {
+			points = append(points, p)
+		}
+		return true
+	})
+	if len(points) < calibrationMinPoints {
+		return 0, fmt.Errorf("only %d returns near the expected direction, need at least %d",
+			len(points), calibrationMinPoints)
+	}
+
+	line := FitLine(points)
+	if line.ResidualMM > calibrationMaxResidualMM {
+		return 0, fmt.Errorf("the returns near the expected direction are not flat, %.1fmm from the fitted line",
+			line.ResidualMM)
+	}
+	return line.DirectionDeg, nil
+}
+
+// LineFit is a line fitted to points in the XY plane by FitLine.
+type LineFit struct {
+	// DistanceMM is the perpendicular distance from the origin to the line.
+	DistanceMM float64
+	// DirectionDeg is the direction of the perpendicular from the origin to the line, in degrees counterclockwise
+	// from the X axis, in [-180, 180).
+	DirectionDeg float64
+	// ResidualMM is the root mean square distance of the points to the line.
+	ResidualMM float64
+}
+
+// FitLine fits a line to the X and Y of points, in millimeters, minimizing their perpendicular distances to it, along
+// the principal axis of their covariance, e.g. to measure a flat wall. The fit is meaningless for fewer than two
+// distinct points.
+func FitLine(points []r3.Vector) LineFit {
+	if len(points) == 0 {
+		return LineFit{}
+	}
+	var centroid r3.Vector
+	for _, p := range points {
+		centroid = centroid.Add(p)
+	}
+	n := float64(len(points))
+	centroid = centroid.Mul(1 / n)
+
+	var sxx, syy, sxy float64
+	for _, p := range points {
+		dx, dy := p.X-centroid.X, p.Y-centroid.Y
+		sxx += dx * dx
+		syy += dy * dy
+		sxy += dx * dy
+	}
+	sxx, syy, sxy = sxx/n, syy/n, sxy/n
+
+	// The normal of the line, pointing away from the origin.
+	normal := 0.5*math.Atan2(2*sxy, sxx-syy) + math.Pi/2
+	if math.Cos(normal)*centroid.X+math.Sin(normal)*centroid.Y < 0 {
+		normal += math.Pi
+	}
+	return LineFit{
+		DistanceMM:   math.Cos(normal)*centroid.X + math.Sin(normal)*centroid.Y,
+		DirectionDeg: SignedAngleDeg(normal * 180 / math.Pi),
+		// The variance across the line is the smaller eigenvalue of the covariance.
+		ResidualMM: math.Sqrt(math.Max(0, (sxx+syy)/2-math.Hypot((sxx-syy)/2, sxy))),
+	}
+}
+
+// SignedAngleDeg normalizes an angle in degrees into [-180, 180).
+func SignedAngleDeg(angleDeg float64) float64 {
+	return normalizeAngleDeg(angleDeg+180) - 180
+}
@@ -0,0 +1,151 @@
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+// wallScan returns a scan of a flat wall 1m away whose perpendicular from the sensor points at directionDeg.
+func wallScan(t *testing.T, directionDeg float64) pointcloud.PointCloud {
+	t.Helper()
+	rad := directionDeg * math.Pi / 180
+	normal := r3.Vector{X: math.Cos(rad), Y: math.Sin(rad)}
+	along := r3.Vector{X: -normal.Y, Y: normal.X}
+	pc := pointcloud.New()
+	for offset := -500.; offset <= 500; offset += 50 {
+		test.That(t, pc.Set(normal.Mul(1000).Add(along.Mul(offset)), nil), test.ShouldBeNil)
+	}
+	return pc
+}
+
+func TestFitFlatFeatureDeg(t *testing.T) {
+	for _, direction := range []float64{0, 90, 93, -120, 179} {
+		fitted, err := fitFlatFeatureDeg(wallScan(t, direction), AngularHint{DirectionDeg: direction, ToleranceDeg: 30}, r3.Vector{})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, SignedAngleDeg(fitted-direction), test.ShouldAlmostEqual, 0, 1e-6)
+	}
+
+	t.Run("directions are seen from the origin offset", func(t *testing.T) {
+		origin := r3.Vector{X: 300, Y: -200}
+		scan := pointcloud.New()
+		wallScan(t, 90).Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			test.That(t, scan.Set(p.Add(origin), d), test.ShouldBeNil)
+			return true
+		})
+		fitted, err := fitFlatFeatureDeg(scan, AngularHint{DirectionDeg: 90, ToleranceDeg: 30}, origin)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, fitted, test.ShouldAlmostEqual, 90, 1e-6)
+	})
+
+	t.Run("too few returns near the expected direction", func(t *testing.T) {
+		_, err := fitFlatFeatureDeg(wallScan(t, 90), AngularHint{DirectionDeg: -90, ToleranceDeg: 30}, r3.Vector{})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "only 0 returns")
+	})
+
+	t.Run("returns that are not flat", func(t *testing.T) {
+		corner := pointcloud.New()
+		for i := 0.; i <= 500; i += 50 {
+			test.That(t, corner.Set(r3.Vector{X: 1000 - i, Y: i}, nil), test.ShouldBeNil)
+			test.That(t, corner.Set(r3.Vector{X: 1000 - i, Y: -i}, nil), test.ShouldBeNil)
+		}
+		_, err := fitFlatFeatureDeg(corner, AngularHint{DirectionDeg: 0, ToleranceDeg: 45}, r3.Vector{})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "not flat")
+	})
+}
+
+func TestFitLine(t *testing.T) {
+	// A wall 1m away along the perpendicular at -120 degrees, with returns 10mm in front of and behind it.
+	rad := -120 * math.Pi / 180
+	normal := r3.Vector{X: math.Cos(rad), Y: math.Sin(rad)}
+	along := r3.Vector{X: -normal.Y, Y: normal.X}
+	var points []r3.Vector
+	for offset := -500.; offset <= 500; offset += 50 {
+		points = append(points, normal.Mul(990).Add(along.Mul(offset)), normal.Mul(1010).Add(along.Mul(offset)))
+	}
+
+	line := FitLine(points)
+	test.That(t, line.DirectionDeg, test.ShouldAlmostEqual, -120, 1e-6)
+	test.That(t, line.DistanceMM, test.ShouldAlmostEqual, 1000, 1e-6)
+	test.That(t, line.ResidualMM, test.ShouldAlmostEqual, 10, 1e-6)
+}
+
+func TestSignedAngleDeg(t *testing.T) {
+	test.That(t, SignedAngleDeg(350), test.ShouldEqual, -10)
+	test.That(t, SignedAngleDeg(-190), test.ShouldEqual, 170)
+	test.That(t, SignedAngleDeg(180), test.ShouldEqual, -180)
+	test.That(t, SignedAngleDeg(10), test.ShouldEqual, 10)
+}
+
+func TestCalibrateAngleOffset(t *testing.T) {
+	// storeWalls keeps storing scans of walls at the given directions in turn until stop is closed.
+	storeWalls := func(cache *dataCache, stop chan struct{}, directions ...float64) {
+		scans := make([]pointcloud.PointCloud, len(directions))
+		for i, direction := range directions {
+			scans[i] = wallScan(t, direction)
+		}
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				case <-time.After(time.Millisecond):
+				}
+				cache.mutex.Lock()
+				cache.pointCloud = scans[i%len(scans)]
+				cache.notifyUpdated()
+				cache.mutex.Unlock()
+			}
+		}()
+	}
+	ctx := context.Background()
+
+	t.Run("corrects the configured offset", func(t *testing.T) {
+		for _, tc := range []struct {
+			handedness string
+			axisMap    string
+			offsetDeg  float64
+		}{
+			{rightHanded, "", 5},
+			{leftHanded, "", -1},
+			// Rotating the point cloud keeps the sense of the offset, mirroring it flips the sense.
+			{rightHanded, "y,-x,z", 5},
+			{rightHanded, "y,x,z", -1},
+			{leftHanded, "y,x,z", 5},
+		} {
+			axes, err := parseAxisMap(tc.axisMap)
+			test.That(t, err, test.ShouldBeNil)
+			rp := rplidar{cache: &dataCache{}, handedness: tc.handedness, axisMap: axes, angleOffsetDeg: 2}
+			stop := make(chan struct{})
+			storeWalls(rp.cache, stop, 93)
+			offset, err := rp.CalibrateAngleOffset(ctx, AngularHint{DirectionDeg: 90})
+			close(stop)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, offset, test.ShouldAlmostEqual, tc.offsetDeg, 1e-6)
+		}
+	})
+
+	t.Run("fails without a stable feature", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{}}
+		stop := make(chan struct{})
+		defer close(stop)
+		storeWalls(rp.cache, stop, 90, 95)
+		_, err := rp.CalibrateAngleOffset(ctx, AngularHint{DirectionDeg: 90})
+		test.That(t, errors.Is(err, ErrNoStableFeature), test.ShouldBeTrue)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "not stable")
+	})
+
+	t.Run("invalid tolerance", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{}}
+		_, err := rp.CalibrateAngleOffset(ctx, AngularHint{ToleranceDeg: 90})
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
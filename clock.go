@@ -0,0 +1,36 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"time"
+
+	goutils "go.viam.com/utils"
+)
+
+// clock is the source of time for the time dependent logic, such as the scan rate, the scan interval and the
+// motor ramp, so that tests can replace real time with a clock they drive.
+type clock interface {
+	Now() time.Time
+	// Wait waits for d to pass, returning false if ctx is done first.
+	Wait(ctx context.Context, d time.Duration) bool
+}
+
+// realClock is the clock of the wall time.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Wait(ctx context.Context, d time.Duration) bool {
+	return goutils.SelectContextOrWait(ctx, d)
+}
+
+// clockOrReal returns c, or the real clock if c is nil, so that a nil clock field means real time.
+func clockOrReal(c clock) clock {
+	if c == nil {
+		return realClock{}
+	}
+	return c
+}
@@ -0,0 +1,43 @@
+// Package standalone constructs the rplidar camera for the terminal tools, which run it outside of a robot.
+package standalone
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rplidar"
+)
+
+// OpenCamera constructs the rplidar camera configured by conf and returns it as T, the part of the camera the tool
+// uses, along with a function that closes it. Closing the camera stops the motor, so the closer does not use ctx,
+// stopping the motor also after Ctrl-C cancelled ctx, and logs the error closing may fail with.
+func OpenCamera[T any](ctx context.Context, conf *rplidar.Config, logger logging.Logger) (T, func(), error) {
+	var cam T
+	reg, ok := resource.LookupRegistration(camera.API, rplidar.Model)
+	if !ok {
+		return cam, nil, errors.Errorf("%v is not registered", rplidar.Model)
+	}
+	res, err := reg.Constructor(ctx, nil, resource.Config{
+		Name:                "rplidar",
+		API:                 camera.API,
+		Model:               rplidar.Model,
+		ConvertedAttributes: conf,
+	}, logger)
+	if err != nil {
+		return cam, nil, err
+	}
+	closeCamera := func() {
+		if err := res.Close(context.Background()); err != nil {
+			logger.Error(err)
+		}
+	}
+	cam, ok = res.(T)
+	if !ok {
+		closeCamera()
+		return cam, nil, errors.Errorf("expected an rplidar camera, got %T", res)
+	}
+	return cam, closeCamera, nil
+}
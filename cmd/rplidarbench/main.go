@@ -0,0 +1,171 @@
+// Package main is a terminal tool that scans with an rplidar for a fixed duration and reports the throughput the
+// host sustained, for checking that a host, cable and scan mode keep up before deploying them. It writes no files.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rplidar"
+	"go.viam.com/rplidar/cmd/internal/standalone"
+
+	"go.viam.com/utils"
+)
+
+// The number of scans requested from the camera at a time. Scans are delivered as they are completed, so this
+// only bounds how many point clouds are held at once.
+const batchSize = 10
+
+// benchCamera is the part of the rplidar camera the benchmark measures.
+type benchCamera interface {
+	NextN(ctx context.Context, n int) ([]pointcloud.PointCloud, []rplidar.ScanMeta, error)
+	Stats() rplidar.DeviceStats
+}
+
+func main() {
+	utils.ContextualMain(mainWithArgs, logging.NewLogger("rplidarbench"))
+}
+
+func mainWithArgs(ctx context.Context, args []string, logger logging.Logger) error {
+	logger.Infof("%v built against rplidar SDK %v", args[0], rplidar.SDKVersion())
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	serialPath := flags.String("serial-path", "", "serial path of the rplidar, detected over USB if empty")
+	protocol := flags.String("express-protocol", "", "express protocol to scan with: auto, legacy, extended or standard")
+	duration := flags.Duration("duration", 30*time.Second, "how long to measure for, after the warm up")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *duration <= 0 {
+		return errors.New("duration must be positive")
+	}
+
+	conf := &rplidar.Config{SerialPath: *serialPath, ExpressProtocol: *protocol}
+	cam, closeCamera, err := standalone.OpenCamera[benchCamera](ctx, conf, logger)
+	if err != nil {
+		return err
+	}
+	defer closeCamera()
+
+	logger.Infof("measuring for %v", *duration)
+	r, err := measure(ctx, cam, *duration)
+	if err != nil {
+		return err
+	}
+	r.print(os.Stdout)
+	return nil
+}
+
+// report is the throughput sustained over a benchmark.
+type report struct {
+	elapsed time.Duration
+	// The counters of the camera's stats accumulated during the benchmark.
+	scans, failedScans, overflows, protocolSwitches int
+	points                                          int
+	// The scans the benchmark did not receive, e.g. while it was handing a batch over.
+	missedScans int
+	// The samples each stage dropped, keyed as in ScanMeta.DroppedPoints.
+	droppedPoints map[string]int
+	// The time between consecutive scans of the same batch.
+	interArrivals []time.Duration
+}
+
+// measure collects scans from cam for duration and reports the throughput. Stopping early because ctx is done
+// reports what was measured until then.
+func measure(ctx context.Context, cam benchCamera, duration time.Duration) (report, error) {
+	before := cam.Stats()
+	start := time.Now()
+	benchCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	var batches [][]rplidar.ScanMeta
+	for benchCtx.Err() == nil {
+		_, batch, err := cam.NextN(benchCtx, batchSize)
+		batches = append(batches, batch)
+		if err != nil && !errors.Is(err, rplidar.ErrPartialBatch) {
+			return report{}, err
+		}
+	}
+	return summarize(batches, before, cam.Stats(), time.Since(start)), nil
+}
+
+// summarize builds the report of the batches of scans received over elapsed, with the camera's stats before and
+// after.
+func summarize(batches [][]rplidar.ScanMeta, before, after rplidar.DeviceStats, elapsed time.Duration) report {
+	r := report{
+		elapsed:          elapsed,
+		scans:            after.Scans - before.Scans,
+		failedScans:      after.FailedScans - before.FailedScans,
+		overflows:        after.Overflows - before.Overflows,
+		protocolSwitches: after.ProtocolSwitches - before.ProtocolSwitches,
+		droppedPoints:    map[string]int{},
+	}
+	var previous *rplidar.ScanMeta
+	for _, batch := range batches {
+		for i, meta := range batch {
+			r.points += meta.PointCount
+			for stage, count := range meta.DroppedPoints {
+				r.droppedPoints[stage] += count
+			}
+			if previous != nil {
+				if gap := int(meta.Seq - previous.Seq); gap > 1 {
+					r.missedScans += gap - 1
+				} else if i > 0 {
+					// Scans across batches include the time the benchmark took to request the next batch.
+					r.interArrivals = append(r.interArrivals, meta.Timestamp.Sub(previous.Timestamp))
+				}
+			}
+			previous = &batch[i]
+		}
+	}
+	return r
+}
+
+// print writes the report in a human readable form.
+func (r report) print(out io.Writer) {
+	seconds := r.elapsed.Seconds()
+	fmt.Fprintf(out, "measured for %v\n", r.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(out, "scans:             %d (%.2f/s)\n", r.scans, float64(r.scans)/seconds)
+	fmt.Fprintf(out, "points:            %d (%.0f/s)\n", r.points, float64(r.points)/seconds)
+	fmt.Fprintf(out, "failed scans:      %d\n", r.failedScans)
+	fmt.Fprintf(out, "buffer overflows:  %d\n", r.overflows)
+	fmt.Fprintf(out, "protocol switches: %d\n", r.protocolSwitches)
+	fmt.Fprintf(out, "missed scans:      %d\n", r.missedScans)
+	stages := make([]string, 0, len(r.droppedPoints))
+	for stage := range r.droppedPoints {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	dropped := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		dropped = append(dropped, fmt.Sprintf("%v %d", stage, r.droppedPoints[stage]))
+	}
+	fmt.Fprintf(out, "dropped points:    %v\n", strings.Join(dropped, ", "))
+	fmt.Fprintf(out, "inter-arrival:     p50 %v, p99 %v\n",
+		percentile(r.interArrivals, 50).Round(time.Microsecond), percentile(r.interArrivals, 99).Round(time.Microsecond))
+}
+
+// percentile returns the p-th percentile of durations using the nearest rank, or zero if there are none.
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
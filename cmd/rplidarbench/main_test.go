@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.viam.com/rplidar"
+	"go.viam.com/test"
+)
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	batches := [][]rplidar.ScanMeta{
+		{
+			{Seq: 4, Timestamp: start, PointCount: 1000, DroppedPoints: map[string]int{"invalid": 10}},
+			{Seq: 5, Timestamp: start.Add(100 * time.Millisecond), PointCount: 1010, DroppedPoints: map[string]int{"invalid": 5}},
+			// Scan 6 was missed, so no inter-arrival time is derived across the gap.
+			{Seq: 7, Timestamp: start.Add(300 * time.Millisecond), PointCount: 990, DroppedPoints: map[string]int{"min_range_mm": 2}},
+			{Seq: 8, Timestamp: start.Add(420 * time.Millisecond), PointCount: 1000},
+		},
+		// Nor across batches.
+		{
+			{Seq: 9, Timestamp: start.Add(600 * time.Millisecond), PointCount: 1000},
+			{Seq: 10, Timestamp: start.Add(700 * time.Millisecond), PointCount: 1000},
+		},
+	}
+	before := rplidar.DeviceStats{Scans: 3, FailedScans: 1, Overflows: 2}
+	after := rplidar.DeviceStats{Scans: 10, FailedScans: 1, Overflows: 5, ProtocolSwitches: 1}
+
+	r := summarize(batches, before, after, 700*time.Millisecond)
+	test.That(t, r.scans, test.ShouldEqual, 7)
+	test.That(t, r.failedScans, test.ShouldEqual, 0)
+	test.That(t, r.overflows, test.ShouldEqual, 3)
+	test.That(t, r.protocolSwitches, test.ShouldEqual, 1)
+	test.That(t, r.points, test.ShouldEqual, 6000)
+	test.That(t, r.missedScans, test.ShouldEqual, 1)
+	test.That(t, r.droppedPoints, test.ShouldResemble, map[string]int{"invalid": 15, "min_range_mm": 2})
+	test.That(t, r.interArrivals, test.ShouldResemble,
+		[]time.Duration{100 * time.Millisecond, 120 * time.Millisecond, 100 * time.Millisecond})
+
+	var out strings.Builder
+	r.print(&out)
+	test.That(t, out.String(), test.ShouldContainSubstring, "scans:             7 (10.00/s)\n")
+	test.That(t, out.String(), test.ShouldContainSubstring, "points:            6000 (8571/s)\n")
+	test.That(t, out.String(), test.ShouldContainSubstring, "dropped points:    invalid 15, min_range_mm 2\n")
+	test.That(t, out.String(), test.ShouldContainSubstring, "inter-arrival:     p50 100ms, p99 120ms\n")
+}
+
+func TestPercentile(t *testing.T) {
+	test.That(t, percentile(nil, 50), test.ShouldEqual, 0)
+	durations := make([]time.Duration, 0, 100)
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	test.That(t, percentile(durations, 50), test.ShouldEqual, 50*time.Millisecond)
+	test.That(t, percentile(durations, 99), test.ShouldEqual, 99*time.Millisecond)
+	test.That(t, percentile(durations, 100), test.ShouldEqual, 100*time.Millisecond)
+	test.That(t, percentile(durations[:1], 99), test.ShouldEqual, 100*time.Millisecond)
+}
@@ -0,0 +1,69 @@
+// Package main is a terminal tool that extracts the raw scans recorded in an rplidar black box ring, oldest first,
+// for inspecting what the sensor saw before a failure.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rplidar"
+
+	"go.viam.com/utils"
+)
+
+func main() {
+	utils.ContextualMain(mainWithArgs, logging.NewLogger("rplidarblackbox"))
+}
+
+func mainWithArgs(ctx context.Context, args []string, logger logging.Logger) error {
+	logger.Infof("%v built against rplidar SDK %v", args[0], rplidar.SDKVersion())
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	path := flags.String("path", "", "path of the black box file, the black_box_path of the camera")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *path == "" {
+		return errors.New("path is required")
+	}
+
+	frames, err := rplidar.ReadBlackBox(*path)
+	if err != nil {
+		return err
+	}
+	if len(frames) == 0 {
+		logger.Warnf("%v holds no scans", *path)
+	} else {
+		logger.Infof("%v holds %d scans from %v to %v", *path, len(frames),
+			frames[0].Timestamp.Format(time.RFC3339Nano), frames[len(frames)-1].Timestamp.Format(time.RFC3339Nano))
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	if err := writeCSV(out, frames); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// writeCSV writes every measurement of the frames as a CSV line, preceded by a header line.
+func writeCSV(out io.Writer, frames []rplidar.BlackBoxFrame) error {
+	if _, err := fmt.Fprintln(out, "seq,timestamp,angle_deg,distance_mm,quality"); err != nil {
+		return err
+	}
+	for _, frame := range frames {
+		timestamp := frame.Timestamp.UTC().Format(time.RFC3339Nano)
+		for _, m := range frame.Measurements {
+			if _, err := fmt.Fprintf(out, "%d,%s,%g,%g,%d\n",
+				frame.Seq, timestamp, m.AngleDeg, m.DistanceMM, m.Quality); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.viam.com/rplidar"
+	"go.viam.com/test"
+)
+
+func TestWriteCSV(t *testing.T) {
+	frames := []rplidar.BlackBoxFrame{
+		{
+			Seq:       7,
+			Timestamp: time.Date(2023, 1, 1, 12, 0, 0, 500000000, time.UTC),
+			Measurements: []rplidar.Measurement{
+				{AngleDeg: 0.5, DistanceMM: 1000, Quality: 47},
+				{AngleDeg: 180.25, DistanceMM: 2000.75, Quality: 12},
+			},
+		},
+		{Seq: 8, Timestamp: time.Date(2023, 1, 1, 12, 0, 0, 600000000, time.UTC)},
+	}
+
+	var out strings.Builder
+	test.That(t, writeCSV(&out, frames), test.ShouldBeNil)
+	test.That(t, out.String(), test.ShouldEqual, "seq,timestamp,angle_deg,distance_mm,quality\n"+
+		"7,2023-01-01T12:00:00.5Z,0.5,1000,47\n"+
+		"7,2023-01-01T12:00:00.5Z,180.25,2000.75,12\n")
+}
@@ -0,0 +1,319 @@
+// Package main is a terminal tool that characterizes an rplidar against a flat wall at a known distance: it
+// records a number of revolutions, writes their raw returns to a CSV file and reports the measured against the
+// expected distance, the angular spread of the wall and the noise of the returns, for filing with the vendor.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rplidar"
+	"go.viam.com/rplidar/cmd/internal/standalone"
+
+	"go.viam.com/utils"
+)
+
+// The fewest returns a revolution needs on the wall for a line to be fitted to them.
+const minWallReturns = 10
+
+// calCamera is the part of the rplidar camera the calibration records from.
+type calCamera interface {
+	rplidar.MeasurementNotifier
+	Stats() rplidar.DeviceStats
+}
+
+func main() {
+	utils.ContextualMain(mainWithArgs, logging.NewLogger("rplidarcal"))
+}
+
+func mainWithArgs(ctx context.Context, args []string, logger logging.Logger) error {
+	logger.Infof("%v built against rplidar SDK %v", args[0], rplidar.SDKVersion())
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	serialPath := flags.String("serial-path", "", "serial path of the rplidar, detected over USB if empty")
+	distanceMM := flags.Float64("distance-mm", 0, "measured perpendicular distance from the sensor's center to the wall")
+	directionDeg := flags.Float64("direction-deg", 0, "direction of the wall in the rplidar's clockwise angles, 0 is its front")
+	toleranceDeg := flags.Float64("tolerance-deg", 30, "use the returns within this many degrees of direction-deg")
+	scans := flags.Int("scans", 50, "number of revolutions to record")
+	rawPath := flags.String("raw-path", "calibration.csv", "path of the CSV file the raw returns are written to")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *distanceMM <= 0 {
+		return errors.New("distance-mm must be positive")
+	}
+	if *toleranceDeg <= 0 || *toleranceDeg >= 90 {
+		return errors.New("tolerance-deg must be between 0 and 90")
+	}
+	if *scans <= 0 {
+		return errors.New("scans must be positive")
+	}
+	if *rawPath == "" {
+		return errors.New("raw-path is required")
+	}
+
+	conf := &rplidar.Config{SerialPath: *serialPath}
+	cam, closeCamera, err := standalone.OpenCamera[calCamera](ctx, conf, logger)
+	if err != nil {
+		return err
+	}
+	defer closeCamera()
+
+	logger.Infof("recording %d revolutions of the wall %.0fmm away at %g degrees", *scans, *distanceMM, *directionDeg)
+	before := cam.Stats()
+	grabs, err := record(ctx, cam, *scans)
+	if err != nil {
+		return err
+	}
+	after := cam.Stats()
+
+	if err := writeRaw(*rawPath, grabs); err != nil {
+		return err
+	}
+	logger.Infof("wrote the raw returns to %v", *rawPath)
+
+	target := wall{distanceMM: *distanceMM, directionDeg: *directionDeg, toleranceDeg: *toleranceDeg}
+	r, err := analyze(grabs, target)
+	if err != nil {
+		return err
+	}
+	r.failedScans = after.FailedScans - before.FailedScans
+	r.overflows = after.Overflows - before.Overflows
+	r.measurementDrops = after.MeasurementDrops - before.MeasurementDrops
+	r.print(os.Stdout)
+	return nil
+}
+
+// record collects the returns of n grabs from cam. With the rplidar SDK 1.12, a grab is a complete revolution.
+func record(ctx context.Context, cam calCamera, n int) ([][]rplidar.Measurement, error) {
+	received := make(chan []rplidar.Measurement, n)
+	cam.OnMeasurements(func(grab []rplidar.Measurement) {
+		select {
+		case received <- grab:
+		default:
+		}
+	})
+	defer cam.OnMeasurements(nil)
+
+	grabs := make([][]rplidar.Measurement, 0, n)
+	for len(grabs) < n {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case grab := <-received:
+			grabs = append(grabs, grab)
+		}
+	}
+	return grabs, nil
+}
+
+// writeRaw writes every return of the grabs to a CSV file at path, preceded by a header line.
+func writeRaw(path string, grabs [][]rplidar.Measurement) error {
+	//nolint:gosec
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(f)
+	if err := writeCSV(out, grabs); err != nil {
+		utils.UncheckedError(f.Close())
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		utils.UncheckedError(f.Close())
+		return err
+	}
+	return f.Close()
+}
+
+// writeCSV writes every return of the grabs as a CSV line, numbering the grabs from 0.
+func writeCSV(out io.Writer, grabs [][]rplidar.Measurement) error {
+	if _, err := fmt.Fprintln(out, "scan,angle_deg,distance_mm,quality"); err != nil {
+		return err
+	}
+	for i, grab := range grabs {
+		for _, m := range grab {
+			if _, err := fmt.Fprintf(out, "%d,%g,%g,%d\n", i, m.AngleDeg, m.DistanceMM, m.Quality); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// wall is the flat target the rplidar is placed in front of.
+type wall struct {
+	// The perpendicular distance from the sensor's center to the wall.
+	distanceMM float64
+	// The direction of the perpendicular to the wall, in the clockwise angles of rplidar.Measurement.
+	directionDeg float64
+	// Only the returns within this many degrees of directionDeg are taken to be on the wall.
+	toleranceDeg float64
+}
+
+// fit is the line fitted to the returns of one revolution on the wall.
+type fit struct {
+	// The perpendicular distance from the sensor to the line and its direction.
+	distanceMM, directionDeg float64
+	// The root mean square distance of the returns to the line.
+	residualMM float64
+	// The angles between the first and the last return on the wall.
+	spanDeg float64
+	returns int
+}
+
+// report is the characterization of the rplidar against the wall.
+type report struct {
+	target wall
+	// The revolutions recorded and those with too few returns on the wall to be fitted.
+	scans, unfitScans int
+	fits              []fit
+	// The difference of every return on the wall to the range the known distance predicts along its angle, using the
+	// direction of the wall fitted to its revolution.
+	rangeErrorsMM []float64
+	meanQuality   float64
+	// The counters of the camera's stats accumulated during the recording.
+	failedScans, overflows, measurementDrops int
+}
+
+// analyze fits the wall in every grab and compares the fits to the known target. It returns an error if the wall
+// could not be fitted in any of them.
+func analyze(grabs [][]rplidar.Measurement, target wall) (report, error) {
+	r := report{target: target, scans: len(grabs)}
+	var qualitySum float64
+	for _, grab := range grabs {
+		onWall := make([]rplidar.Measurement, 0, len(grab))
+		for _, m := range grab {
+			if math.Abs(rplidar.SignedAngleDeg(m.AngleDeg-target.directionDeg)) <= target.toleranceDeg {
+				onWall = append(onWall, m)
+			}
+		}
+		if len(onWall) < minWallReturns {
+			r.unfitScans++
+			continue
+		}
+		f := fitWall(onWall)
+		r.fits = append(r.fits, f)
+		for _, m := range onWall {
+			expected := target.distanceMM / math.Cos((m.AngleDeg-f.directionDeg)*math.Pi/180)
+			r.rangeErrorsMM = append(r.rangeErrorsMM, m.DistanceMM-expected)
+			qualitySum += float64(m.Quality)
+		}
+	}
+	if len(r.fits) == 0 {
+		return report{}, errors.Errorf("found no wall within %g degrees of %g degrees in any of the %d revolutions, "+
+			"each needs at least %d returns", target.toleranceDeg, target.directionDeg, len(grabs), minWallReturns)
+	}
+	r.meanQuality = qualitySum / float64(len(r.rangeErrorsMM))
+	return r, nil
+}
+
+// fitWall fits a line to the returns with rplidar.FitLine, in the rplidar's own angles.
+func fitWall(returns []rplidar.Measurement) fit {
+	points := make([]r3.Vector, len(returns))
+	minDeg, maxDeg := math.Inf(1), math.Inf(-1)
+	for i, m := range returns {
+		angle := m.AngleDeg * math.Pi / 180
+		points[i] = r3.Vector{X: m.DistanceMM * math.Cos(angle), Y: m.DistanceMM * math.Sin(angle)}
+		// Relative to the first return, so that a wall around 0 degrees does not span the whole circle.
+		relative := rplidar.SignedAngleDeg(m.AngleDeg - returns[0].AngleDeg)
+		minDeg, maxDeg = math.Min(minDeg, relative), math.Max(maxDeg, relative)
+	}
+	line := rplidar.FitLine(points)
+	return fit{
+		distanceMM:   line.DistanceMM,
+		directionDeg: line.DirectionDeg,
+		residualMM:   line.ResidualMM,
+		spanDeg:      maxDeg - minDeg,
+		returns:      len(returns),
+	}
+}
+
+// print writes the report in a human readable form.
+func (r report) print(out io.Writer) {
+	distances := make([]float64, len(r.fits))
+	directionErrors := make([]float64, len(r.fits))
+	var residuals, spans, returns float64
+	for i, f := range r.fits {
+		distances[i] = f.distanceMM
+		directionErrors[i] = rplidar.SignedAngleDeg(f.directionDeg - r.target.directionDeg)
+		residuals += f.residualMM
+		spans += f.spanDeg
+		returns += float64(f.returns)
+	}
+	fits := float64(len(r.fits))
+	meanDistance, stdDistance := meanStd(distances)
+	meanDirection, stdDirection := meanStd(directionErrors)
+	meanRangeError, _ := meanStd(r.rangeErrorsMM)
+	absRangeErrors := make([]float64, len(r.rangeErrorsMM))
+	for i, e := range r.rangeErrorsMM {
+		absRangeErrors[i] = math.Abs(e)
+	}
+
+	fmt.Fprintf(out, "target:             %.1fmm at %g degrees, returns within %g degrees\n",
+		r.target.distanceMM, r.target.directionDeg, r.target.toleranceDeg)
+	fmt.Fprintf(out, "scans:              %d, %d without the wall\n", r.scans, r.unfitScans)
+	fmt.Fprintf(out, "wall returns:       %.1f per scan over %.2f degrees, mean quality %.1f\n",
+		returns/fits, spans/fits, r.meanQuality)
+	fmt.Fprintf(out, "measured distance:  %.1fmm, std %.2fmm\n", meanDistance, stdDistance)
+	fmt.Fprintf(out, "distance error:     %+.1fmm (%+.2f%%)\n",
+		meanDistance-r.target.distanceMM, (meanDistance-r.target.distanceMM)/r.target.distanceMM*100)
+	fmt.Fprintf(out, "range error:        mean %+.1fmm, rms %.1fmm, p99 %.1fmm\n",
+		meanRangeError, rms(r.rangeErrorsMM), percentile(absRangeErrors, 99))
+	fmt.Fprintf(out, "wall direction:     %+.2f degrees off, std %.3f degrees\n", meanDirection, stdDirection)
+	fmt.Fprintf(out, "noise:              %.2fmm rms from the fitted line\n", residuals/fits)
+	fmt.Fprintf(out, "failed scans:       %d\n", r.failedScans)
+	fmt.Fprintf(out, "buffer overflows:   %d\n", r.overflows)
+	fmt.Fprintf(out, "measurement drops:  %d\n", r.measurementDrops)
+}
+
+// meanStd returns the mean and the population standard deviation of values, zero if there are none.
+func meanStd(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+// rms returns the root mean square of values, zero if there are none.
+func rms(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var squares float64
+	for _, v := range values {
+		squares += v * v
+	}
+	return math.Sqrt(squares / float64(len(values)))
+}
+
+// percentile returns the p-th percentile of values using the nearest rank, or zero if there are none.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"go.viam.com/rplidar"
+	"go.viam.com/test"
+)
+
+// wallGrab returns the returns of a revolution facing a wall distanceMM away at directionDeg, one per degree
+// within 20 degrees of it, with every range scaled by scale and every odd one offset by noiseMM.
+func wallGrab(distanceMM, directionDeg, scale, noiseMM float64) []rplidar.Measurement {
+	var grab []rplidar.Measurement
+	for offset := -20; offset <= 20; offset++ {
+		angleDeg := math.Mod(directionDeg+float64(offset)+360, 360)
+		rangeMM := distanceMM / math.Cos(float64(offset)*math.Pi/180) * scale
+		if offset%2 != 0 {
+			rangeMM += noiseMM
+		}
+		grab = append(grab, rplidar.Measurement{AngleDeg: angleDeg, DistanceMM: rangeMM, Quality: 40})
+	}
+	// A return behind the sensor, away from the wall.
+	return append(grab, rplidar.Measurement{AngleDeg: math.Mod(directionDeg+180, 360), DistanceMM: 300, Quality: 40})
+}
+
+func TestFitWall(t *testing.T) {
+	for _, directionDeg := range []float64{0, 90, -135} {
+		f := fitWall(wallGrab(1000, directionDeg, 1, 0)[:41])
+		test.That(t, f.distanceMM, test.ShouldAlmostEqual, 1000, 1e-6)
+		test.That(t, f.directionDeg, test.ShouldAlmostEqual, directionDeg, 1e-6)
+		test.That(t, f.residualMM, test.ShouldAlmostEqual, 0, 1e-6)
+		test.That(t, f.spanDeg, test.ShouldAlmostEqual, 40, 1e-6)
+		test.That(t, f.returns, test.ShouldEqual, 41)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	target := wall{distanceMM: 1000, directionDeg: 0, toleranceDeg: 30}
+
+	t.Run("an accurate unit", func(t *testing.T) {
+		r, err := analyze([][]rplidar.Measurement{wallGrab(1000, 0, 1, 0), wallGrab(1000, 0, 1, 0)}, target)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, r.scans, test.ShouldEqual, 2)
+		test.That(t, r.unfitScans, test.ShouldEqual, 0)
+		test.That(t, len(r.fits), test.ShouldEqual, 2)
+		test.That(t, len(r.rangeErrorsMM), test.ShouldEqual, 2*41)
+		test.That(t, rms(r.rangeErrorsMM), test.ShouldAlmostEqual, 0, 1e-6)
+		test.That(t, r.meanQuality, test.ShouldEqual, 40)
+	})
+
+	t.Run("a unit that overestimates ranges", func(t *testing.T) {
+		r, err := analyze([][]rplidar.Measurement{wallGrab(1000, 0, 1.02, 0), {}}, target)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, r.unfitScans, test.ShouldEqual, 1)
+		test.That(t, r.fits[0].distanceMM, test.ShouldAlmostEqual, 1020, 1e-6)
+		mean, _ := meanStd(r.rangeErrorsMM)
+		test.That(t, mean, test.ShouldBeGreaterThan, 20)
+
+		var out strings.Builder
+		r.print(&out)
+		test.That(t, out.String(), test.ShouldContainSubstring, "scans:              2, 1 without the wall\n")
+		test.That(t, out.String(), test.ShouldContainSubstring, "measured distance:  1020.0mm, std 0.00mm\n")
+		test.That(t, out.String(), test.ShouldContainSubstring, "distance error:     +20.0mm (+2.00%)\n")
+		test.That(t, out.String(), test.ShouldContainSubstring, "wall direction:     +0.00 degrees off, std 0.000 degrees\n")
+	})
+
+	t.Run("a noisy unit", func(t *testing.T) {
+		r, err := analyze([][]rplidar.Measurement{wallGrab(1000, 0, 1, 10)}, target)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, r.fits[0].residualMM, test.ShouldBeGreaterThan, 4)
+		test.That(t, percentile(r.rangeErrorsMM, 100), test.ShouldBeGreaterThan, 5)
+	})
+
+	t.Run("no wall in the direction", func(t *testing.T) {
+		_, err := analyze([][]rplidar.Measurement{wallGrab(1000, 90, 1, 0)}, target)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "found no wall within 30 degrees of 0 degrees")
+	})
+}
+
+func TestWriteCSV(t *testing.T) {
+	var out strings.Builder
+	grabs := [][]rplidar.Measurement{
+		{{AngleDeg: 0.5, DistanceMM: 1000, Quality: 47}},
+		{{AngleDeg: 359.75, DistanceMM: 1001.25, Quality: 12}, {AngleDeg: 1, DistanceMM: 998, Quality: 40}},
+	}
+	test.That(t, writeCSV(&out, grabs), test.ShouldBeNil)
+	test.That(t, out.String(), test.ShouldEqual,
+		"scan,angle_deg,distance_mm,quality\n0,0.5,1000,47\n1,359.75,1001.25,12\n1,1,998,40\n")
+}
@@ -0,0 +1,142 @@
+// Package main is a tool that connects to an rplidar without an RDK robot and publishes a compact summary of
+// every scan to an MQTT topic, for monitoring fleets that report through an MQTT broker.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rplidar"
+	"go.viam.com/rplidar/cmd/internal/standalone"
+
+	"go.viam.com/utils"
+)
+
+// scanCamera is the part of the rplidar camera the publisher reads scans from.
+type scanCamera interface {
+	NextN(ctx context.Context, n int) ([]pointcloud.PointCloud, []rplidar.ScanMeta, error)
+}
+
+// scanMessage is the JSON payload published for every scan.
+type scanMessage struct {
+	Seq        uint64    `json:"seq"`
+	Timestamp  time.Time `json:"timestamp"`
+	PointCount int       `json:"point_count"`
+	// The fraction of the revolution with returns beyond coverage_near_mm, see ScanMeta.
+	Coverage float64 `json:"coverage"`
+	// The nearest return in the XY plane, absent if the scan has no returns.
+	Nearest *obstacle `json:"nearest,omitempty"`
+	// Every cloud_decimation-th point of the scan as [x, y] in millimeters, only present if requested.
+	Points [][2]float64 `json:"points,omitempty"`
+}
+
+// obstacle is a return of a scan, in the directions of rplidar.AngularSector.
+type obstacle struct {
+	DistanceMM   float64 `json:"distance_mm"`
+	DirectionDeg float64 `json:"direction_deg"`
+}
+
+func main() {
+	utils.ContextualMain(mainWithArgs, logging.NewLogger("rplidarmqtt"))
+}
+
+func mainWithArgs(ctx context.Context, args []string, logger logging.Logger) error {
+	logger.Infof("%v built against rplidar SDK %v", args[0], rplidar.SDKVersion())
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	serialPath := flags.String("serial-path", "", "serial path of the rplidar, detected over USB if empty")
+	broker := flags.String("broker", "", "host:port of the MQTT broker, e.g. localhost:1883")
+	topic := flags.String("topic", "rplidar/scans", "MQTT topic to publish the scans to")
+	clientID := flags.String("client-id", "rplidarmqtt", "MQTT client identifier, unique per broker")
+	username := flags.String("username", "", "MQTT username, none if empty")
+	password := flags.String("password", "", "MQTT password, none if empty")
+	keepAlive := flags.Duration("keep-alive", 30*time.Second, "MQTT keep alive interval")
+	cloudDecimation := flags.Int("cloud-decimation", 0, "include every n-th point of each scan, none if 0")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *broker == "" {
+		return errors.New("broker is required")
+	}
+	if *cloudDecimation < 0 {
+		return errors.New("cloud-decimation cannot be negative")
+	}
+	if *keepAlive < time.Second || *keepAlive > math.MaxUint16*time.Second {
+		return errors.New("keep-alive must be between 1s and 18h12m15s")
+	}
+	if _, err := encodeConnect(*clientID, *username, *password, *keepAlive); err != nil {
+		return err
+	}
+
+	conf := &rplidar.Config{SerialPath: *serialPath}
+	cam, closeCamera, err := standalone.OpenCamera[scanCamera](ctx, conf, logger)
+	if err != nil {
+		return err
+	}
+	defer closeCamera()
+
+	pub := &publisher{
+		broker:      *broker,
+		clientID:    *clientID,
+		username:    *username,
+		password:    *password,
+		keepAlive:   *keepAlive,
+		pingTimeout: defaultPingTimeout,
+		logger:      logger,
+	}
+	defer func() {
+		if err := pub.close(); err != nil {
+			logger.Debugf("failed to disconnect from the broker: %v", err)
+		}
+	}()
+
+	logger.Infof("publishing scans to %q on %v", *topic, *broker)
+	for ctx.Err() == nil {
+		scans, metas, err := cam.NextN(ctx, 1)
+		if err != nil {
+			// NextN only fails once ctx is done, e.g. on Ctrl-C.
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		payload, err := json.Marshal(summarize(scans[0], metas[0], *cloudDecimation))
+		if err != nil {
+			return err
+		}
+		// Scans published while the broker is unreachable are dropped, the next one supersedes them.
+		if err := pub.publish(ctx, *topic, payload); err != nil {
+			logger.Debugf("dropped scan %d: %v", metas[0].Seq, err)
+		}
+	}
+	return nil
+}
+
+// summarize builds the message of a scan, with every cloudDecimation-th of its points if cloudDecimation is
+// positive.
+func summarize(scan pointcloud.PointCloud, meta rplidar.ScanMeta, cloudDecimation int) scanMessage {
+	msg := scanMessage{
+		Seq:        meta.Seq,
+		Timestamp:  meta.Timestamp,
+		PointCount: meta.PointCount,
+		Coverage:   meta.Coverage,
+	}
+	var i int
+	scan.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if distanceMM := math.Hypot(p.X, p.Y); distanceMM > 0 && (msg.Nearest == nil || distanceMM < msg.Nearest.DistanceMM) {
+			msg.Nearest = &obstacle{DistanceMM: distanceMM, DirectionDeg: math.Atan2(p.Y, p.X) * 180 / math.Pi}
+		}
+		if cloudDecimation > 0 && i%cloudDecimation == 0 {
+			msg.Points = append(msg.Points, [2]float64{p.X, p.Y})
+		}
+		i++
+		return true
+	})
+	return msg
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rplidar"
+	"go.viam.com/test"
+)
+
+func TestSummarize(t *testing.T) {
+	scan := pointcloud.New()
+	test.That(t, scan.Set(r3.Vector{X: 0, Y: 800}, nil), test.ShouldBeNil)
+	test.That(t, scan.Set(r3.Vector{X: -300, Y: 0}, nil), test.ShouldBeNil)
+	test.That(t, scan.Set(r3.Vector{X: 1000, Y: 1000}, nil), test.ShouldBeNil)
+	meta := rplidar.ScanMeta{
+		Seq:        7,
+		Timestamp:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		PointCount: 3,
+		Coverage:   0.75,
+	}
+
+	msg := summarize(scan, meta, 0)
+	test.That(t, msg.Seq, test.ShouldEqual, 7)
+	test.That(t, msg.PointCount, test.ShouldEqual, 3)
+	test.That(t, msg.Coverage, test.ShouldEqual, 0.75)
+	test.That(t, *msg.Nearest, test.ShouldResemble, obstacle{DistanceMM: 300, DirectionDeg: 180})
+	test.That(t, msg.Points, test.ShouldBeNil)
+
+	payload, err := json.Marshal(msg)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(payload), test.ShouldEqual,
+		`{"seq":7,"timestamp":"2024-05-01T12:00:00Z","point_count":3,"coverage":0.75,"nearest":{"distance_mm":300,"direction_deg":180}}`)
+
+	t.Run("decimated cloud", func(t *testing.T) {
+		test.That(t, len(summarize(scan, meta, 1).Points), test.ShouldEqual, 3)
+		test.That(t, len(summarize(scan, meta, 2).Points), test.ShouldEqual, 2)
+		test.That(t, len(summarize(scan, meta, 5).Points), test.ShouldEqual, 1)
+	})
+
+	t.Run("empty scan", func(t *testing.T) {
+		msg := summarize(pointcloud.New(), meta, 2)
+		test.That(t, msg.Nearest, test.ShouldBeNil)
+		test.That(t, msg.Points, test.ShouldBeNil)
+	})
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/logging"
+
+	"go.viam.com/utils"
+)
+
+// The MQTT 3.1.1 control packet types used by the publisher, already shifted into the fixed header.
+const (
+	packetConnect    = 0x10
+	packetConnack    = 0x20
+	packetPublish    = 0x30
+	packetPingreq    = 0xC0
+	packetPingresp   = 0xD0
+	packetDisconnect = 0xE0
+)
+
+const (
+	// The largest remaining length an MQTT packet can encode.
+	maxRemainingLength = 268435455
+	// How long to wait for the broker to accept a connection.
+	connectTimeout = 10 * time.Second
+	// How long a packet may take to be written before the connection is given up on.
+	writeTimeout = 5 * time.Second
+	// How long to wait for the broker to answer a ping before the connection is given up on.
+	defaultPingTimeout = 5 * time.Second
+	// The longest wait between attempts to reconnect to the broker.
+	maxReconnectBackoff = 30 * time.Second
+)
+
+// publisher publishes messages to an MQTT broker with QoS 0, at most once, which is all a stream of scans needs:
+// a lost scan is superseded by the next one. It connects on the first publish, and after the broker disconnects
+// or stops answering pings it drops messages while reconnecting, backing off exponentially between attempts. It
+// is not safe for concurrent use.
+type publisher struct {
+	broker    string
+	clientID  string
+	username  string
+	password  string
+	keepAlive time.Duration
+	// How long to wait for the broker to answer a ping.
+	pingTimeout time.Duration
+	logger      logging.Logger
+
+	conn net.Conn
+	// The last time the broker answered, to ping it before the keep alive runs out and to notice when it stops
+	// answering. Publishing alone does not show that the broker is still there, since writes keep succeeding on a
+	// connection the broker silently went away from until the operating system gives up on it.
+	lastReceived time.Time
+	// When the next attempt to reconnect may be made and how long to back off after it fails.
+	nextAttempt time.Time
+	backoff     time.Duration
+}
+
+// publish sends payload to topic, connecting to the broker first if needed. It returns an error if the message
+// could not be sent, in which case the connection is closed and reestablished by a later publish.
+func (p *publisher) publish(ctx context.Context, topic string, payload []byte) error {
+	if p.conn == nil {
+		if time.Now().Before(p.nextAttempt) {
+			return errors.New("waiting to reconnect to the broker")
+		}
+		if err := p.connect(ctx); err != nil {
+			p.backOff()
+			p.logger.Warnf("failed to connect to the MQTT broker at %v, retrying in %v: %v", p.broker, p.backoff, err)
+			return err
+		}
+		p.backoff = 0
+		p.logger.Infof("connected to the MQTT broker at %v", p.broker)
+	}
+
+	if p.keepAlive > 0 && time.Since(p.lastReceived) >= p.keepAlive/2 {
+		if err := p.ping(); err != nil {
+			return err
+		}
+	}
+	packet, err := encodePublish(topic, payload)
+	if err != nil {
+		return err
+	}
+	return p.write(packet)
+}
+
+// connect opens a connection to the broker and waits for it to accept the session.
+func (p *publisher) connect(ctx context.Context) error {
+	packet, err := encodeConnect(p.clientID, p.username, p.password, p.keepAlive)
+	if err != nil {
+		return err
+	}
+	dialer := net.Dialer{Timeout: connectTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.broker)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to the broker")
+	}
+	if err := conn.SetDeadline(time.Now().Add(connectTimeout)); err != nil {
+		return closeWith(conn, err)
+	}
+	if _, err := conn.Write(packet); err != nil {
+		return closeWith(conn, errors.Wrap(err, "failed to send the connect packet"))
+	}
+	if err := readConnack(conn); err != nil {
+		return closeWith(conn, err)
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return closeWith(conn, err)
+	}
+	p.conn = conn
+	p.lastReceived = time.Now()
+	return nil
+}
+
+// ping sends a PINGREQ packet and waits for the broker to answer it, closing the connection if it does not answer
+// within the ping timeout.
+func (p *publisher) ping() error {
+	if err := p.write([]byte{packetPingreq, 0}); err != nil {
+		return err
+	}
+	if err := p.conn.SetReadDeadline(time.Now().Add(p.pingTimeout)); err != nil {
+		p.disconnected(err)
+		return err
+	}
+	if err := readPingresp(p.conn); err != nil {
+		p.disconnected(err)
+		return err
+	}
+	p.lastReceived = time.Now()
+	return nil
+}
+
+// write sends packet to the broker, closing the connection if it fails.
+func (p *publisher) write(packet []byte) error {
+	if err := p.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		p.disconnected(err)
+		return err
+	}
+	if _, err := p.conn.Write(packet); err != nil {
+		p.disconnected(err)
+		return errors.Wrap(err, "lost the connection to the broker")
+	}
+	return nil
+}
+
+// disconnected closes the connection after it failed with err, so that the next publish reconnects.
+func (p *publisher) disconnected(err error) {
+	p.logger.Warnf("disconnected from the MQTT broker at %v: %v", p.broker, err)
+	if closeErr := p.conn.Close(); closeErr != nil {
+		p.logger.Debugf("failed to close the connection to the broker: %v", closeErr)
+	}
+	p.conn = nil
+}
+
+// backOff doubles the time until the next attempt to connect, up to maxReconnectBackoff.
+func (p *publisher) backOff() {
+	switch {
+	case p.backoff == 0:
+		p.backoff = time.Second
+	case p.backoff < maxReconnectBackoff:
+		p.backoff *= 2
+		if p.backoff > maxReconnectBackoff {
+			p.backoff = maxReconnectBackoff
+		}
+	}
+	p.nextAttempt = time.Now().Add(p.backoff)
+}
+
+// close ends the session with the broker, if connected.
+func (p *publisher) close() error {
+	if p.conn == nil {
+		return nil
+	}
+	conn := p.conn
+	p.conn = nil
+	if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return closeWith(conn, err)
+	}
+	if _, err := conn.Write([]byte{packetDisconnect, 0}); err != nil {
+		return closeWith(conn, err)
+	}
+	return conn.Close()
+}
+
+// closeWith closes conn and returns err.
+func closeWith(conn net.Conn, err error) error {
+	utils.UncheckedErrorFunc(conn.Close)
+	return err
+}
+
+// encodeConnect returns the CONNECT packet of a clean session for clientID, with the optional credentials.
+func encodeConnect(clientID, username, password string, keepAlive time.Duration) ([]byte, error) {
+	if password != "" && username == "" {
+		return nil, errors.New("a password requires a username")
+	}
+	flags := byte(0x02)
+	body := appendString(nil, "MQTT")
+	body = append(body, 4)
+	if username != "" {
+		flags |= 0x80
+	}
+	if password != "" {
+		flags |= 0x40
+	}
+	body = append(body, flags)
+	body = appendUint16(body, uint16(keepAlive/time.Second))
+	body = appendString(body, clientID)
+	if username != "" {
+		body = appendString(body, username)
+	}
+	if password != "" {
+		body = appendString(body, password)
+	}
+	return encodePacket(packetConnect, body)
+}
+
+// encodePublish returns the PUBLISH packet of payload to topic with QoS 0.
+func encodePublish(topic string, payload []byte) ([]byte, error) {
+	if topic == "" {
+		return nil, errors.New("the topic must not be empty")
+	}
+	body := appendString(nil, topic)
+	return encodePacket(packetPublish, append(body, payload...))
+}
+
+// encodePacket prefixes body with the fixed header of a packet of the given type.
+func encodePacket(packetType byte, body []byte) ([]byte, error) {
+	if len(body) > maxRemainingLength {
+		return nil, errors.Errorf("a packet of %d bytes is too large for MQTT", len(body))
+	}
+	packet := append([]byte{packetType}, encodeRemainingLength(len(body))...)
+	return append(packet, body...), nil
+}
+
+// encodeRemainingLength encodes n as the variable length integer of the fixed header, 7 bits per byte with the
+// lowest bits first.
+func encodeRemainingLength(n int) []byte {
+	var encoded []byte
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		encoded = append(encoded, b)
+		if n == 0 {
+			return encoded
+		}
+	}
+}
+
+// appendString appends s with the two byte length prefix of MQTT strings.
+func appendString(b []byte, s string) []byte {
+	b = appendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// appendUint16 appends v in the big endian byte order of MQTT.
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+// readConnack reads the broker's answer to a CONNECT packet and returns an error unless it accepted the session.
+func readConnack(r io.Reader) error {
+	var packet [4]byte
+	if _, err := io.ReadFull(r, packet[:]); err != nil {
+		return errors.Wrap(err, "failed to read the broker's connect acknowledgement")
+	}
+	if packet[0] != packetConnack || packet[1] != 2 {
+		return errors.Errorf("expected a connect acknowledgement, got packet type %#x", packet[0])
+	}
+	if code := packet[3]; code != 0 {
+		return errors.Errorf("the broker refused the connection with return code %d", code)
+	}
+	return nil
+}
+
+// readPingresp reads the broker's answer to a PINGREQ packet. The broker sends nothing else to a client that only
+// publishes with QoS 0 and does not subscribe, so any other packet is an error.
+func readPingresp(r io.Reader) error {
+	var packet [2]byte
+	if _, err := io.ReadFull(r, packet[:]); err != nil {
+		return errors.Wrap(err, "the broker did not answer a ping")
+	}
+	if packet[0] != packetPingresp || packet[1] != 0 {
+		return errors.Errorf("expected a ping response, got packet type %#x", packet[0])
+	}
+	return nil
+}
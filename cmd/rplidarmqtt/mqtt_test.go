@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+// fakeBroker accepts MQTT connections, acknowledges them with returnCode and hands every packet received after the
+// CONNECT packet to packets. It answers pings if answerPings is set.
+type fakeBroker struct {
+	listener    net.Listener
+	returnCode  byte
+	answerPings bool
+	connects    chan []byte
+	packets     chan []byte
+	conns       chan net.Conn
+}
+
+func newFakeBroker(t *testing.T, returnCode byte, answerPings bool) *fakeBroker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	test.That(t, err, test.ShouldBeNil)
+	b := &fakeBroker{
+		listener:    listener,
+		returnCode:  returnCode,
+		answerPings: answerPings,
+		connects:    make(chan []byte, 10),
+		packets:     make(chan []byte, 100),
+		conns:       make(chan net.Conn, 10),
+	}
+	t.Cleanup(func() {
+		test.That(t, listener.Close(), test.ShouldBeNil)
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			b.conns <- conn
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	connect, err := readPacket(conn)
+	if err != nil {
+		return
+	}
+	b.connects <- connect
+	if _, err := conn.Write([]byte{packetConnack, 2, 0, b.returnCode}); err != nil {
+		return
+	}
+	for {
+		packet, err := readPacket(conn)
+		if err != nil {
+			return
+		}
+		b.packets <- packet
+		if packet[0] == packetPingreq && b.answerPings {
+			if _, err := conn.Write([]byte{packetPingresp, 0}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readPacket reads a single MQTT packet, including its fixed header.
+func readPacket(r io.Reader) ([]byte, error) {
+	header := make([]byte, 1, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	var length, shift int
+	for {
+		var b [1]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, err
+		}
+		header = append(header, b[0])
+		length |= int(b[0]&0x7f) << shift
+		shift += 7
+		if b[0]&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
+
+func receive(t *testing.T, packets chan []byte) []byte {
+	t.Helper()
+	select {
+	case packet := <-packets:
+		return packet
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a packet")
+		return nil
+	}
+}
+
+func TestEncodeRemainingLength(t *testing.T) {
+	test.That(t, encodeRemainingLength(0), test.ShouldResemble, []byte{0})
+	test.That(t, encodeRemainingLength(127), test.ShouldResemble, []byte{0x7f})
+	test.That(t, encodeRemainingLength(128), test.ShouldResemble, []byte{0x80, 0x01})
+	test.That(t, encodeRemainingLength(16383), test.ShouldResemble, []byte{0xff, 0x7f})
+	test.That(t, encodeRemainingLength(maxRemainingLength), test.ShouldResemble, []byte{0xff, 0xff, 0xff, 0x7f})
+}
+
+func TestEncodeConnect(t *testing.T) {
+	packet, err := encodeConnect("fleet-1", "robot", "secret", 30*time.Second)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, packet, test.ShouldResemble, append([]byte{
+		packetConnect, 34,
+		0, 4, 'M', 'Q', 'T', 'T', 4, 0xC2, 0, 30,
+		0, 7, 'f', 'l', 'e', 'e', 't', '-', '1',
+		0, 5, 'r', 'o', 'b', 'o', 't',
+	}, append([]byte{0, 6}, "secret"...)...))
+
+	packet, err = encodeConnect("fleet-1", "", "", time.Minute)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, packet[9], test.ShouldEqual, 0x02)
+
+	_, err = encodeConnect("fleet-1", "", "secret", time.Minute)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestPublisher(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	t.Run("publishes and reconnects after the broker disconnects", func(t *testing.T) {
+		broker := newFakeBroker(t, 0, true)
+		pub := &publisher{broker: broker.listener.Addr().String(), clientID: "test", keepAlive: time.Minute, logger: logger}
+
+		test.That(t, pub.publish(ctx, "rplidar/scans", []byte(`{"seq":1}`)), test.ShouldBeNil)
+		receive(t, broker.connects)
+		test.That(t, receive(t, broker.packets), test.ShouldResemble, append([]byte{
+			packetPublish, 24, 0, 13, 'r', 'p', 'l', 'i', 'd', 'a', 'r', '/', 's', 'c', 'a', 'n', 's',
+		}, `{"seq":1}`...))
+
+		// Writes keep failing once the broker closed the connection, which drops the connection.
+		conn := <-broker.conns
+		test.That(t, conn.Close(), test.ShouldBeNil)
+		var err error
+		for i := 0; i < 100 && err == nil; i++ {
+			err = pub.publish(ctx, "rplidar/scans", []byte(`{"seq":2}`))
+			time.Sleep(10 * time.Millisecond)
+		}
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, pub.conn, test.ShouldBeNil)
+
+		test.That(t, pub.publish(ctx, "rplidar/scans", []byte(`{"seq":3}`)), test.ShouldBeNil)
+		receive(t, broker.connects)
+		test.That(t, pub.close(), test.ShouldBeNil)
+	})
+
+	t.Run("pings the broker before the keep alive runs out", func(t *testing.T) {
+		broker := newFakeBroker(t, 0, true)
+		pub := &publisher{
+			broker: broker.listener.Addr().String(), clientID: "test", keepAlive: time.Minute,
+			pingTimeout: time.Second, logger: logger,
+		}
+
+		test.That(t, pub.publish(ctx, "rplidar/scans", []byte("{}")), test.ShouldBeNil)
+		receive(t, broker.connects)
+		receive(t, broker.packets)
+
+		// Publishing does not count as hearing from the broker, so it is pinged even while scans are published.
+		pub.lastReceived = time.Now().Add(-time.Minute)
+		test.That(t, pub.publish(ctx, "rplidar/scans", []byte("{}")), test.ShouldBeNil)
+		test.That(t, receive(t, broker.packets), test.ShouldResemble, []byte{packetPingreq, 0})
+		test.That(t, receive(t, broker.packets)[0], test.ShouldEqual, packetPublish)
+		test.That(t, time.Since(pub.lastReceived), test.ShouldBeLessThan, time.Minute/2)
+		test.That(t, pub.close(), test.ShouldBeNil)
+	})
+
+	t.Run("drops the connection when the broker does not answer a ping", func(t *testing.T) {
+		broker := newFakeBroker(t, 0, false)
+		pub := &publisher{
+			broker: broker.listener.Addr().String(), clientID: "test", keepAlive: time.Minute,
+			pingTimeout: 50 * time.Millisecond, logger: logger,
+		}
+
+		test.That(t, pub.publish(ctx, "rplidar/scans", []byte("{}")), test.ShouldBeNil)
+		receive(t, broker.connects)
+
+		pub.lastReceived = time.Now().Add(-time.Minute)
+		err := pub.publish(ctx, "rplidar/scans", []byte("{}"))
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "did not answer a ping")
+		test.That(t, pub.conn, test.ShouldBeNil)
+
+		// The next publish connects again.
+		test.That(t, pub.publish(ctx, "rplidar/scans", []byte("{}")), test.ShouldBeNil)
+		receive(t, broker.connects)
+		test.That(t, pub.close(), test.ShouldBeNil)
+	})
+
+	t.Run("backs off after the broker refuses the connection", func(t *testing.T) {
+		broker := newFakeBroker(t, 5, true)
+		pub := &publisher{broker: broker.listener.Addr().String(), clientID: "test", logger: logger}
+
+		err := pub.publish(ctx, "rplidar/scans", []byte("{}"))
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "return code 5")
+		test.That(t, pub.backoff, test.ShouldEqual, time.Second)
+
+		err = pub.publish(ctx, "rplidar/scans", []byte("{}"))
+		test.That(t, err.Error(), test.ShouldContainSubstring, "waiting to reconnect")
+		test.That(t, len(broker.connects), test.ShouldEqual, 1)
+
+		for i := 0; i < 10; i++ {
+			pub.backOff()
+		}
+		test.That(t, pub.backoff, test.ShouldEqual, maxReconnectBackoff)
+	})
+}
@@ -0,0 +1,169 @@
+// Package main is a terminal tool that scans with an rplidar until interrupted and appends the points of every scan
+// as rows to a Parquet file, one file per run, for loading captures into analytics pipelines.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+	"go.uber.org/multierr"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rplidar"
+	"go.viam.com/rplidar/cmd/internal/standalone"
+
+	"go.viam.com/utils"
+)
+
+const (
+	// The size of the row groups the points are buffered in before they are written. A row group is only written
+	// once full or when the file is closed, so it is kept small enough that a run killed without Ctrl-C loses
+	// little.
+	rowGroupSize = 16 * 1024 * 1024
+	// The number of goroutines the Parquet writer marshals rows with.
+	writerParallelism = 4
+)
+
+// scanCamera is the part of the rplidar camera the tool reads scans from.
+type scanCamera interface {
+	NextN(ctx context.Context, n int) ([]pointcloud.PointCloud, []rplidar.ScanMeta, error)
+	DeviceInfo() rplidar.DeviceInfo
+}
+
+// pointRow is a row of the Parquet file, a point of a scan.
+type pointRow struct {
+	X float64 `parquet:"name=x, type=DOUBLE"`
+	Y float64 `parquet:"name=y, type=DOUBLE"`
+	Z float64 `parquet:"name=z, type=DOUBLE"`
+	// The quality the rplidar reported for the return, from 0 to 255.
+	Quality int32 `parquet:"name=quality, type=INT32"`
+	// The time the scan completed, in microseconds since the Unix epoch.
+	Timestamp int64 `parquet:"name=timestamp, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	// The sequence number of the scan, see rplidar.ScanMeta.
+	ScanSeq int64 `parquet:"name=scan_seq, type=INT64"`
+	// The serial number of the rplidar, the same for every row of a file.
+	Serial string `parquet:"name=serial, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+}
+
+func main() {
+	utils.ContextualMain(mainWithArgs, logging.NewLogger("rplidarparquet"))
+}
+
+func mainWithArgs(ctx context.Context, args []string, logger logging.Logger) error {
+	logger.Infof("%v built against rplidar SDK %v", args[0], rplidar.SDKVersion())
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	serialPath := flags.String("serial-path", "", "serial path of the rplidar, detected over USB if empty")
+	out := flags.String("out", "", "path of the Parquet file to write, scans-<start time>.parquet if empty")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("scans-%s.parquet", time.Now().Format("20060102-150405"))
+	}
+
+	// The quality is stored as the value of each point, so that it can be read back exactly.
+	conf := &rplidar.Config{SerialPath: *serialPath, QualityEncoding: "value"}
+	cam, closeCamera, err := standalone.OpenCamera[scanCamera](ctx, conf, logger)
+	if err != nil {
+		return err
+	}
+	defer closeCamera()
+
+	w, err := newScanWriter(*out, cam.DeviceInfo().SerialNumber)
+	if err != nil {
+		return err
+	}
+	logger.Infof("writing scans to %v until interrupted", *out)
+	err = capture(ctx, cam, w)
+	// The footer is only written when closing, without it the file cannot be read.
+	if err := w.close(); err != nil {
+		return errors.Wrapf(err, "failed to finish %v", *out)
+	}
+	if err != nil {
+		return err
+	}
+	logger.Infof("wrote %d points of %d scans to %v", w.points, w.scans, *out)
+	return nil
+}
+
+// capture writes scans to w until ctx is done, e.g. on Ctrl-C.
+func capture(ctx context.Context, cam scanCamera, w *scanWriter) error {
+	for ctx.Err() == nil {
+		scans, metas, err := cam.NextN(ctx, 1)
+		if err != nil {
+			// NextN only fails once ctx is done.
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := w.write(scans[0], metas[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanWriter appends the points of scans as rows to a Parquet file.
+type scanWriter struct {
+	file   *os.File
+	pw     *writer.ParquetWriter
+	serial string
+	scans  int
+	points int
+}
+
+// newScanWriter creates the Parquet file at path for the scans of the rplidar with the given serial number.
+func newScanWriter(path, serial string) (*scanWriter, error) {
+	//nolint:gosec
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := writer.NewParquetWriterFromWriter(file, new(pointRow), writerParallelism)
+	if err != nil {
+		return nil, multierr.Combine(err, file.Close())
+	}
+	pw.RowGroupSize = rowGroupSize
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	return &scanWriter{file: file, pw: pw, serial: serial}, nil
+}
+
+// write appends a row for every point of scan.
+func (w *scanWriter) write(scan pointcloud.PointCloud, meta rplidar.ScanMeta) error {
+	timestamp := meta.Timestamp.UnixMicro()
+	var err error
+	scan.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		row := pointRow{
+			X:         p.X,
+			Y:         p.Y,
+			Z:         p.Z,
+			Timestamp: timestamp,
+			ScanSeq:   int64(meta.Seq),
+			Serial:    w.serial,
+		}
+		if d != nil && d.HasValue() {
+			row.Quality = int32(d.Value())
+		}
+		err = w.pw.Write(row)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	w.scans++
+	w.points += scan.Size()
+	return nil
+}
+
+// close writes the buffered rows and the footer and closes the file.
+func (w *scanWriter) close() error {
+	return multierr.Combine(w.pw.WriteStop(), w.file.Close())
+}
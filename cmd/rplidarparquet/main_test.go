@@ -0,0 +1,52 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rplidar"
+	"go.viam.com/test"
+)
+
+func TestScanWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scans.parquet")
+	w, err := newScanWriter(path, "ABC123")
+	test.That(t, err, test.ShouldBeNil)
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, quality := range []int{10, 47} {
+		scan := pointcloud.New()
+		test.That(t, scan.Set(r3.Vector{X: float64(i), Y: 800}, pointcloud.NewValueData(quality)), test.ShouldBeNil)
+		meta := rplidar.ScanMeta{Seq: uint64(i + 1), Timestamp: start.Add(time.Duration(i) * 100 * time.Millisecond)}
+		test.That(t, w.write(scan, meta), test.ShouldBeNil)
+	}
+	// A point without a stored quality is written with a quality of 0.
+	scan := pointcloud.New()
+	test.That(t, scan.Set(r3.Vector{X: -300}, nil), test.ShouldBeNil)
+	test.That(t, w.write(scan, rplidar.ScanMeta{Seq: 3, Timestamp: start}), test.ShouldBeNil)
+	test.That(t, w.scans, test.ShouldEqual, 3)
+	test.That(t, w.points, test.ShouldEqual, 3)
+	test.That(t, w.close(), test.ShouldBeNil)
+
+	file, err := local.NewLocalFileReader(path)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, file.Close(), test.ShouldBeNil)
+	}()
+	pr, err := reader.NewParquetReader(file, new(pointRow), 1)
+	test.That(t, err, test.ShouldBeNil)
+	defer pr.ReadStop()
+	test.That(t, pr.GetNumRows(), test.ShouldEqual, 3)
+	rows := make([]pointRow, 3)
+	test.That(t, pr.Read(&rows), test.ShouldBeNil)
+	test.That(t, rows, test.ShouldResemble, []pointRow{
+		{X: 0, Y: 800, Quality: 10, Timestamp: start.UnixMicro(), ScanSeq: 1, Serial: "ABC123"},
+		{X: 1, Y: 800, Quality: 47, Timestamp: start.Add(100 * time.Millisecond).UnixMicro(), ScanSeq: 2, Serial: "ABC123"},
+		{X: -300, Timestamp: start.UnixMicro(), ScanSeq: 3, Serial: "ABC123"},
+	})
+}
@@ -0,0 +1,138 @@
+// Package main is a terminal tool that renders the scans of an rplidar as a live ASCII top-down view, for
+// confirming the sensor sees its environment on a headless machine.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rplidar"
+	"go.viam.com/rplidar/cmd/internal/standalone"
+
+	"go.viam.com/utils"
+)
+
+const (
+	// The terminal size used when it cannot be queried, e.g. when the output is not a terminal.
+	defaultCols = 80
+	defaultRows = 24
+	// Terminal characters are about twice as tall as they are wide.
+	charAspect = 2.
+	// How often to check for a new scan, well above the scan rate of any rplidar.
+	pollInterval = 20 * time.Millisecond
+)
+
+// rangeRamp maps ranges to characters, from the nearest to the farthest returns.
+var rangeRamp = []byte("@%#*+=-:.")
+
+func main() {
+	utils.ContextualMain(mainWithArgs, logging.NewLogger("rplidarscope"))
+}
+
+func mainWithArgs(ctx context.Context, args []string, logger logging.Logger) error {
+	logger.Infof("%v built against rplidar SDK %v", args[0], rplidar.SDKVersion())
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	serialPath := flags.String("serial-path", "", "serial path of the rplidar, detected over USB if empty")
+	rangeM := flags.Float64("range-m", 6, "range in meters from the sensor to the edge of the view")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *rangeM <= 0 {
+		return errors.New("range-m must be positive")
+	}
+
+	conf := &rplidar.Config{SerialPath: *serialPath}
+	cam, closeCamera, err := standalone.OpenCamera[camera.Camera](ctx, conf, logger)
+	if err != nil {
+		return err
+	}
+	defer closeCamera()
+
+	// Clear the screen and hide the cursor while drawing, restoring it on exit.
+	fmt.Print("\x1b[2J\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\n")
+
+	// Point clouds are cached by the camera, so wait for a new one instead of redrawing the same scan.
+	var previous pointcloud.PointCloud
+	for ctx.Err() == nil {
+		scan, err := cam.NextPointCloud(ctx)
+		if err != nil || scan == previous {
+			utils.SelectContextOrWait(ctx, pollInterval)
+			continue
+		}
+		previous = scan
+
+		// The size is queried for every scan so resizing the terminal takes effect on the next one.
+		cols, rows := terminalSize()
+		// Leave the last row for the status line.
+		lines := renderASCII(scan, cols, rows-1, *rangeM*1000)
+		fmt.Printf("\x1b[H%s\n%-*s", strings.Join(lines, "\n"),
+			cols, fmt.Sprintf("%d points, %.1fm to the edge, Ctrl-C to exit", scan.Size(), *rangeM))
+	}
+	return nil
+}
+
+// renderASCII rasterizes scan into rows lines of cols characters, centered on the sensor, which is drawn as an
+// "O". The edges of the shorter axis of the view are rangeMM away from the sensor. Each character shows the
+// nearest return within it, from "@" for the nearest to "." for returns rangeMM away; empty cells are spaces.
+// The X axis of the scan points to the right and the Y axis up.
+func renderASCII(scan pointcloud.PointCloud, cols, rows int, rangeMM float64) []string {
+	if cols <= 0 || rows <= 0 {
+		return nil
+	}
+	nearest := make([]float64, cols*rows)
+	for i := range nearest {
+		nearest[i] = math.Inf(1)
+	}
+
+	// The size in millimeters of a character along X, chosen so that the full range fits on both axes.
+	mmPerCol := 2 * rangeMM / math.Min(float64(cols), float64(rows)*charAspect)
+	mmPerRow := mmPerCol * charAspect
+	centerCol, centerRow := cols/2, rows/2
+	bounds := image.Rect(0, 0, cols, rows)
+
+	scan.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		col := centerCol + int(math.Floor(p.X/mmPerCol+0.5))
+		row := centerRow - int(math.Floor(p.Y/mmPerRow+0.5))
+		if image.Pt(col, row).In(bounds) {
+			i := row*cols + col
+			nearest[i] = math.Min(nearest[i], math.Hypot(p.X, p.Y))
+		}
+		return true
+	})
+
+	lines := make([]string, rows)
+	line := make([]byte, cols)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			line[col] = rangeChar(nearest[row*cols+col], rangeMM)
+		}
+		if row == centerRow {
+			line[centerCol] = 'O'
+		}
+		lines[row] = string(line)
+	}
+	return lines
+}
+
+// rangeChar returns the character of the ramp for a return at rangeMM, or a space if there is none.
+func rangeChar(distanceMM, rangeMM float64) byte {
+	if math.IsInf(distanceMM, 1) {
+		return ' '
+	}
+	i := int(distanceMM / rangeMM * float64(len(rangeRamp)))
+	if i >= len(rangeRamp) {
+		i = len(rangeRamp) - 1
+	}
+	return rangeRamp[i]
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestRenderASCII(t *testing.T) {
+	scan := pointcloud.New()
+	test.That(t, scan.Set(r3.Vector{X: 500, Y: 0}, nil), test.ShouldBeNil)
+	test.That(t, scan.Set(r3.Vector{X: 0, Y: 1900}, nil), test.ShouldBeNil)
+	test.That(t, scan.Set(r3.Vector{X: -100000, Y: 0}, nil), test.ShouldBeNil)
+
+	// 20 columns and 10 rows with a range of 2000mm are 200mm per column and 400mm per row.
+	lines := renderASCII(scan, 20, 10, 2000)
+	test.That(t, len(lines), test.ShouldEqual, 10)
+	for _, line := range lines {
+		test.That(t, len(line), test.ShouldEqual, 20)
+	}
+	test.That(t, lines[5][10], test.ShouldEqual, 'O')
+	test.That(t, lines[5][13], test.ShouldEqual, rangeChar(500, 2000))
+	test.That(t, lines[0][10], test.ShouldEqual, rangeChar(1900, 2000))
+	test.That(t, strings.Count(strings.Join(lines, ""), " "), test.ShouldEqual, 20*10-3)
+}
+
+func TestRangeChar(t *testing.T) {
+	test.That(t, rangeChar(math.Inf(1), 1000), test.ShouldEqual, ' ')
+	test.That(t, rangeChar(0, 1000), test.ShouldEqual, rangeRamp[0])
+	test.That(t, rangeChar(999, 1000), test.ShouldEqual, rangeRamp[len(rangeRamp)-1])
+	test.That(t, rangeChar(5000, 1000), test.ShouldEqual, rangeRamp[len(rangeRamp)-1])
+}
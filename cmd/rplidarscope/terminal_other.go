@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package main
+
+// terminalSize returns the default terminal size, since querying the terminal is only supported on Linux and
+// macOS.
+func terminalSize() (int, int) {
+	return defaultCols, defaultRows
+}
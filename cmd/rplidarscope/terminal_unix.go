@@ -0,0 +1,19 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalSize returns the number of columns and rows of the terminal on stdout.
+func terminalSize() (int, int) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row < 2 {
+		return defaultCols, defaultRows
+	}
+	return int(ws.Col), int(ws.Row)
+}
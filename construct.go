@@ -0,0 +1,67 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+)
+
+// The time constructing the camera may take, from connecting to the device to the end of the warmup, unless
+// configured otherwise. Generous enough for probing every scan mode of a device that needs both baud rates.
+const defaultConstructTimeout = time.Minute
+
+// ErrConstructTimeout is wrapped by the error returned when constructing the camera takes longer than
+// construct_timeout_ms, e.g. because the device hangs while connecting.
+var ErrConstructTimeout = errors.New("timed out constructing the rplidar")
+
+// constructResult is the outcome of constructing the camera.
+type constructResult struct {
+	cam camera.Camera
+	err error
+}
+
+// constructWithin runs construct with a context that is done after timeout and returns its result, or an error
+// wrapping ErrConstructTimeout if it has not returned by then. Calls into the SDK cannot be interrupted, so a timed
+// out construct keeps running in the background; whatever camera it still returns is closed, releasing the serial
+// port and the lock file, and an error is expected to release what was opened so far itself.
+func constructWithin(
+	ctx context.Context,
+	timeout time.Duration,
+	logger logging.Logger,
+	construct func(ctx context.Context) (camera.Camera, error),
+) (camera.Camera, error) {
+	constructCtx, cancel := context.WithTimeout(ctx, timeout)
+	done := make(chan constructResult, 1)
+	go func() {
+		cam, err := construct(constructCtx)
+		done <- constructResult{cam: cam, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		cancel()
+		return result.cam, result.err
+	case <-constructCtx.Done():
+	}
+
+	go func() {
+		defer cancel()
+		result := <-done
+		if result.cam == nil {
+			return
+		}
+		logger.Warn("closing the rplidar that finished constructing after timing out")
+		if err := result.cam.Close(context.Background()); err != nil {
+			logger.Errorf("failed to close the rplidar that finished constructing after timing out: %v", err)
+		}
+	}()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w after %v", ErrConstructTimeout, timeout)
+}
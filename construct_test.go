@@ -0,0 +1,75 @@
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+// closeRecorder is a camera that only records being closed.
+type closeRecorder struct {
+	camera.Camera
+	closed chan struct{}
+}
+
+func (c *closeRecorder) Close(ctx context.Context) error {
+	close(c.closed)
+	return nil
+}
+
+func TestConstructWithin(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+
+	t.Run("returns what construct returns in time", func(t *testing.T) {
+		cam := &closeRecorder{closed: make(chan struct{})}
+		got, err := constructWithin(context.Background(), time.Minute, logger, func(ctx context.Context) (camera.Camera, error) {
+			return cam, nil
+		})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, got, test.ShouldEqual, cam)
+
+		errConstruct := errors.New("bad health")
+		_, err = constructWithin(context.Background(), time.Minute, logger, func(ctx context.Context) (camera.Camera, error) {
+			return nil, errConstruct
+		})
+		test.That(t, err, test.ShouldEqual, errConstruct)
+	})
+
+	t.Run("closes a camera constructed after timing out", func(t *testing.T) {
+		cam := &closeRecorder{closed: make(chan struct{})}
+		release := make(chan struct{})
+		var constructErr error
+		_, err := constructWithin(context.Background(), 10*time.Millisecond, logger, func(ctx context.Context) (camera.Camera, error) {
+			<-ctx.Done()
+			constructErr = ctx.Err()
+			// A call into the SDK that cannot be interrupted.
+			<-release
+			return cam, nil
+		})
+		test.That(t, errors.Is(err, ErrConstructTimeout), test.ShouldBeTrue)
+
+		close(release)
+		select {
+		case <-cam.closed:
+		case <-time.After(time.Second):
+			t.Fatal("the late camera was not closed")
+		}
+		test.That(t, errors.Is(constructErr, context.DeadlineExceeded), test.ShouldBeTrue)
+	})
+
+	t.Run("returns the error of a cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := constructWithin(ctx, time.Minute, logger, func(ctx context.Context) (camera.Camera, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		test.That(t, errors.Is(err, context.Canceled), test.ShouldBeTrue)
+		test.That(t, errors.Is(err, ErrConstructTimeout), test.ShouldBeFalse)
+	})
+}
@@ -0,0 +1,63 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+// The number of equally sized angular bins the revolution is divided into to measure the scan coverage.
+const coverageBins = 360
+
+// The default range in millimeters within which returns are considered to come from something blocking the
+// lens rather than from the surroundings.
+const defaultCoverageNearMM = 100.
+
+// scanCoverage accumulates which directions of the revolution saw valid returns. The zero value is ready to use.
+type scanCoverage struct {
+	// far marks bins with at least one return beyond the near range.
+	far [coverageBins]bool
+	// near marks bins with at least one return within the near range.
+	near [coverageBins]bool
+}
+
+// add records the valid returns of a revolution, classifying returns within nearMM as near.
+func (c *scanCoverage) add(measurements []measurement, nearMM float64) {
+	for _, m := range measurements {
+		bin := int(normalizeAngleDeg(m.angleDeg) * coverageBins / 360)
+		// Guard against floating point error placing an angle just below 360 in a bin past the last one.
+		if bin >= coverageBins {
+			bin = coverageBins - 1
+		}
+		if m.distanceMM > nearMM {
+			c.far[bin] = true
+		} else {
+			c.near[bin] = true
+		}
+	}
+}
+
+// fractions returns the fraction of the revolution with at least one return beyond the near range, the coverage,
+// and the fraction where every return was within the near range, which is what a blocked lens looks like. The
+// remainder of the revolution saw no returns at all, which is what open space beyond the device's range looks
+// like.
+func (c *scanCoverage) fractions() (coverage, blocked float64) {
+	var farBins, blockedBins int
+	for bin := range c.far {
+		switch {
+		case c.far[bin]:
+			farBins++
+		case c.near[bin]:
+			blockedBins++
+		}
+	}
+	return float64(farBins) / coverageBins, float64(blockedBins) / coverageBins
+}
+
+// coverageState classifies a scan with the given coverage fractions against the minimum coverage. A scan below
+// the minimum is "blocked" if most of the uncovered directions saw near returns, and "open" otherwise.
+func coverageState(coverage, blocked, minCoverage float64) string {
+	switch {
+	case coverage >= minCoverage:
+		return "ok"
+	case blocked >= (1-coverage)/2:
+		return "blocked"
+	default:
+		return "open"
+	}
+}
@@ -0,0 +1,55 @@
+package rplidar
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestScanCoverage(t *testing.T) {
+	arc := func(fromDeg, toDeg int, distanceMM float64) []measurement {
+		var measurements []measurement
+		for angle := fromDeg; angle < toDeg; angle++ {
+			measurements = append(measurements, measurement{angleDeg: float64(angle) + 0.5, distanceMM: distanceMM})
+		}
+		return measurements
+	}
+
+	t.Run("no returns", func(t *testing.T) {
+		var c scanCoverage
+		coverage, blocked := c.fractions()
+		test.That(t, coverage, test.ShouldEqual, 0)
+		test.That(t, blocked, test.ShouldEqual, 0)
+	})
+
+	t.Run("full coverage", func(t *testing.T) {
+		var c scanCoverage
+		c.add(arc(0, 360, 2000), defaultCoverageNearMM)
+		coverage, blocked := c.fractions()
+		test.That(t, coverage, test.ShouldEqual, 1)
+		test.That(t, blocked, test.ShouldEqual, 0)
+	})
+
+	t.Run("near returns block directions without far returns", func(t *testing.T) {
+		var c scanCoverage
+		c.add(arc(0, 180, 2000), defaultCoverageNearMM)
+		c.add(arc(90, 270, 50), defaultCoverageNearMM)
+		coverage, blocked := c.fractions()
+		test.That(t, coverage, test.ShouldEqual, 0.5)
+		test.That(t, blocked, test.ShouldEqual, 0.25)
+	})
+
+	t.Run("angles are normalized", func(t *testing.T) {
+		var c scanCoverage
+		c.add([]measurement{{angleDeg: 359.99999999, distanceMM: 2000}, {angleDeg: -90, distanceMM: 2000}}, defaultCoverageNearMM)
+		coverage, _ := c.fractions()
+		test.That(t, coverage, test.ShouldEqual, 2./coverageBins)
+	})
+}
+
+func TestCoverageState(t *testing.T) {
+	test.That(t, coverageState(0.9, 0, 0.8), test.ShouldEqual, "ok")
+	test.That(t, coverageState(0.5, 0.4, 0.8), test.ShouldEqual, "blocked")
+	test.That(t, coverageState(0.5, 0.1, 0.8), test.ShouldEqual, "open")
+	test.That(t, coverageState(0, 0, 0), test.ShouldEqual, "ok")
+}
@@ -0,0 +1,147 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import "time"
+
+// fallbackProtocol returns the protocol to step down to from protocol when scanning keeps failing, following the
+// chain extended, legacy, standard and skipping the protocols supported reports the device cannot scan with. It
+// returns an empty string if there is nothing to step down to.
+func fallbackProtocol(protocol string, supported func(protocol string) bool) string {
+	chain := []string{expressProtocolExtended, expressProtocolLegacy, expressProtocolStandard}
+	for i, p := range chain {
+		if p != protocol {
+			continue
+		}
+		for _, next := range chain[i+1:] {
+			if supported(next) {
+				return next
+			}
+		}
+	}
+	return ""
+}
+
+// scanDegrader decides when to step the scan protocol down the fallback chain because of sustained scan errors,
+// e.g. overflows on a loaded CPU, and when to step back up after scanning has been stable for a while. A nil
+// scanDegrader never changes the protocol. It is only used by the scan loop.
+type scanDegrader struct {
+	afterErrors  int
+	recoverAfter time.Duration
+
+	consecutiveErrors int
+	stableSince       time.Time
+	// The protocols stepped down from, the most recent one last.
+	steppedDown []string
+}
+
+// newScanDegrader creates a scanDegrader stepping down after the given number of consecutive scan errors and back
+// up after recoverAfter without errors. A non-positive number of errors disables it and returns nil, and a
+// non-positive recoverAfter never steps back up.
+func newScanDegrader(afterErrors int, recoverAfter time.Duration) *scanDegrader {
+	if afterErrors <= 0 {
+		return nil
+	}
+	return &scanDegrader{afterErrors: afterErrors, recoverAfter: recoverAfter}
+}
+
+// record records the outcome of a scan with the current protocol and returns the protocol to switch to, or an
+// empty string to keep scanning with the current one. Stepping down skips the protocols supported reports the
+// device cannot scan with. Nothing changes until the switch is reported back with switched, or with postpone if
+// it failed.
+func (d *scanDegrader) record(err error, now time.Time, current string, supported func(protocol string) bool) string {
+	if d == nil {
+		return ""
+	}
+	if err != nil {
+		d.stableSince = time.Time{}
+		if d.consecutiveErrors++; d.consecutiveErrors < d.afterErrors {
+			return ""
+		}
+		return fallbackProtocol(current, supported)
+	}
+
+	d.consecutiveErrors = 0
+	if d.stableSince.IsZero() {
+		d.stableSince = now
+	}
+	if d.recoverAfter <= 0 || len(d.steppedDown) == 0 || now.Sub(d.stableSince) < d.recoverAfter {
+		return ""
+	}
+	return d.steppedDown[len(d.steppedDown)-1]
+}
+
+// switched records that scanning switched from one protocol to the other that record returned.
+func (d *scanDegrader) switched(from, to string, now time.Time) {
+	if n := len(d.steppedDown); n > 0 && to == d.steppedDown[n-1] {
+		d.steppedDown = d.steppedDown[:n-1]
+	} else {
+		d.steppedDown = append(d.steppedDown, from)
+	}
+	d.postpone(now)
+}
+
+// postpone restarts counting the consecutive errors and the stable period, so that the protocol is only switched
+// again after as many errors or as long a stable period, e.g. after a switch failed.
+func (d *scanDegrader) postpone(now time.Time) {
+	d.consecutiveErrors = 0
+	if !d.stableSince.IsZero() {
+		d.stableSince = now
+	}
+}
+
+// degraded reports whether the protocol is stepped down from the one scanning started with.
+func (d *scanDegrader) degraded() bool {
+	return d != nil && len(d.steppedDown) > 0
+}
+
+// switchProtocol restarts scanning with the given protocol. If the device fails to start it, scanning is
+// restarted with the previous protocol instead.
+func (rp *rplidar) switchProtocol(protocol string) error {
+	rp.device.mutex.Lock()
+	defer rp.device.mutex.Unlock()
+
+	previous := rp.device.currentExpressProtocol()
+	rp.device.driver.Stop()
+	if err := rp.device.startScan(protocol, false); err != nil {
+		if restartErr := rp.device.startScan(previous, false); restartErr != nil {
+			return restartErr
+		}
+		return err
+	}
+	rp.stats.addProtocolSwitch()
+	return nil
+}
+
+// checkDegradation steps the scan protocol down or back up according to the degrader after every scan. It is only
+// called from the scan loop.
+func (rp *rplidar) checkDegradation(err error, now time.Time) {
+	if rp.degrader == nil {
+		return
+	}
+	current := rp.device.currentExpressProtocol()
+	next := rp.degrader.record(err, now, current, func(protocol string) bool {
+		return rp.device.checkExpressProtocol(protocol) == nil
+	})
+	if next == "" {
+		return
+	}
+	if switchErr := rp.switchProtocol(next); switchErr != nil {
+		rp.degrader.postpone(now)
+		rp.logger.Errorf("failed to switch the scan protocol from %v to %v: %v", current, next, switchErr)
+		return
+	}
+	rp.degrader.switched(current, next, now)
+	rp.recordLastGood()
+	if rp.degrader.degraded() {
+		rp.setState(StateDegraded)
+	} else {
+		rp.setState(StateScanning)
+	}
+	if err != nil {
+		rp.logger.Warnf("scanning degraded from the %v to the %v protocol after %d consecutive scan errors, last: %v",
+			current, next, rp.degrader.afterErrors, err)
+	} else {
+		rp.logger.Infof("scanning stable for %v, stepped back up from the %v to the %v protocol",
+			rp.degrader.recoverAfter, current, next)
+	}
+}
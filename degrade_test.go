@@ -0,0 +1,129 @@
+package rplidar
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rplidar/gen"
+	"go.viam.com/rplidar/inject"
+	"go.viam.com/test"
+)
+
+func TestFallbackProtocol(t *testing.T) {
+	all := func(string) bool { return true }
+	test.That(t, fallbackProtocol(expressProtocolExtended, all), test.ShouldEqual, expressProtocolLegacy)
+	test.That(t, fallbackProtocol(expressProtocolLegacy, all), test.ShouldEqual, expressProtocolStandard)
+	test.That(t, fallbackProtocol(expressProtocolStandard, all), test.ShouldEqual, "")
+
+	// The S-series and A-series firmware older than 1.17 cannot scan with the legacy protocol.
+	for _, device := range []*rplidarDevice{
+		{model: 97, firmwareVersionRaw: 1<<8 | 29},
+		{model: 24, firmwareVersionRaw: 1<<8 | 16},
+	} {
+		supported := func(protocol string) bool { return device.checkExpressProtocol(protocol) == nil }
+		test.That(t, fallbackProtocol(expressProtocolExtended, supported), test.ShouldEqual, expressProtocolStandard)
+	}
+}
+
+func TestScanDegrader(t *testing.T) {
+	errScan := errors.New("bad scan")
+	start := time.Now()
+	all := func(string) bool { return true }
+	// step records a scan and switches to the protocol the degrader returns, if any.
+	step := func(d *scanDegrader, err error, now time.Time, current string) string {
+		next := d.record(err, now, current, all)
+		if next != "" {
+			d.switched(current, next, now)
+		}
+		return next
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		test.That(t, newScanDegrader(0, time.Second), test.ShouldBeNil)
+		var d *scanDegrader
+		test.That(t, d.record(errScan, start, expressProtocolExtended, all), test.ShouldEqual, "")
+	})
+
+	t.Run("steps down after consecutive errors", func(t *testing.T) {
+		d := newScanDegrader(3, 0)
+		test.That(t, step(d, errScan, start, expressProtocolExtended), test.ShouldEqual, "")
+		test.That(t, step(d, errScan, start, expressProtocolExtended), test.ShouldEqual, "")
+		// A successful scan resets the count.
+		test.That(t, step(d, nil, start, expressProtocolExtended), test.ShouldEqual, "")
+		test.That(t, step(d, errScan, start, expressProtocolExtended), test.ShouldEqual, "")
+		test.That(t, step(d, errScan, start, expressProtocolExtended), test.ShouldEqual, "")
+		test.That(t, step(d, errScan, start, expressProtocolExtended), test.ShouldEqual, expressProtocolLegacy)
+
+		for i := 0; i < 2; i++ {
+			test.That(t, step(d, errScan, start, expressProtocolLegacy), test.ShouldEqual, "")
+		}
+		test.That(t, step(d, errScan, start, expressProtocolLegacy), test.ShouldEqual, expressProtocolStandard)
+
+		// There is nothing below standard.
+		for i := 0; i < 5; i++ {
+			test.That(t, step(d, errScan, start, expressProtocolStandard), test.ShouldEqual, "")
+		}
+		// Without a recovery period it never steps back up.
+		test.That(t, step(d, nil, start.Add(time.Hour), expressProtocolStandard), test.ShouldEqual, "")
+	})
+
+	t.Run("steps back up after a stable period", func(t *testing.T) {
+		d := newScanDegrader(1, time.Minute)
+		test.That(t, step(d, errScan, start, expressProtocolExtended), test.ShouldEqual, expressProtocolLegacy)
+		test.That(t, step(d, errScan, start, expressProtocolLegacy), test.ShouldEqual, expressProtocolStandard)
+
+		test.That(t, step(d, nil, start, expressProtocolStandard), test.ShouldEqual, "")
+		test.That(t, step(d, nil, start.Add(59*time.Second), expressProtocolStandard), test.ShouldEqual, "")
+		test.That(t, step(d, nil, start.Add(time.Minute), expressProtocolStandard), test.ShouldEqual,
+			expressProtocolLegacy)
+
+		// An error restarts the stable period.
+		test.That(t, step(d, nil, start.Add(90*time.Second), expressProtocolLegacy), test.ShouldEqual, "")
+		d.afterErrors = 2
+		test.That(t, step(d, errScan, start.Add(100*time.Second), expressProtocolLegacy), test.ShouldEqual, "")
+		test.That(t, step(d, nil, start.Add(110*time.Second), expressProtocolLegacy), test.ShouldEqual, "")
+		test.That(t, step(d, nil, start.Add(169*time.Second), expressProtocolLegacy), test.ShouldEqual, "")
+		test.That(t, step(d, nil, start.Add(170*time.Second), expressProtocolLegacy), test.ShouldEqual,
+			expressProtocolExtended)
+	})
+
+	t.Run("a failed switch is not recorded", func(t *testing.T) {
+		d := newScanDegrader(2, time.Minute)
+		test.That(t, d.record(errScan, start, expressProtocolExtended, all), test.ShouldEqual, "")
+		test.That(t, d.record(errScan, start, expressProtocolExtended, all), test.ShouldEqual, expressProtocolLegacy)
+		d.postpone(start)
+		test.That(t, d.degraded(), test.ShouldBeFalse)
+
+		// The switch is tried again after as many errors.
+		test.That(t, d.record(errScan, start, expressProtocolExtended, all), test.ShouldEqual, "")
+		test.That(t, d.record(errScan, start, expressProtocolExtended, all), test.ShouldEqual, expressProtocolLegacy)
+	})
+}
+
+func TestCheckDegradation(t *testing.T) {
+	driver := inject.NewRPLiDARDriver()
+	var started []string
+	driver.StopFunc = func(a ...interface{}) uint {
+		return uint(gen.RESULT_OK)
+	}
+	driver.StartScanFunc = func(a ...interface{}) uint {
+		started = append(started, expressProtocolStandard)
+		return uint(gen.RESULT_OK)
+	}
+
+	rp := rplidar{
+		device:   &rplidarDevice{driver: &driver, firmwareVersionRaw: 1<<8 | 29, expressProtocol: expressProtocolLegacy},
+		degrader: newScanDegrader(2, 0),
+		logger:   logging.NewTestLogger(t),
+	}
+	rp.setState(StateScanning)
+	now := time.Now()
+	rp.checkDegradation(errors.New("bad scan"), now)
+	test.That(t, started, test.ShouldBeEmpty)
+	rp.checkDegradation(errors.New("bad scan"), now)
+	test.That(t, started, test.ShouldResemble, []string{expressProtocolStandard})
+	test.That(t, rp.ExpressProtocol(), test.ShouldEqual, expressProtocolStandard)
+	test.That(t, rp.State(), test.ShouldEqual, StateDegraded)
+}
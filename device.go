@@ -4,6 +4,9 @@ package rplidar
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 
 	"go.viam.com/rdk/logging"
@@ -13,16 +16,125 @@ import (
 	"go.viam.com/utils/usb"
 )
 
+const (
+	// expressProtocolAuto lets the SDK select the scan mode, preferring the device's typical mode.
+	expressProtocolAuto = "auto"
+	// expressProtocolLegacy forces the legacy express scan protocol (capsuled measurements).
+	expressProtocolLegacy = "legacy"
+	// expressProtocolExtended forces the device's typical scan mode through the extended (configuration
+	// command based) protocol, which enables the ultra capsuled and dense capsuled answer types.
+	expressProtocolExtended = "extended"
+	// expressProtocolStandard is reported when the device is scanning without any express protocol.
+	expressProtocolStandard = "standard"
+
+	// The minimum firmware versions, encoded as major<<8 | minor, that support each express protocol.
+	minFirmwareLegacyExpress   = uint16(1<<8 | 17)
+	minFirmwareExtendedExpress = uint16(1<<8 | 24)
+)
+
 type rplidarDevice struct {
-	driver           gen.RPlidarDriver
-	model            byte
-	serialNumber     string
-	firmwareVersion  string
-	hardwareRevision int
-	mutex            sync.Mutex
+	driver             gen.RPlidarDriver
+	model              byte
+	serialNumber       string
+	firmwareVersion    string
+	firmwareVersionRaw uint16
+	hardwareRevision   int
+	healthStatus       int
+	// The model assumed for a model ID that is not in rplidarModelByteMap, selected by on_unknown_model.
+	assumedModel RPLiDARModel
+	// The time in milliseconds to wait for the device to answer a request or deliver a revolution.
+	timeoutMs uint
+	// The baud rate the device answered at.
+	baudRate uint
+	// The scan modes the device supports, queried once when connecting since it cannot be asked while scanning.
+	scanModes []scanModeInfo
+	// Guards the identity, health and scan modes above, which are replaced when the device is reconnected, and the
+	// scan mode and express protocol in use, which change when scanning is restarted in another mode.
+	modeMutex       sync.RWMutex
+	scanModeName    string
+	expressProtocol string
+	mutex           sync.Mutex
+}
+
+// currentScanMode returns the name of the scan mode the device is scanning in.
+func (device *rplidarDevice) currentScanMode() string {
+	device.modeMutex.RLock()
+	defer device.modeMutex.RUnlock()
+	return device.scanModeName
+}
+
+// currentExpressProtocol returns the express protocol the device is scanning with.
+func (device *rplidarDevice) currentExpressProtocol() string {
+	device.modeMutex.RLock()
+	defer device.modeMutex.RUnlock()
+	return device.expressProtocol
+}
+
+// DeviceInfo is the identity of the connected rplidar. It is read from the device when connecting and is read
+// again whenever the device is reconnected. Model is the model assumed according to on_unknown_model if the device reports an
+// unknown ModelID.
+type DeviceInfo struct {
+	Model            string
+	ModelID          int
+	Family           Family
+	SerialNumber     string
+	FirmwareVersion  string
+	HardwareRevision int
+}
+
+// currentHealthStatus returns the health status the device reported when it was last connected.
+func (device *rplidarDevice) currentHealthStatus() int {
+	device.modeMutex.RLock()
+	defer device.modeMutex.RUnlock()
+	return device.healthStatus
+}
+
+// supportedScanModes returns the scan modes the device reported when it was last connected.
+func (device *rplidarDevice) supportedScanModes() []scanModeInfo {
+	device.modeMutex.RLock()
+	defer device.modeMutex.RUnlock()
+	return device.scanModes
+}
+
+// replaceIdentity takes over the identity, health and scan modes read from connected, the device reconnected to
+// in place of this one, which may be another unit. The model assumed by on_unknown_model is kept.
+func (device *rplidarDevice) replaceIdentity(connected *rplidarDevice) {
+	device.modeMutex.Lock()
+	defer device.modeMutex.Unlock()
+	device.model = connected.model
+	device.serialNumber = connected.serialNumber
+	device.firmwareVersion = connected.firmwareVersion
+	device.firmwareVersionRaw = connected.firmwareVersionRaw
+	device.hardwareRevision = connected.hardwareRevision
+	device.healthStatus = connected.healthStatus
+	device.scanModes = connected.scanModes
+}
+
+// info returns the identity of the device as it was read when last connecting, without querying the device.
+func (device *rplidarDevice) info() DeviceInfo {
+	device.modeMutex.RLock()
+	defer device.modeMutex.RUnlock()
+	return DeviceInfo{
+		Model:            modelToString(device.rplidarModel()),
+		ModelID:          int(device.model),
+		Family:           device.family(),
+		SerialNumber:     device.serialNumber,
+		FirmwareVersion:  device.firmwareVersion,
+		HardwareRevision: device.hardwareRevision,
+	}
+}
+
+// rplidarModel returns the model of the device, or the model assumed for it if its model ID is unknown.
+func (device *rplidarDevice) rplidarModel() RPLiDARModel {
+	if model, ok := rplidarModelByteMap[device.model]; ok {
+		return model
+	}
+	return device.assumedModel
 }
 
-func searchForDevicePath(logger logging.Logger) (string, error) {
+// searchForDevicePath detects the rplidars connected over USB and returns the path of the one at deviceIndex,
+// with the detected devices ordered by path so that the index is stable for the same wiring.
+func searchForDevicePath(deviceIndex int, logger logging.Logger) (string, error) {
 	var usbInfo = &usb.Identifier{
 		Vendor:  0x10c4,
 		Product: 0xea60,
@@ -39,21 +151,115 @@ func searchForDevicePath(logger logging.Logger) (string, error) {
 	}
 
 	logger.Debugf("detected %d lidar devices", len(usbDevices))
+	paths := make([]string, 0, len(usbDevices))
 	for _, comp := range usbDevices {
 		logger.Debug(comp)
+		paths = append(paths, comp.Path)
+	}
+	return selectDevicePath(paths, deviceIndex)
+}
+
+// selectDevicePath returns the path at index of the given device paths once they are sorted.
+func selectDevicePath(paths []string, index int) (string, error) {
+	if index < 0 || index >= len(paths) {
+		return "", fmt.Errorf("device_index %d is out of range, only %d devices found", index, len(paths))
+	}
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+	return sorted[index], nil
+}
+
+// stableDevicePathDir holds symlinks to serial devices (ex. /dev/serial/by-id/) that are named after the device
+// and therefore stay the same across reboots, unlike the /dev/ttyUSB* numbering.
+const stableDevicePathDir = "/serial/by-id/"
+
+// resolveDevicePath resolves a serial path that may be a glob pattern (ex. /dev/serial/by-id/*CP2102*) to the
+// path of a single device. Matches that are links to the same device are treated as one, preferring the stable
+// by-id path, and an error listing the candidates is returned if the pattern matches more than one device.
+func resolveDevicePath(pattern string) (string, error) {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return pattern, nil
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid serial_path pattern %q: %w", pattern, err)
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no devices match serial_path %q", pattern)
+	}
+
+	pathByDevice := map[string]string{}
+	for _, match := range matches {
+		device := resolveDeviceLink(match)
+		if existing, ok := pathByDevice[device]; !ok || (isStableDevicePath(match) && !isStableDevicePath(existing)) {
+			pathByDevice[device] = match
+		}
+	}
+
+	if len(pathByDevice) > 1 {
+		candidates := make([]string, 0, len(pathByDevice))
+		for _, path := range pathByDevice {
+			candidates = append(candidates, path)
+		}
+		sort.Strings(candidates)
+		return "", fmt.Errorf("serial_path %q matches multiple devices, use a more specific path: %v",
+			pattern, strings.Join(candidates, ", "))
+	}
+
+	for _, path := range pathByDevice {
+		return path, nil
 	}
-	return usbDevices[0].Path, nil
+	return "", nil
+}
+
+// resolveDeviceLink returns the device a path links to, or the path itself if it cannot be resolved.
+func resolveDeviceLink(path string) string {
+	if device, err := filepath.EvalSymlinks(path); err == nil {
+		return device
+	}
+	return path
+}
+
+// isStableDevicePath reports whether path is a by-id path that is stable across reboots.
+func isStableDevicePath(path string) bool {
+	return strings.Contains(path, stableDevicePathDir)
+}
+
+// releaseDriver closes the serial port of driver before disposing of it, so the OS handle is released right away.
+func releaseDriver(driver gen.RPlidarDriver) {
+	driver.Disconnect()
+	gen.RPlidarDriverDisposeDriver(driver)
 }
 
-func getRplidarDevice(devicePath string) (*rplidarDevice, error) {
+// The baud rates the rplidars communicate at, tried in this order unless another one is preferred.
+var baudRates = []uint{256000, 115200}
+
+// baudRatesPreferring returns baudRates with preferred moved to the front, if it is one of them.
+func baudRatesPreferring(preferred uint) []uint {
+	rates := []uint{}
+	for _, rate := range baudRates {
+		if rate == preferred {
+			rates = append([]uint{rate}, rates...)
+		} else {
+			rates = append(rates, rate)
+		}
+	}
+	return rates
+}
+
+// getRplidarDevice connects to the device at devicePath, trying preferredBaudRate first if it is not zero.
+func getRplidarDevice(devicePath string, timeoutMs, preferredBaudRate uint) (*rplidarDevice, error) {
 	var driver gen.RPlidarDriver
+	var baudRate uint
 	devInfo := gen.NewRplidar_response_device_info_t()
 	defer gen.DeleteRplidar_response_device_info_t(devInfo)
 
 	var connectErr error
-	for _, rate := range []uint{256000, 115200} {
+	for _, rate := range baudRatesPreferring(preferredBaudRate) {
 		possibleDriver := gen.RPlidarDriverCreateDriver(uint(gen.DRIVER_TYPE_SERIALPORT))
 		if result := possibleDriver.Connect(devicePath, rate); Result(result) != ResultOk {
+			releaseDriver(possibleDriver)
 			r := Result(result)
 			if r == ResultOpTimeout {
 				continue
@@ -62,7 +268,8 @@ func getRplidarDevice(devicePath string) (*rplidarDevice, error) {
 			continue
 		}
 
-		if result := possibleDriver.GetDeviceInfo(devInfo, defaultDeviceTimeoutMs); Result(result) != ResultOk {
+		if result := possibleDriver.GetDeviceInfo(devInfo, timeoutMs); Result(result) != ResultOk {
+			releaseDriver(possibleDriver)
 			r := Result(result)
 			if r == ResultOpTimeout {
 				continue
@@ -71,11 +278,12 @@ func getRplidarDevice(devicePath string) (*rplidarDevice, error) {
 			continue
 		}
 		driver = possibleDriver
+		baudRate = rate
 		break
 	}
 	if driver == nil {
 		if connectErr == nil {
-			return &rplidarDevice{}, fmt.Errorf("timed out connecting to %q", devicePath)
+			return &rplidarDevice{}, fmt.Errorf("timed out connecting to %q: %w", devicePath, ErrSerialTimeout)
 		}
 		return nil, connectErr
 	}
@@ -94,25 +302,134 @@ func getRplidarDevice(devicePath string) (*rplidarDevice, error) {
 	healthInfo := gen.NewRplidar_response_device_health_t()
 	defer gen.DeleteRplidar_response_device_health_t(healthInfo)
 
-	if result := driver.GetHealth(healthInfo, defaultDeviceTimeoutMs); Result(result) != ResultOk {
-		gen.RPlidarDriverDisposeDriver(driver)
+	if result := driver.GetHealth(healthInfo, timeoutMs); Result(result) != ResultOk {
+		releaseDriver(driver)
 		driver = nil
 		return nil, fmt.Errorf("failed to get health: %w", Result(result).Failed())
 	}
 
 	if int(healthInfo.GetStatus()) == gen.RPLIDAR_STATUS_ERROR {
-		gen.RPlidarDriverDisposeDriver(driver)
+		releaseDriver(driver)
 		driver = nil
 		return nil, errors.New("bad health")
 	}
 
 	rplidarDevice := &rplidarDevice{
-		driver:           driver,
-		model:            devInfo.GetModel(),
-		serialNumber:     serialNumStr,
-		firmwareVersion:  firmwareVer,
-		hardwareRevision: hardwareRev,
+		driver:             driver,
+		model:              devInfo.GetModel(),
+		serialNumber:       serialNumStr,
+		firmwareVersion:    firmwareVer,
+		firmwareVersionRaw: devInfo.GetFirmware_version(),
+		hardwareRevision:   hardwareRev,
+		healthStatus:       int(healthInfo.GetStatus()),
+		timeoutMs:          timeoutMs,
+		baudRate:           baudRate,
+	}
+
+	scanModes, err := rplidarDevice.querySupportedScanModes()
+	if err != nil {
+		releaseDriver(driver)
+		return nil, err
 	}
+	rplidarDevice.scanModes = scanModes
 
 	return rplidarDevice, nil
 }
+
+// startScan starts scanning using the requested express protocol and records the scan mode the device
+// ended up using. The legacy and extended protocols are only accepted if the device's firmware supports them, and
+// the standard protocol starts a standard scan without any express protocol.
+// If force is set, a standard scan is started with the SDK's force scan command instead, which makes the device
+// send data even if it does not detect the motor rotating. The SDK only supports forcing standard scans.
+func (device *rplidarDevice) startScan(protocol string, force bool) error {
+	usedScanMode := gen.NewRplidarScanMode()
+	defer gen.DeleteRplidarScanMode(usedScanMode)
+
+	var result uint
+	switch {
+	case force:
+		if protocol != "" && protocol != expressProtocolAuto {
+			return fmt.Errorf("express_protocol %q cannot be used with a forced scan", protocol)
+		}
+		result = device.driver.StartScan(true, false, uint(0), usedScanMode)
+	case protocol == expressProtocolLegacy:
+		if err := device.checkExpressProtocol(protocol); err != nil {
+			return err
+		}
+		result = device.driver.StartScanExpress(false, uint16(gen.RPLIDAR_CONF_SCAN_COMMAND_EXPRESS), uint(0), usedScanMode)
+	case protocol == expressProtocolExtended:
+		if err := device.checkExpressProtocol(protocol); err != nil {
+			return err
+		}
+		var typicalScanMode uint16
+		if result := device.driver.GetTypicalScanMode(&typicalScanMode); Result(result) != ResultOk {
+			return fmt.Errorf("failed to get typical scan mode: %w", Result(result).Failed())
+		}
+		result = device.driver.StartScanExpress(false, typicalScanMode, uint(0), usedScanMode)
+	case protocol == expressProtocolStandard:
+		result = device.driver.StartScan(false, false, uint(0), usedScanMode)
+	default:
+		result = device.driver.StartScan(false, true, uint(0), usedScanMode)
+	}
+	if err := Result(result).Failed(); err != nil {
+		return fmt.Errorf("failed to start scan: %w", err)
+	}
+	device.setScanMode(usedScanMode)
+	return nil
+}
+
+// checkExpressProtocol returns an error if the family or firmware of the device does not support scanning with
+// protocol.
+func (device *rplidarDevice) checkExpressProtocol(protocol string) error {
+	switch protocol {
+	case expressProtocolLegacy:
+		if device.family() == FamilyS {
+			return fmt.Errorf("express_protocol %q is not supported by %v rplidars", protocol, FamilyS)
+		}
+		if device.firmwareVersionRaw < minFirmwareLegacyExpress {
+			return fmt.Errorf("express_protocol %q requires firmware 1.17 or newer, found %v",
+				protocol, device.firmwareVersion)
+		}
+	case expressProtocolExtended:
+		// The S-series numbers its firmware from 1.0 and supports the configuration commands on all of it.
+		if device.family() == FamilyA && device.firmwareVersionRaw < minFirmwareExtendedExpress {
+			return fmt.Errorf("express_protocol %q requires firmware 1.24 or newer, found %v",
+				protocol, device.firmwareVersion)
+		}
+	}
+	return nil
+}
+
+// setScanMode records the scan mode the device started scanning in.
+func (device *rplidarDevice) setScanMode(usedScanMode gen.RplidarScanMode) {
+	device.modeMutex.Lock()
+	defer device.modeMutex.Unlock()
+	device.scanModeName = usedScanMode.GetScan_mode()
+	device.expressProtocol = expressProtocolFromAnsType(usedScanMode.GetAns_type())
+}
+
+// healthStatusToString converts an rplidar health status to a human readable string.
+func healthStatusToString(status int) string {
+	switch status {
+	case gen.RPLIDAR_STATUS_OK:
+		return "ok"
+	case gen.RPLIDAR_STATUS_WARNING:
+		return "warning"
+	case gen.RPLIDAR_STATUS_ERROR:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// expressProtocolFromAnsType maps the answer type of a scan mode to the express protocol it is served with.
+func expressProtocolFromAnsType(ansType byte) string {
+	switch int(ansType) {
+	case gen.RPLIDAR_ANS_TYPE_MEASUREMENT_CAPSULED:
+		return expressProtocolLegacy
+	case gen.RPLIDAR_ANS_TYPE_MEASUREMENT_CAPSULED_ULTRA, gen.RPLIDAR_ANS_TYPE_MEASUREMENT_DENSE_CAPSULED:
+		return expressProtocolExtended
+	default:
+		return expressProtocolStandard
+	}
+}
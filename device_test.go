@@ -0,0 +1,207 @@
+package rplidar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.viam.com/rplidar/gen"
+	"go.viam.com/rplidar/inject"
+	"go.viam.com/test"
+)
+
+func TestStartScan(t *testing.T) {
+	injectedRPlidarDriver := inject.NewRPLiDARDriver()
+	var calledStartScan, calledStartScanExpress bool
+	injectedRPlidarDriver.StartScanFunc = func(a ...interface{}) uint {
+		calledStartScan = true
+		return uint(gen.RESULT_OK)
+	}
+	injectedRPlidarDriver.StartScanExpressFunc = func(a ...interface{}) uint {
+		calledStartScanExpress = true
+		return uint(gen.RESULT_OK)
+	}
+	injectedRPlidarDriver.GetTypicalScanModeFunc = func(a ...interface{}) uint {
+		return uint(gen.RESULT_OK)
+	}
+
+	t.Run("legacy protocol on firmware without express support", func(t *testing.T) {
+		calledStartScan, calledStartScanExpress = false, false
+		device := rplidarDevice{driver: &injectedRPlidarDriver, firmwareVersion: "1.16", firmwareVersionRaw: 1<<8 | 16}
+
+		err := device.startScan(expressProtocolLegacy, false)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "requires firmware 1.17 or newer, found 1.16")
+		test.That(t, calledStartScanExpress, test.ShouldBeFalse)
+	})
+
+	t.Run("extended protocol on firmware without configuration commands", func(t *testing.T) {
+		calledStartScan, calledStartScanExpress = false, false
+		device := rplidarDevice{driver: &injectedRPlidarDriver, firmwareVersion: "1.20", firmwareVersionRaw: 1<<8 | 20}
+
+		err := device.startScan(expressProtocolExtended, false)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "requires firmware 1.24 or newer, found 1.20")
+		test.That(t, calledStartScanExpress, test.ShouldBeFalse)
+	})
+
+	t.Run("supported protocols start an express scan", func(t *testing.T) {
+		for _, protocol := range []string{expressProtocolLegacy, expressProtocolExtended} {
+			calledStartScan, calledStartScanExpress = false, false
+			device := rplidarDevice{driver: &injectedRPlidarDriver, firmwareVersionRaw: 1<<8 | 29}
+
+			err := device.startScan(protocol, false)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, calledStartScan, test.ShouldBeFalse)
+			test.That(t, calledStartScanExpress, test.ShouldBeTrue)
+		}
+	})
+
+	t.Run("auto protocol lets the sdk select the scan mode", func(t *testing.T) {
+		for _, protocol := range []string{"", expressProtocolAuto} {
+			calledStartScan, calledStartScanExpress = false, false
+			device := rplidarDevice{driver: &injectedRPlidarDriver}
+
+			err := device.startScan(protocol, false)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, calledStartScan, test.ShouldBeTrue)
+			test.That(t, calledStartScanExpress, test.ShouldBeFalse)
+		}
+	})
+
+	t.Run("forced scan starts a forced standard scan", func(t *testing.T) {
+		forcingRPlidarDriver := inject.NewRPLiDARDriver()
+		var force, useTypicalScan bool
+		forcingRPlidarDriver.StartScanFunc = func(a ...interface{}) uint {
+			args := a[0].([]interface{})
+			force, useTypicalScan = args[0].(bool), args[1].(bool)
+			return uint(gen.RESULT_OK)
+		}
+		device := rplidarDevice{driver: &forcingRPlidarDriver}
+
+		err := device.startScan(expressProtocolAuto, true)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, force, test.ShouldBeTrue)
+		test.That(t, useTypicalScan, test.ShouldBeFalse)
+	})
+
+	t.Run("forced scan with an express protocol", func(t *testing.T) {
+		calledStartScan, calledStartScanExpress = false, false
+		device := rplidarDevice{driver: &injectedRPlidarDriver, firmwareVersionRaw: 1<<8 | 29}
+
+		err := device.startScan(expressProtocolLegacy, true)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "cannot be used with a forced scan")
+		test.That(t, calledStartScan, test.ShouldBeFalse)
+		test.That(t, calledStartScanExpress, test.ShouldBeFalse)
+	})
+
+	t.Run("failure to start the scan", func(t *testing.T) {
+		failingRPlidarDriver := inject.NewRPLiDARDriver()
+		failingRPlidarDriver.StartScanFunc = func(a ...interface{}) uint {
+			return uint(gen.RESULT_OPERATION_FAIL)
+		}
+		device := rplidarDevice{driver: &failingRPlidarDriver}
+
+		err := device.startScan(expressProtocolAuto, false)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "failed to start scan")
+	})
+}
+
+func TestExpressProtocolFromAnsType(t *testing.T) {
+	test.That(t, expressProtocolFromAnsType(gen.RPLIDAR_ANS_TYPE_MEASUREMENT), test.ShouldEqual, expressProtocolStandard)
+	test.That(t, expressProtocolFromAnsType(gen.RPLIDAR_ANS_TYPE_MEASUREMENT_CAPSULED), test.ShouldEqual, expressProtocolLegacy)
+	test.That(t, expressProtocolFromAnsType(gen.RPLIDAR_ANS_TYPE_MEASUREMENT_CAPSULED_ULTRA),
+		test.ShouldEqual, expressProtocolExtended)
+	test.That(t, expressProtocolFromAnsType(gen.RPLIDAR_ANS_TYPE_MEASUREMENT_DENSE_CAPSULED),
+		test.ShouldEqual, expressProtocolExtended)
+}
+
+func TestResolveDevicePath(t *testing.T) {
+	dir := t.TempDir()
+	byIDDir := filepath.Join(dir, "serial", "by-id")
+	test.That(t, os.MkdirAll(byIDDir, 0o755), test.ShouldBeNil)
+
+	ttyUSB0 := filepath.Join(dir, "ttyUSB0")
+	ttyUSB1 := filepath.Join(dir, "ttyUSB1")
+	for _, path := range []string{ttyUSB0, ttyUSB1} {
+		f, err := os.Create(path)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, f.Close(), test.ShouldBeNil)
+	}
+	rplidarByID := filepath.Join(byIDDir, "usb-Silicon_Labs_CP2102_rplidar-if00-port0")
+	otherByID := filepath.Join(byIDDir, "usb-FTDI_other-if00-port0")
+	test.That(t, os.Symlink(ttyUSB0, rplidarByID), test.ShouldBeNil)
+	test.That(t, os.Symlink(ttyUSB1, otherByID), test.ShouldBeNil)
+
+	t.Run("plain paths are returned as is", func(t *testing.T) {
+		for _, path := range []string{"", "/dev/ttyUSB0", "/dev/does-not-exist"} {
+			resolved, err := resolveDevicePath(path)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, resolved, test.ShouldEqual, path)
+		}
+	})
+
+	t.Run("pattern matching a single device", func(t *testing.T) {
+		resolved, err := resolveDevicePath(filepath.Join(byIDDir, "*rplidar*"))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resolved, test.ShouldEqual, rplidarByID)
+	})
+
+	t.Run("stable by-id path is preferred over a volatile path to the same device", func(t *testing.T) {
+		byPathDir := filepath.Join(dir, "serial", "by-path")
+		test.That(t, os.MkdirAll(byPathDir, 0o755), test.ShouldBeNil)
+		rplidarByPath := filepath.Join(byPathDir, "pci-0000:00:14.0-usb-0:1:1.0-rplidar-port0")
+		test.That(t, os.Symlink(ttyUSB0, rplidarByPath), test.ShouldBeNil)
+		defer os.Remove(rplidarByPath)
+
+		resolved, err := resolveDevicePath(filepath.Join(dir, "serial", "*", "*rplidar*"))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resolved, test.ShouldEqual, rplidarByID)
+	})
+
+	t.Run("pattern matching no device", func(t *testing.T) {
+		resolved, err := resolveDevicePath(filepath.Join(byIDDir, "*slamtec*"))
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "no devices match serial_path")
+		test.That(t, resolved, test.ShouldBeEmpty)
+	})
+
+	t.Run("pattern matching multiple devices lists the candidates", func(t *testing.T) {
+		resolved, err := resolveDevicePath(filepath.Join(byIDDir, "usb-*"))
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "matches multiple devices")
+		test.That(t, err.Error(), test.ShouldContainSubstring, rplidarByID)
+		test.That(t, err.Error(), test.ShouldContainSubstring, otherByID)
+		test.That(t, resolved, test.ShouldBeEmpty)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		resolved, err := resolveDevicePath("/dev/[")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "invalid serial_path pattern")
+		test.That(t, resolved, test.ShouldBeEmpty)
+	})
+}
+
+func TestSelectDevicePath(t *testing.T) {
+	paths := []string{"/dev/ttyUSB1", "/dev/ttyUSB0"}
+
+	t.Run("devices are ordered by path", func(t *testing.T) {
+		path, err := selectDevicePath(paths, 0)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, path, test.ShouldEqual, "/dev/ttyUSB0")
+
+		path, err = selectDevicePath(paths, 1)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, path, test.ShouldEqual, "/dev/ttyUSB1")
+		test.That(t, paths, test.ShouldResemble, []string{"/dev/ttyUSB1", "/dev/ttyUSB0"})
+	})
+
+	t.Run("index out of range", func(t *testing.T) {
+		_, err := selectDevicePath(paths, 2)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "device_index 2 is out of range, only 2 devices found")
+	})
+}
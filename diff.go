@@ -0,0 +1,127 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// PointMove is a point of the first point cloud of a diff that is at a different position in the second one.
+type PointMove struct {
+	From     r3.Vector
+	To       r3.Vector
+	Distance float64
+}
+
+// DiffResult describes how a point cloud differs from another one, see DiffPointClouds.
+type DiffResult struct {
+	// Unchanged is the number of points at exactly the same position in both point clouds.
+	Unchanged int
+	// Moved holds the points that are within the tolerance of a point of the other point cloud, but not at the
+	// same position.
+	Moved []PointMove
+	// Added holds the points of the second point cloud without a match in the first one.
+	Added []r3.Vector
+	// Removed holds the points of the first point cloud without a match in the second one.
+	Removed []r3.Vector
+	// MaxMove is the largest distance of the moved points, zero if none moved.
+	MaxMove float64
+}
+
+// Equal reports whether both point clouds contain the same points at the same positions.
+func (d DiffResult) Equal() bool {
+	return len(d.Moved) == 0 && len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// String summarizes the diff in a single line.
+func (d DiffResult) String() string {
+	return fmt.Sprintf("%d unchanged, %d moved by up to %.3g, %d added, %d removed",
+		d.Unchanged, len(d.Moved), d.MaxMove, len(d.Added), len(d.Removed))
+}
+
+// diffCell identifies a cube of the grid the points of a diff are bucketed into.
+type diffCell struct {
+	x, y, z int64
+}
+
+// DiffPointClouds compares point cloud b against a. Every point of b is matched with the nearest point of a within
+// tol that is not matched yet, in the order b iterates its points, so each point is matched at most once. Matched
+// points are unchanged if they are at the same position and moved otherwise. The points of b without a match are
+// added and the points of a without a match are removed. tol is in the units of the point clouds, which are
+// millimeters for the camera's point clouds, and a tol of zero only matches points at the same position. Only
+// positions are compared, not the data of the points. The point clouds may differ in size.
+func DiffPointClouds(a, b pointcloud.PointCloud, tol float64) (DiffResult, error) {
+	if a == nil || b == nil {
+		return DiffResult{}, errors.New("both point clouds are required")
+	}
+	if tol < 0 || math.IsNaN(tol) || math.IsInf(tol, 0) {
+		return DiffResult{}, errors.New("tol must be a finite, non negative number")
+	}
+
+	// Bucket the points of a into cubes of the tolerance's size, so each match only has to look at the
+	// neighboring cubes.
+	cellSize := tol
+	if cellSize == 0 {
+		cellSize = 1
+	}
+	cellOf := func(p r3.Vector) diffCell {
+		return diffCell{
+			int64(math.Floor(p.X / cellSize)),
+			int64(math.Floor(p.Y / cellSize)),
+			int64(math.Floor(p.Z / cellSize)),
+		}
+	}
+	var points []r3.Vector
+	cells := map[diffCell][]int{}
+	a.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		cell := cellOf(p)
+		cells[cell] = append(cells[cell], len(points))
+		points = append(points, p)
+		return true
+	})
+	matched := make([]bool, len(points))
+
+	var result DiffResult
+	b.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		nearest, nearestDistance := -1, math.Inf(1)
+		cell := cellOf(p)
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for dz := int64(-1); dz <= 1; dz++ {
+					for _, i := range cells[diffCell{cell.x + dx, cell.y + dy, cell.z + dz}] {
+						if matched[i] {
+							continue
+						}
+						if distance := points[i].Distance(p); distance <= tol && distance < nearestDistance {
+							nearest, nearestDistance = i, distance
+						}
+					}
+				}
+			}
+		}
+
+		switch {
+		case nearest < 0:
+			result.Added = append(result.Added, p)
+		case nearestDistance == 0:
+			matched[nearest] = true
+			result.Unchanged++
+		default:
+			matched[nearest] = true
+			result.Moved = append(result.Moved, PointMove{From: points[nearest], To: p, Distance: nearestDistance})
+			result.MaxMove = math.Max(result.MaxMove, nearestDistance)
+		}
+		return true
+	})
+
+	for i, p := range points {
+		if !matched[i] {
+			result.Removed = append(result.Removed, p)
+		}
+	}
+	return result, nil
+}
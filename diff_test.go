@@ -0,0 +1,84 @@
+package rplidar
+
+import (
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestDiffPointClouds(t *testing.T) {
+	cloudOf := func(points ...r3.Vector) pointcloud.PointCloud {
+		pc := pointcloud.New()
+		for _, p := range points {
+			test.That(t, pc.Set(p, nil), test.ShouldBeNil)
+		}
+		return pc
+	}
+
+	t.Run("identical point clouds", func(t *testing.T) {
+		a := cloudOf(r3.Vector{X: 1}, r3.Vector{X: 2}, r3.Vector{Y: 3})
+		diff, err := DiffPointClouds(a, a, 5)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, diff.Equal(), test.ShouldBeTrue)
+		test.That(t, diff.Unchanged, test.ShouldEqual, 3)
+		test.That(t, diff.String(), test.ShouldEqual, "3 unchanged, 0 moved by up to 0, 0 added, 0 removed")
+	})
+
+	t.Run("added, removed and moved points", func(t *testing.T) {
+		a := cloudOf(r3.Vector{X: 100}, r3.Vector{X: 200}, r3.Vector{X: 300})
+		b := cloudOf(r3.Vector{X: 100}, r3.Vector{X: 203, Y: 4}, r3.Vector{X: 500}, r3.Vector{X: 600})
+		diff, err := DiffPointClouds(a, b, 10)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, diff.Equal(), test.ShouldBeFalse)
+		test.That(t, diff.Unchanged, test.ShouldEqual, 1)
+		test.That(t, diff.Moved, test.ShouldResemble, []PointMove{
+			{From: r3.Vector{X: 200}, To: r3.Vector{X: 203, Y: 4}, Distance: 5},
+		})
+		test.That(t, diff.MaxMove, test.ShouldEqual, 5)
+		test.That(t, diff.Added, test.ShouldResemble, []r3.Vector{{X: 500}, {X: 600}})
+		test.That(t, diff.Removed, test.ShouldResemble, []r3.Vector{{X: 300}})
+	})
+
+	t.Run("points beyond the tolerance are not matched", func(t *testing.T) {
+		diff, err := DiffPointClouds(cloudOf(r3.Vector{X: 100}), cloudOf(r3.Vector{X: 111}), 10)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(diff.Added), test.ShouldEqual, 1)
+		test.That(t, len(diff.Removed), test.ShouldEqual, 1)
+	})
+
+	t.Run("every point is matched at most once", func(t *testing.T) {
+		a := cloudOf(r3.Vector{X: 100})
+		b := cloudOf(r3.Vector{X: 101}, r3.Vector{X: 102})
+		diff, err := DiffPointClouds(a, b, 10)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(diff.Moved), test.ShouldEqual, 1)
+		test.That(t, len(diff.Added), test.ShouldEqual, 1)
+		test.That(t, diff.Removed, test.ShouldBeEmpty)
+	})
+
+	t.Run("zero tolerance only matches the same positions", func(t *testing.T) {
+		a := cloudOf(r3.Vector{X: 1.5}, r3.Vector{X: -2})
+		b := cloudOf(r3.Vector{X: 1.5}, r3.Vector{X: -2.1})
+		diff, err := DiffPointClouds(a, b, 0)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, diff.Unchanged, test.ShouldEqual, 1)
+		test.That(t, diff.Added, test.ShouldResemble, []r3.Vector{{X: -2.1}})
+		test.That(t, diff.Removed, test.ShouldResemble, []r3.Vector{{X: -2}})
+	})
+
+	t.Run("empty point clouds", func(t *testing.T) {
+		diff, err := DiffPointClouds(pointcloud.New(), cloudOf(r3.Vector{X: 1}), 1)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(diff.Added), test.ShouldEqual, 1)
+		test.That(t, diff.Removed, test.ShouldBeEmpty)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := DiffPointClouds(nil, pointcloud.New(), 1)
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = DiffPointClouds(pointcloud.New(), pointcloud.New(), -1)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,35 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+// Family is the product line of an rplidar. The families number their firmware independently and support
+// different express protocols, so scanning has to be started differently for each.
+type Family string
+
+const (
+	// FamilyA is the A-series, the A1 and A3, which support the legacy express protocol from firmware 1.17 and
+	// the extended one from firmware 1.24.
+	FamilyA Family = "A-series"
+	// FamilyS is the S-series, the S1, which only supports the extended express protocol, with the dense
+	// capsules, on any firmware.
+	FamilyS Family = "S-series"
+)
+
+// familyOf returns the family of model.
+func familyOf(model RPLiDARModel) Family {
+	if model == S1 {
+		return FamilyS
+	}
+	return FamilyA
+}
+
+// family returns the family of the device, according to the model assumed for it if its model ID is unknown.
+func (device *rplidarDevice) family() Family {
+	return familyOf(device.rplidarModel())
+}
+
+// Family returns the family of the connected rplidar, which decides how scanning is started.
+func (rp *rplidar) Family() Family {
+	rp.device.modeMutex.RLock()
+	defer rp.device.modeMutex.RUnlock()
+	return rp.device.family()
+}
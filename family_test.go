@@ -0,0 +1,135 @@
+package rplidar
+
+import (
+	"testing"
+
+	"go.viam.com/rplidar/gen"
+	"go.viam.com/rplidar/inject"
+	rputils "go.viam.com/rplidar/utils"
+	"go.viam.com/test"
+)
+
+// rawNode is a measurement node as the SDK hands it over, before decoding.
+type rawNode struct {
+	angleQ14 uint16
+	distQ2   uint
+	quality  byte
+	flag     byte
+}
+
+// nodesOf returns a node buffer holding nodes, as filled by a grab.
+func nodesOf(nodes []rawNode) gen.Rplidar_response_measurement_node_hq_t {
+	buf := gen.New_measurementNodeHqArray(len(nodes))
+	fillNodes(buf, nodes)
+	return buf
+}
+
+// fillNodes fills the start of the node buffer buf with nodes, as a grab does.
+func fillNodes(buf gen.Rplidar_response_measurement_node_hq_t, nodes []rawNode) {
+	for i, n := range nodes {
+		node := gen.NewRplidar_response_measurement_node_hq_t()
+		node.SetAngle_z_q14(n.angleQ14)
+		node.SetDist_mm_q2(n.distQ2)
+		node.SetQuality(n.quality)
+		node.SetFlag(n.flag)
+		gen.MeasurementNodeHqArray_setitem(buf, rputils.CastInt(i), node)
+		gen.DeleteRplidar_response_measurement_node_hq_t(node)
+	}
+}
+
+func TestFamily(t *testing.T) {
+	for model, family := range map[byte]Family{24: FamilyA, 49: FamilyA, 97: FamilyS} {
+		rp := rplidar{device: &rplidarDevice{model: model}}
+		test.That(t, rp.Family(), test.ShouldEqual, family)
+	}
+	// Unknown models have the family of the model assumed for them.
+	rp := rplidar{device: &rplidarDevice{model: 200, assumedModel: S1}}
+	test.That(t, rp.Family(), test.ShouldEqual, FamilyS)
+}
+
+func TestStartScanByFamily(t *testing.T) {
+	driver := inject.NewRPLiDARDriver()
+	var calledStartScanExpress bool
+	driver.StartScanExpressFunc = func(a ...interface{}) uint {
+		calledStartScanExpress = true
+		return uint(gen.RESULT_OK)
+	}
+	driver.GetTypicalScanModeFunc = func(a ...interface{}) uint {
+		return uint(gen.RESULT_OK)
+	}
+
+	t.Run("the s-series has no legacy express protocol", func(t *testing.T) {
+		calledStartScanExpress = false
+		device := rplidarDevice{driver: &driver, model: 97, firmwareVersionRaw: 1<<8 | 29}
+
+		err := device.startScan(expressProtocolLegacy, false)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "is not supported by S-series rplidars")
+		test.That(t, calledStartScanExpress, test.ShouldBeFalse)
+	})
+
+	t.Run("the s-series firmware supports the extended express protocol", func(t *testing.T) {
+		calledStartScanExpress = false
+		device := rplidarDevice{driver: &driver, model: 97, firmwareVersion: "1.02", firmwareVersionRaw: 1<<8 | 2}
+
+		test.That(t, device.startScan(expressProtocolExtended, false), test.ShouldBeNil)
+		test.That(t, calledStartScanExpress, test.ShouldBeTrue)
+	})
+
+	t.Run("the a-series firmware requirements still apply", func(t *testing.T) {
+		calledStartScanExpress = false
+		device := rplidarDevice{driver: &driver, model: 24, firmwareVersion: "1.02", firmwareVersionRaw: 1<<8 | 2}
+
+		err := device.startScan(expressProtocolExtended, false)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "requires firmware 1.24 or newer, found 1.02")
+		test.That(t, calledStartScanExpress, test.ShouldBeFalse)
+	})
+}
+
+// The nodes below are synthetic, built by hand to mirror what the SDK hands over for each family rather than
+// captured from real devices: they only check that decoding is independent of the family, not the SDK's own
+// decoding of the protocols.
+func TestDecodeNodesByFamily(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		nodes    []rawNode
+		expected []measurement
+	}{
+		{
+			// Shaped like a standard scan of an A1, with a quality per sample and a sample without a return.
+			name: "a-series standard scan",
+			nodes: []rawNode{
+				{angleQ14: 1820, distQ2: 4000, quality: 60, flag: 1},
+				{angleQ14: 1984, distQ2: 4010, quality: 56},
+				{angleQ14: 2148, distQ2: 0, quality: 0},
+			},
+			expected: []measurement{
+				{angleDeg: 9.99755859375, distanceMM: 1000, quality: 60},
+				{angleDeg: 10.8984375, distanceMM: 1002.5, quality: 56},
+			},
+		},
+		{
+			// Shaped like a dense express scan of an S1, with the fixed quality the SDK reports for dense
+			// capsules, a slot without a return and a return beyond the range of the A-series.
+			name: "s-series dense express scan",
+			nodes: []rawNode{
+				{angleQ14: 8192, distQ2: 12000, quality: 188, flag: 1},
+				{angleQ14: 8212, distQ2: 0, quality: 0},
+				{angleQ14: 8232, distQ2: 12004, quality: 188},
+				{angleQ14: 8252, distQ2: 160000, quality: 188},
+			},
+			expected: []measurement{
+				{angleDeg: 45, distanceMM: 3000, quality: 188},
+				{angleDeg: 45.2197265625, distanceMM: 3001, quality: 188},
+				{angleDeg: 45.32958984375, distanceMM: 40000, quality: 188},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rp := rplidar{nodes: nodesOf(tc.nodes)}
+			defer gen.Delete_measurementNodeHqArray(rp.nodes)
+			test.That(t, rp.decodeNodes(int64(len(tc.nodes))), test.ShouldResemble, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+//go:build rplidar_faults
+// +build rplidar_faults
+
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrInjectedDisconnect is returned by NextPointCloud while a disconnect is being simulated.
+var ErrInjectedDisconnect = errors.New("injected fault: rplidar disconnected")
+
+// FaultInjector is implemented by the rplidar camera only when built with the rplidar_faults build tag. It
+// allows tests and tools to make NextPointCloud fail on command, without real hardware, to exercise how
+// callers react to sensor faults.
+type FaultInjector interface {
+	// InjectErrors makes the next n calls to NextPointCloud return err.
+	InjectErrors(n int, err error)
+	// InjectStall makes the next call to NextPointCloud block for d, or until its context is done.
+	InjectStall(d time.Duration)
+	// InjectDisconnect makes every call to NextPointCloud fail with ErrInjectedDisconnect until it is called
+	// again with false.
+	InjectDisconnect(disconnected bool)
+}
+
+// faultInjector holds the faults injected into an rplidar. The zero value injects no faults.
+type faultInjector struct {
+	mutex        sync.Mutex
+	errorCount   int
+	err          error
+	stall        time.Duration
+	disconnected bool
+}
+
+// nextPointCloudFault applies the injected faults to a NextPointCloud call, returning the error it should fail with.
+func (f *faultInjector) nextPointCloudFault(ctx context.Context) error {
+	f.mutex.Lock()
+	stall := f.stall
+	f.stall = 0
+	f.mutex.Unlock()
+
+	if stall > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(stall):
+		}
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.disconnected {
+		return ErrInjectedDisconnect
+	}
+	if f.errorCount > 0 {
+		f.errorCount--
+		return f.err
+	}
+	return nil
+}
+
+// InjectErrors makes the next n calls to NextPointCloud return err. See FaultInjector.
+func (rp *rplidar) InjectErrors(n int, err error) {
+	rp.faults.mutex.Lock()
+	defer rp.faults.mutex.Unlock()
+	rp.faults.errorCount = n
+	rp.faults.err = err
+}
+
+// InjectStall makes the next call to NextPointCloud block for d. See FaultInjector.
+func (rp *rplidar) InjectStall(d time.Duration) {
+	rp.faults.mutex.Lock()
+	defer rp.faults.mutex.Unlock()
+	rp.faults.stall = d
+}
+
+// InjectDisconnect simulates a disconnected rplidar until called again with false. See FaultInjector.
+func (rp *rplidar) InjectDisconnect(disconnected bool) {
+	rp.faults.mutex.Lock()
+	defer rp.faults.mutex.Unlock()
+	rp.faults.disconnected = disconnected
+}
@@ -0,0 +1,15 @@
+//go:build !rplidar_faults
+// +build !rplidar_faults
+
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import "context"
+
+// faultInjector injects no faults in production builds. Build with the rplidar_faults tag to enable fault injection.
+type faultInjector struct{}
+
+// nextPointCloudFault never fails in production builds.
+func (f *faultInjector) nextPointCloudFault(ctx context.Context) error {
+	return nil
+}
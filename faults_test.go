@@ -0,0 +1,77 @@
+//go:build rplidar_faults
+// +build rplidar_faults
+
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestFaultInjection(t *testing.T) {
+	ctx := context.Background()
+	rp := &rplidar{
+		cache: &dataCache{pointCloud: pointcloud.New()},
+	}
+	var _ FaultInjector = rp
+
+	t.Run("no faults injected", func(t *testing.T) {
+		pc, err := rp.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldNotBeNil)
+	})
+
+	t.Run("next n calls fail with the injected error", func(t *testing.T) {
+		injectedErr := errors.New("injected")
+		rp.InjectErrors(2, injectedErr)
+		for i := 0; i < 2; i++ {
+			pc, err := rp.NextPointCloud(ctx)
+			test.That(t, err, test.ShouldBeError, injectedErr)
+			test.That(t, pc, test.ShouldBeNil)
+		}
+
+		pc, err := rp.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldNotBeNil)
+	})
+
+	t.Run("disconnect lasts until cleared", func(t *testing.T) {
+		rp.InjectDisconnect(true)
+		for i := 0; i < 3; i++ {
+			_, err := rp.NextPointCloud(ctx)
+			test.That(t, err, test.ShouldBeError, ErrInjectedDisconnect)
+		}
+
+		rp.InjectDisconnect(false)
+		_, err := rp.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+	})
+
+	t.Run("stall blocks the next call only", func(t *testing.T) {
+		rp.InjectStall(20 * time.Millisecond)
+
+		startTime := time.Now()
+		_, err := rp.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, time.Since(startTime), test.ShouldBeGreaterThanOrEqualTo, 20*time.Millisecond)
+
+		startTime = time.Now()
+		_, err = rp.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, time.Since(startTime), test.ShouldBeLessThan, 20*time.Millisecond)
+	})
+
+	t.Run("stall is interrupted by the context", func(t *testing.T) {
+		rp.InjectStall(time.Minute)
+		cancelCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := rp.NextPointCloud(cancelCtx)
+		test.That(t, err, test.ShouldBeError, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,51 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ErrFirmwareTooOld is wrapped by the error returned when constructing the camera for an rplidar whose firmware is
+// older than min_firmware_version.
+var ErrFirmwareTooOld = errors.New("rplidar firmware too old")
+
+// firmwareVersionPattern matches a firmware version as get_device_info reports it, e.g. 1.29.
+var firmwareVersionPattern = regexp.MustCompile(`^(\d{1,3})\.(\d{2})$`)
+
+// parseFirmwareVersion parses a firmware version of the form major.minor with a two digit minor version, e.g. 1.29
+// or 1.05, into the major<<8 | minor encoding of the device. An empty version parses to 0, which any firmware is
+// at least.
+func parseFirmwareVersion(version string) (uint16, error) {
+	if version == "" {
+		return 0, nil
+	}
+	parts := firmwareVersionPattern.FindStringSubmatch(version)
+	if parts == nil {
+		return 0, fmt.Errorf("min_firmware_version must be major.minor with a two digit minor version, e.g. \"1.29\", got %q",
+			version)
+	}
+	major, err := strconv.Atoi(parts[1])
+	if err != nil || major > 0xFF {
+		return 0, fmt.Errorf("min_firmware_version must have a major version of at most 255, got %q", version)
+	}
+	// Two digits always parse and fit into a byte.
+	minor, _ := strconv.Atoi(parts[2])
+	return uint16(major<<8 | minor), nil
+}
+
+// checkFirmwareVersion returns an error wrapping ErrFirmwareTooOld if the firmware of device is older than
+// minVersion, given as in min_firmware_version.
+func checkFirmwareVersion(device *rplidarDevice, minVersion string) error {
+	required, err := parseFirmwareVersion(minVersion)
+	if err != nil {
+		return err
+	}
+	if device.firmwareVersionRaw < required {
+		return fmt.Errorf("%w: found %v on the rplidar with serial number %v, %v or newer is required, flash it first",
+			ErrFirmwareTooOld, device.firmwareVersion, device.serialNumber, minVersion)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package rplidar
+
+import (
+	"errors"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestParseFirmwareVersion(t *testing.T) {
+	for version, encoded := range map[string]uint16{"": 0, "1.29": 1<<8 | 29, "1.05": 1<<8 | 5, "2.00": 2 << 8} {
+		got, err := parseFirmwareVersion(version)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, got, test.ShouldEqual, encoded)
+	}
+	for _, version := range []string{"1", "1.5", "1.290", "v1.29", "1.29.1", "256.00"} {
+		_, err := parseFirmwareVersion(version)
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
+
+func TestCheckFirmwareVersion(t *testing.T) {
+	device := &rplidarDevice{serialNumber: "ABC", firmwareVersion: "1.24", firmwareVersionRaw: 1<<8 | 24}
+
+	test.That(t, checkFirmwareVersion(device, ""), test.ShouldBeNil)
+	test.That(t, checkFirmwareVersion(device, "1.24"), test.ShouldBeNil)
+	test.That(t, checkFirmwareVersion(device, "1.09"), test.ShouldBeNil)
+
+	err := checkFirmwareVersion(device, "1.29")
+	test.That(t, errors.Is(err, ErrFirmwareTooOld), test.ShouldBeTrue)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "found 1.24")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "1.29 or newer is required")
+
+	// The minor version compares as a number, not as digits after a decimal point.
+	err = checkFirmwareVersion(device, "2.00")
+	test.That(t, errors.Is(err, ErrFirmwareTooOld), test.ShouldBeTrue)
+}
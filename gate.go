@@ -0,0 +1,56 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import "sync"
+
+// ScanGate is implemented by the rplidar camera. It lets the robot skip scans smeared by its own motion, e.g.
+// while turning fast, by telling the camera whether its motion currently allows scanning. The gating is advisory:
+// the camera has no motion sensing of its own and relies entirely on the signal the caller supplies.
+type ScanGate interface {
+	// SetScanGate registers okToScan to be asked after every revolution whether the motion of the robot allowed
+	// it. Revolutions taken while it returns false are still grabbed, keeping the device warm, but not cached, so
+	// NextPointCloud keeps returning the last allowed scan and callers waiting for a new one wait for the next
+	// allowed scan. okToScan is called from the scan loop and must return quickly. Passing nil unregisters it.
+	SetScanGate(okToScan func() bool)
+}
+
+// scanGate decides whether the scan loop caches a scan, from the callback registered with SetScanGate and the
+// flag set with the set_motion_ok command. Both must allow it. The zero value allows every scan and all methods
+// are safe for concurrent use.
+type scanGate struct {
+	mutex    sync.Mutex
+	okToScan func() bool
+	// Whether the set_motion_ok command reported motion that does not allow scanning.
+	motionNotOK bool
+}
+
+// setCallback registers okToScan, or unregisters the callback if it is nil.
+func (g *scanGate) setCallback(okToScan func() bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.okToScan = okToScan
+}
+
+// setMotionOK records whether the motion of the robot allows scanning, as reported by set_motion_ok.
+func (g *scanGate) setMotionOK(ok bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.motionNotOK = !ok
+}
+
+// allows reports whether a scan completed now may be cached.
+func (g *scanGate) allows() bool {
+	g.mutex.Lock()
+	okToScan, motionNotOK := g.okToScan, g.motionNotOK
+	g.mutex.Unlock()
+	if motionNotOK {
+		return false
+	}
+	// The callback is called without the mutex held, so it may set the gate itself.
+	return okToScan == nil || okToScan()
+}
+
+// SetScanGate registers okToScan to be asked whether the robot's motion allows caching each scan. See ScanGate.
+func (rp *rplidar) SetScanGate(okToScan func() bool) {
+	rp.scanGate.setCallback(okToScan)
+}
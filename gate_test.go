@@ -0,0 +1,39 @@
+package rplidar
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestScanGate(t *testing.T) {
+	var gate scanGate
+	test.That(t, gate.allows(), test.ShouldBeTrue)
+
+	moving := true
+	gate.setCallback(func() bool { return !moving })
+	test.That(t, gate.allows(), test.ShouldBeFalse)
+	moving = false
+	test.That(t, gate.allows(), test.ShouldBeTrue)
+
+	// The flag and the callback both have to allow a scan.
+	gate.setMotionOK(false)
+	test.That(t, gate.allows(), test.ShouldBeFalse)
+	gate.setMotionOK(true)
+	test.That(t, gate.allows(), test.ShouldBeTrue)
+
+	gate.setCallback(func() bool { return false })
+	test.That(t, gate.allows(), test.ShouldBeFalse)
+	gate.setCallback(nil)
+	test.That(t, gate.allows(), test.ShouldBeTrue)
+
+	t.Run("the callback may set the flag", func(t *testing.T) {
+		var gate scanGate
+		gate.setCallback(func() bool {
+			gate.setMotionOK(false)
+			return true
+		})
+		test.That(t, gate.allows(), test.ShouldBeTrue)
+		test.That(t, gate.allows(), test.ShouldBeFalse)
+	})
+}
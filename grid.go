@@ -0,0 +1,146 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// CellState is the state of a single cell of an OccupancyGrid.
+type CellState uint8
+
+const (
+	// CellUnknown cells were not observed by the scan.
+	CellUnknown CellState = iota
+	// CellFree cells lie between the sensor and a return.
+	CellFree
+	// CellOccupied cells contain at least one return.
+	CellOccupied
+)
+
+// OccupancyGrid is a 2D occupancy grid of a scan, centered on the sensor.
+//
+// The grid is Width by Height cells of CellSizeMM millimeters each. The sensor is at the center of the grid, so
+// for an even size it lies on the corner shared by the four middle cells and for an odd size at the center of the
+// middle cell. Columns increase along the scan's X axis and rows along its Y axis, so the cell at column 0, row 0
+// covers the most negative X and Y. Unlike an image, row 0 is at the bottom of the grid.
+type OccupancyGrid struct {
+	CellSizeMM float64
+	Width      int
+	Height     int
+	// Cells holds the state of every cell, row by row, starting at row 0.
+	Cells []CellState
+}
+
+// At returns the state of the cell at col and row, or CellUnknown if it is outside of the grid.
+func (g OccupancyGrid) At(col, row int) CellState {
+	if col < 0 || col >= g.Width || row < 0 || row >= g.Height {
+		return CellUnknown
+	}
+	return g.Cells[row*g.Width+col]
+}
+
+// NewOccupancyGrid rasterizes scan into an occupancy grid of widthCells by heightCells cells of cellSizeMM
+// millimeters each, using the convention documented on OccupancyGrid. Every cell a ray from the sensor to a return
+// passes through is free and every cell containing a return is occupied, taking precedence over free. Rays to
+// returns outside of the grid mark the cells up to the edge of the grid as free. All other cells are unknown.
+func NewOccupancyGrid(scan pointcloud.PointCloud, cellSizeMM float64, widthCells, heightCells int) (OccupancyGrid, error) {
+	if scan == nil {
+		return OccupancyGrid{}, errors.New("no point cloud to rasterize")
+	}
+	if cellSizeMM <= 0 {
+		return OccupancyGrid{}, errors.New("cellSizeMM must be positive")
+	}
+	if widthCells <= 0 || heightCells <= 0 {
+		return OccupancyGrid{}, errors.New("widthCells and heightCells must be positive")
+	}
+
+	grid := OccupancyGrid{
+		CellSizeMM: cellSizeMM,
+		Width:      widthCells,
+		Height:     heightCells,
+		Cells:      make([]CellState, widthCells*heightCells),
+	}
+	// The sensor's position in grid coordinates, in which every cell is a unit square.
+	originX, originY := float64(widthCells)/2, float64(heightCells)/2
+
+	var hits [][2]int
+	scan.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		x, y := originX+p.X/cellSizeMM, originY+p.Y/cellSizeMM
+		castRay(originX, originY, x, y, func(col, row int) bool {
+			if col < 0 || col >= widthCells || row < 0 || row >= heightCells {
+				return false
+			}
+			grid.Cells[row*widthCells+col] = CellFree
+			return true
+		})
+		hits = append(hits, [2]int{int(math.Floor(x)), int(math.Floor(y))})
+		return true
+	})
+
+	// Occupied cells are marked after all rays so that a ray passing through a return never clears it.
+	for _, hit := range hits {
+		col, row := hit[0], hit[1]
+		if col >= 0 && col < widthCells && row >= 0 && row < heightCells {
+			grid.Cells[row*widthCells+col] = CellOccupied
+		}
+	}
+	return grid, nil
+}
+
+// castRay calls visit with every cell the ray from (x0, y0) to (x1, y1) in grid coordinates passes through, in
+// order, starting with the cell containing (x0, y0) and excluding the cell containing (x1, y1). It stops early
+// when visit returns false.
+func castRay(x0, y0, x1, y1 float64, visit func(col, row int) bool) {
+	col, row := int(math.Floor(x0)), int(math.Floor(y0))
+	endCol, endRow := int(math.Floor(x1)), int(math.Floor(y1))
+	stepCol, deltaCol, nextCol := rayAxis(x0, x1-x0)
+	stepRow, deltaRow, nextRow := rayAxis(y0, y1-y0)
+
+	for col != endCol || row != endRow {
+		if !visit(col, row) {
+			return
+		}
+		// Move into whichever neighboring cell the ray reaches first.
+		if nextCol < nextRow {
+			if nextCol > 1 {
+				return
+			}
+			col += stepCol
+			nextCol += deltaCol
+		} else {
+			if nextRow > 1 {
+				return
+			}
+			row += stepRow
+			nextRow += deltaRow
+		}
+	}
+}
+
+// rayAxis returns the step direction along one axis of a ray starting at start and moving by delta, the fraction
+// of the ray needed to cross a whole cell along that axis, and the fraction at which the ray first crosses a cell
+// boundary along it.
+func rayAxis(start, delta float64) (int, float64, float64) {
+	switch {
+	case delta > 0:
+		return 1, 1 / delta, (math.Floor(start) + 1 - start) / delta
+	case delta < 0:
+		return -1, -1 / delta, (start - math.Floor(start)) / -delta
+	default:
+		return 0, math.Inf(1), math.Inf(1)
+	}
+}
+
+// NextOccupancyGrid returns an occupancy grid of the current cached point cloud. See NewOccupancyGrid.
+func (rp *rplidar) NextOccupancyGrid(ctx context.Context, cellSizeMM float64, widthCells, heightCells int) (OccupancyGrid, error) {
+	pc, err := rp.NextPointCloud(ctx)
+	if err != nil {
+		return OccupancyGrid{}, err
+	}
+	return NewOccupancyGrid(pc, cellSizeMM, widthCells, heightCells)
+}
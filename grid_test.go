@@ -0,0 +1,98 @@
+package rplidar
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestNewOccupancyGrid(t *testing.T) {
+	t.Run("marks returns occupied and the rays to them free", func(t *testing.T) {
+		scan := pointcloud.New()
+		test.That(t, scan.Set(r3.Vector{X: 350, Y: 50}, nil), test.ShouldBeNil)
+		test.That(t, scan.Set(r3.Vector{X: 50, Y: 250}, nil), test.ShouldBeNil)
+
+		grid, err := NewOccupancyGrid(scan, 100, 10, 10)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(grid.Cells), test.ShouldEqual, 100)
+
+		// The sensor is on the corner of cells (4, 4) and (5, 5).
+		test.That(t, grid.At(8, 5), test.ShouldEqual, CellOccupied)
+		test.That(t, grid.At(5, 7), test.ShouldEqual, CellOccupied)
+		for _, col := range []int{5, 6, 7} {
+			test.That(t, grid.At(col, 5), test.ShouldEqual, CellFree)
+		}
+		test.That(t, grid.At(5, 6), test.ShouldEqual, CellFree)
+		test.That(t, grid.At(9, 5), test.ShouldEqual, CellUnknown)
+		test.That(t, grid.At(2, 2), test.ShouldEqual, CellUnknown)
+
+		var counts [3]int
+		for _, cell := range grid.Cells {
+			counts[cell]++
+		}
+		test.That(t, counts[CellOccupied], test.ShouldEqual, 2)
+		test.That(t, counts[CellFree], test.ShouldEqual, 4)
+	})
+
+	t.Run("rays to returns outside of the grid are clipped", func(t *testing.T) {
+		scan := pointcloud.New()
+		test.That(t, scan.Set(r3.Vector{X: -100000, Y: 50}, nil), test.ShouldBeNil)
+
+		grid, err := NewOccupancyGrid(scan, 100, 10, 10)
+		test.That(t, err, test.ShouldBeNil)
+		for col := 0; col <= 5; col++ {
+			test.That(t, grid.At(col, 5), test.ShouldEqual, CellFree)
+		}
+		for _, cell := range grid.Cells {
+			test.That(t, cell, test.ShouldNotEqual, CellOccupied)
+		}
+	})
+
+	t.Run("occupied cells are not cleared by later rays", func(t *testing.T) {
+		scan := pointcloud.New()
+		test.That(t, scan.Set(r3.Vector{X: 150, Y: 50}, nil), test.ShouldBeNil)
+		test.That(t, scan.Set(r3.Vector{X: 450, Y: 50}, nil), test.ShouldBeNil)
+
+		grid, err := NewOccupancyGrid(scan, 100, 10, 10)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, grid.At(6, 5), test.ShouldEqual, CellOccupied)
+		test.That(t, grid.At(7, 5), test.ShouldEqual, CellFree)
+		test.That(t, grid.At(9, 5), test.ShouldEqual, CellOccupied)
+	})
+
+	t.Run("odd sizes center the sensor in the middle cell", func(t *testing.T) {
+		scan := pointcloud.New()
+		test.That(t, scan.Set(r3.Vector{X: 0, Y: 0}, nil), test.ShouldBeNil)
+
+		grid, err := NewOccupancyGrid(scan, 100, 3, 3)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, grid.At(1, 1), test.ShouldEqual, CellOccupied)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := NewOccupancyGrid(nil, 100, 10, 10)
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = NewOccupancyGrid(pointcloud.New(), 0, 10, 10)
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = NewOccupancyGrid(pointcloud.New(), 100, 0, 10)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
+
+func TestNextOccupancyGrid(t *testing.T) {
+	ctx := context.Background()
+	rp := rplidar{cache: &dataCache{}}
+
+	_, err := rp.NextOccupancyGrid(ctx, 100, 10, 10)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	rp.cache.pointCloud = pointcloud.New()
+	test.That(t, rp.cache.pointCloud.Set(r3.Vector{X: 150, Y: 50}, nil), test.ShouldBeNil)
+	grid, err := rp.NextOccupancyGrid(ctx, 100, 10, 10)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, grid.At(6, 5), test.ShouldEqual, CellOccupied)
+	test.That(t, grid.At(5, 5), test.ShouldEqual, CellFree)
+}
@@ -0,0 +1,113 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// The policies for NextPointCloud calls arriving sooner than the minimum scan interval after the previous one.
+const (
+	// The previously returned point cloud is returned again right away.
+	scanIntervalPolicyCached = "cached"
+	// The call blocks until the interval has passed and a newer point cloud is available.
+	scanIntervalPolicyBlock = "block"
+)
+
+// scanIntervalLimiter limits how often NextPointCloud hands out a new point cloud, protecting against callers
+// that poll far faster than the device scans. A nil scanIntervalLimiter does not limit anything.
+type scanIntervalLimiter struct {
+	mutex       sync.Mutex
+	minInterval time.Duration
+	policy      string
+
+	lastPointCloud pointcloud.PointCloud
+	lastSeq        uint64
+	lastTime       time.Time
+
+	// The clock the interval is measured with, the real one if nil.
+	clock clock
+}
+
+// newScanIntervalLimiter creates a scanIntervalLimiter enforcing the given minimum interval between point
+// clouds. A non-positive interval disables the limit and returns nil.
+func newScanIntervalLimiter(minInterval time.Duration, policy string) *scanIntervalLimiter {
+	if minInterval <= 0 {
+		return nil
+	}
+	if policy == "" {
+		policy = scanIntervalPolicyCached
+	}
+	return &scanIntervalLimiter{minInterval: minInterval, policy: policy}
+}
+
+// next returns the point cloud for a NextPointCloud call, taking it from cache unless the call arrived within the
+// minimum interval of the previous one.
+func (l *scanIntervalLimiter) next(ctx context.Context, cache *dataCache) (pointcloud.PointCloud, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	clk := clockOrReal(l.clock)
+	if l.lastPointCloud != nil {
+		if wait := l.minInterval - clk.Now().Sub(l.lastTime); wait > 0 {
+			if l.policy == scanIntervalPolicyCached {
+				return l.lastPointCloud, nil
+			}
+			if !clk.Wait(ctx, wait) {
+				return nil, ctx.Err()
+			}
+		}
+		if l.policy == scanIntervalPolicyBlock {
+			if err := cache.waitForScanAfter(ctx, l.lastSeq); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	cache.mutex.RLock()
+	pc, seq := cache.pointCloud, cache.meta.Seq
+	cache.mutex.RUnlock()
+	if pc == nil {
+		return nil, errors.New("pointcloud has not been saved yet")
+	}
+
+	l.lastPointCloud, l.lastSeq, l.lastTime = pc, seq, clk.Now()
+	return pc, nil
+}
+
+// waitForScanAfter blocks until the cache holds a scan newer than the one with the given sequence number, or
+// until ctx is done.
+func (c *dataCache) waitForScanAfter(ctx context.Context, seq uint64) error {
+	for {
+		c.mutex.Lock()
+		if c.meta.Seq > seq {
+			c.mutex.Unlock()
+			return nil
+		}
+		if c.updated == nil {
+			c.updated = make(chan struct{})
+		}
+		updated := c.updated
+		c.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-updated:
+		}
+	}
+}
+
+// notifyUpdated wakes up everyone waiting for a new scan and publishes it to subscribers. It must be called with
+// the cache's mutex held.
+func (c *dataCache) notifyUpdated() {
+	c.publish()
+	if c.updated != nil {
+		close(c.updated)
+		c.updated = nil
+	}
+}
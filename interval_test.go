@@ -0,0 +1,125 @@
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestScanIntervalLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	storeScan := func(cache *dataCache, x float64) pointcloud.PointCloud {
+		pc := pointcloud.New()
+		test.That(t, pc.Set(r3.Vector{X: x}, nil), test.ShouldBeNil)
+		cache.mutex.Lock()
+		defer cache.mutex.Unlock()
+		cache.pointCloud = pc
+		cache.meta = ScanMeta{Seq: cache.meta.Seq + 1, Timestamp: time.Now(), PointCount: 1}
+		cache.notifyUpdated()
+		return pc
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		test.That(t, newScanIntervalLimiter(0, scanIntervalPolicyBlock), test.ShouldBeNil)
+	})
+
+	t.Run("nothing cached yet", func(t *testing.T) {
+		l := newScanIntervalLimiter(time.Hour, "")
+		_, err := l.next(ctx, &dataCache{})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "pointcloud has not been saved yet")
+	})
+
+	t.Run("cached policy returns the previous point cloud within the interval", func(t *testing.T) {
+		cache := &dataCache{}
+		l := newScanIntervalLimiter(time.Hour, "")
+		first := storeScan(cache, 1)
+
+		pc, err := l.next(ctx, cache)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldEqual, first)
+
+		storeScan(cache, 2)
+		pc, err = l.next(ctx, cache)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldEqual, first)
+	})
+
+	t.Run("cached policy returns the latest point cloud after the interval", func(t *testing.T) {
+		cache := &dataCache{}
+		clk := newFakeClock()
+		l := newScanIntervalLimiter(time.Second, scanIntervalPolicyCached)
+		l.clock = clk
+		first := storeScan(cache, 1)
+		_, err := l.next(ctx, cache)
+		test.That(t, err, test.ShouldBeNil)
+
+		storeScan(cache, 2)
+		clk.Advance(time.Second - time.Nanosecond)
+		pc, err := l.next(ctx, cache)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldEqual, first)
+
+		clk.Advance(time.Nanosecond)
+		second := storeScan(cache, 3)
+		pc, err = l.next(ctx, cache)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldEqual, second)
+	})
+
+	t.Run("block policy waits out the interval on the clock", func(t *testing.T) {
+		cache := &dataCache{}
+		clk := newFakeClock()
+		l := newScanIntervalLimiter(time.Hour, scanIntervalPolicyBlock)
+		l.clock = clk
+		storeScan(cache, 1)
+		start := clk.Now()
+		_, err := l.next(ctx, cache)
+		test.That(t, err, test.ShouldBeNil)
+
+		second := storeScan(cache, 2)
+		pc, err := l.next(ctx, cache)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldEqual, second)
+		test.That(t, clk.Now().Sub(start), test.ShouldEqual, time.Hour)
+	})
+
+	t.Run("block policy waits for a newer point cloud", func(t *testing.T) {
+		cache := &dataCache{}
+		l := newScanIntervalLimiter(20*time.Millisecond, scanIntervalPolicyBlock)
+		storeScan(cache, 1)
+		_, err := l.next(ctx, cache)
+		test.That(t, err, test.ShouldBeNil)
+		start := time.Now()
+
+		second := make(chan pointcloud.PointCloud, 1)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			second <- storeScan(cache, 2)
+		}()
+
+		pc, err := l.next(ctx, cache)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldEqual, <-second)
+		test.That(t, time.Since(start), test.ShouldBeGreaterThanOrEqualTo, 50*time.Millisecond)
+	})
+
+	t.Run("block policy gives up when the context is done", func(t *testing.T) {
+		cache := &dataCache{}
+		l := newScanIntervalLimiter(time.Millisecond, scanIntervalPolicyBlock)
+		storeScan(cache, 1)
+		_, err := l.next(ctx, cache)
+		test.That(t, err, test.ShouldBeNil)
+
+		cancelCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = l.next(cancelCtx, cache)
+		test.That(t, errors.Is(err, context.DeadlineExceeded), test.ShouldBeTrue)
+	})
+}
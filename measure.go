@@ -0,0 +1,62 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The tolerance the measure_at_angle command uses when none is given, wider than the angular resolution of
+// every scan mode.
+const defaultMeasureToleranceDeg = 1.
+
+// ErrNoReturnAtAngle is wrapped by the error MeasureAtAngle returns when the current revolution has no valid
+// return within the tolerance of the requested angle.
+var ErrNoReturnAtAngle = errors.New("no return at the requested angle")
+
+// ErrNoScanYet is wrapped by the error MeasureAtAngle returns when there is no current revolution to measure in,
+// either because none has been scanned yet or because the most recent scan failed.
+var ErrNoScanYet = errors.New("no revolution has been scanned yet")
+
+// MeasureAtAngle returns the valid return of the current revolution whose angle is nearest to angleDeg, if it is
+// within toleranceDeg of it. Angles are the rplidar's own, as marked on the device and before angle_offset_deg,
+// and the returns are taken before any filtering. It returns an error wrapping ErrNoReturnAtAngle if no return
+// falls within the window, e.g. when the target is out of range or the window is narrower than the angular
+// resolution, or wrapping ErrNoScanYet if there is no current revolution, e.g. after a failed scan.
+func (rp *rplidar) MeasureAtAngle(ctx context.Context, angleDeg, toleranceDeg float64) (Measurement, error) {
+	if math.IsNaN(angleDeg) || math.IsInf(angleDeg, 0) {
+		return Measurement{}, errors.New("angleDeg must be finite")
+	}
+	if toleranceDeg < 0 || toleranceDeg > 180 {
+		return Measurement{}, errors.New("toleranceDeg must be between 0 and 180")
+	}
+	if err := rp.ensureStarted(ctx); err != nil {
+		return Measurement{}, err
+	}
+
+	rp.cache.mutex.RLock()
+	measurements := rp.cache.measurements
+	rp.cache.mutex.RUnlock()
+	if measurements == nil {
+		// A failed scan clears the cached revolution rather than leaving a stale one to measure in.
+		if err := rp.stats.lastError(); err != nil {
+			return Measurement{}, fmt.Errorf("%w: the most recent scan failed: %v", ErrNoScanYet, err)
+		}
+		return Measurement{}, ErrNoScanYet
+	}
+
+	angleDeg = normalizeAngleDeg(angleDeg)
+	nearest, nearestDeg := -1, math.Inf(1)
+	for i, m := range measurements {
+		if diffDeg := math.Abs(shortestAngleDiffDeg(angleDeg, m.AngleDeg)); diffDeg <= toleranceDeg && diffDeg < nearestDeg {
+			nearest, nearestDeg = i, diffDeg
+		}
+	}
+	if nearest < 0 {
+		return Measurement{}, fmt.Errorf("%w: none of the %d returns of the current revolution is within %v degrees of %v degrees",
+			ErrNoReturnAtAngle, len(measurements), toleranceDeg, angleDeg)
+	}
+	return measurements[nearest], nil
+}
@@ -0,0 +1,74 @@
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestMeasureAtAngle(t *testing.T) {
+	ctx := context.Background()
+	rp := rplidar{cache: &dataCache{}}
+
+	_, err := rp.MeasureAtAngle(ctx, 90, 1)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, errors.Is(err, ErrNoScanYet), test.ShouldBeTrue)
+	test.That(t, err.Error(), test.ShouldEqual, "no revolution has been scanned yet")
+
+	// A failed scan clears the cached revolution, which is reported as such rather than as a missing return.
+	rp.stats.setLastError(errors.New("bad scan: OpTimeout"))
+	_, err = rp.MeasureAtAngle(ctx, 90, 1)
+	test.That(t, errors.Is(err, ErrNoScanYet), test.ShouldBeTrue)
+	test.That(t, errors.Is(err, ErrNoReturnAtAngle), test.ShouldBeFalse)
+	test.That(t, err.Error(), test.ShouldEqual, "no revolution has been scanned yet: the most recent scan failed: bad scan: OpTimeout")
+	rp.stats.setLastError(nil)
+
+	rp.cache.measurements = []Measurement{
+		{AngleDeg: 0.2, DistanceMM: 500, Quality: 40},
+		{AngleDeg: 89.4, DistanceMM: 1000, Quality: 50},
+		{AngleDeg: 90.3, DistanceMM: 1010, Quality: 60},
+		{AngleDeg: 359.9, DistanceMM: 510, Quality: 70},
+	}
+
+	t.Run("returns the return nearest to the angle", func(t *testing.T) {
+		m, err := rp.MeasureAtAngle(ctx, 90, 1)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, m, test.ShouldResemble, Measurement{AngleDeg: 90.3, DistanceMM: 1010, Quality: 60})
+	})
+
+	t.Run("the window wraps around 360 degrees", func(t *testing.T) {
+		m, err := rp.MeasureAtAngle(ctx, 360, 0.5)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, m.DistanceMM, test.ShouldEqual, 510)
+		m, err = rp.MeasureAtAngle(ctx, -0.1, 0.5)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, m.DistanceMM, test.ShouldEqual, 510)
+	})
+
+	t.Run("no return within the window", func(t *testing.T) {
+		_, err := rp.MeasureAtAngle(ctx, 180, 1)
+		test.That(t, errors.Is(err, ErrNoReturnAtAngle), test.ShouldBeTrue)
+		test.That(t, err.Error(), test.ShouldContainSubstring,
+			"none of the 4 returns of the current revolution is within 1 degrees of 180 degrees")
+	})
+
+	t.Run("invalid tolerance", func(t *testing.T) {
+		_, err := rp.MeasureAtAngle(ctx, 90, -1)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("do command", func(t *testing.T) {
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "measure_at_angle", "angle_deg": 89.})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{
+			"angle_deg":   89.4,
+			"distance_mm": 1000.,
+			"quality":     50,
+		})
+
+		_, err = rp.DoCommand(ctx, map[string]interface{}{"command": "measure_at_angle"})
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
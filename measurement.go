@@ -0,0 +1,321 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"math"
+	"sort"
+)
+
+// measurement is a single valid return of the rplidar, in the sensor's native polar frame.
+type measurement struct {
+	angleDeg   float64
+	distanceMM float64
+	quality    uint8
+}
+
+// Measurement is a single valid return of the rplidar, in the sensor's native polar frame: the angle increases
+// clockwise from the 0 degree direction marked on the device.
+type Measurement struct {
+	AngleDeg   float64
+	DistanceMM float64
+	Quality    uint8
+}
+
+// exportMeasurements copies measurements into a new slice of exported measurements.
+func exportMeasurements(measurements []measurement) []Measurement {
+	exported := make([]Measurement, len(measurements))
+	for i, m := range measurements {
+		exported[i] = Measurement{AngleDeg: m.angleDeg, DistanceMM: m.distanceMM, Quality: m.quality}
+	}
+	return exported
+}
+
+// The policies for a sample whose angle steps backward from the previous one within a revolution.
+const (
+	// outOfOrderDrop drops the sample.
+	outOfOrderDrop = "drop"
+	// outOfOrderClamp moves the sample to the angle of the previous one.
+	outOfOrderClamp = "clamp"
+	// outOfOrderKeep keeps the sample as it is.
+	outOfOrderKeep = "keep"
+)
+
+// orderAngles applies policy to every measurement whose angle steps backward from the previous one, as jitter
+// can cause, and returns the measurements along with the number of such measurements. The measurements must be
+// in the order they were taken, as grabbed and before sorting, which would hide the step. A step backward of more
+// than 180 degrees is taken as the angles wrapping from 360 to 0 degrees within the revolution, not as jitter.
+// Dropped measurements are not considered the previous one; clamped ones are, at their clamped angle.
+func orderAngles(measurements []measurement, policy string) ([]measurement, int) {
+	var outOfOrder int
+	ordered := measurements[:0]
+	for _, m := range measurements {
+		if len(ordered) > 0 {
+			prevDeg := ordered[len(ordered)-1].angleDeg
+			if shortestAngleDiffDeg(prevDeg, m.angleDeg) < 0 {
+				outOfOrder++
+				switch policy {
+				case outOfOrderKeep:
+				case outOfOrderClamp:
+					m.angleDeg = prevDeg
+				default:
+					continue
+				}
+			}
+		}
+		ordered = append(ordered, m)
+	}
+	return ordered, outOfOrder
+}
+
+// decimate returns every n-th of the measurements, starting with the first, in a new slice.
+func decimate(measurements []measurement, n int) []measurement {
+	decimated := make([]measurement, 0, (len(measurements)+n-1)/n)
+	for i := 0; i < len(measurements); i += n {
+		decimated = append(decimated, measurements[i])
+	}
+	return decimated
+}
+
+// nearestPerSector divides the revolution into the given number of equally sized sectors and keeps only the
+// measurement with the smallest distance in each sector. Sectors without any measurement are left empty.
+// The result is ordered by sector, starting at 0 degrees.
+func nearestPerSector(measurements []measurement, sectors int) []measurement {
+	nearest := make([]*measurement, sectors)
+	sectorWidthDeg := 360. / float64(sectors)
+	for i := range measurements {
+		m := &measurements[i]
+		sector := int(normalizeAngleDeg(m.angleDeg) / sectorWidthDeg)
+		// Guard against floating point error placing an angle just below 360 in a sector past the last one.
+		if sector >= sectors {
+			sector = sectors - 1
+		}
+		if nearest[sector] == nil || m.distanceMM < nearest[sector].distanceMM {
+			nearest[sector] = m
+		}
+	}
+
+	filtered := make([]measurement, 0, sectors)
+	for _, m := range nearest {
+		if m != nil {
+			filtered = append(filtered, *m)
+		}
+	}
+	return filtered
+}
+
+// The statistics smoothPerBin reduces the distances within a window to.
+const (
+	smoothingMedian = "median"
+	smoothingMean   = "mean"
+)
+
+// smoothPerBin divides the revolution into the given number of equally sized bins and replaces the measurements
+// with one per bin, at the center of the bin, whose distance is the median or mean of the distances of the
+// measurements within windowDeg centered on it, and whose quality is the mean of their qualities. The window wraps
+// around 360 degrees and may be wider than a bin, so a measurement can contribute to several bins. Bins without any
+// measurement in their window are left empty. The result is ordered by bin, starting at 0 degrees.
+func smoothPerBin(measurements []measurement, bins int, windowDeg float64, method string) []measurement {
+	sorted := make([]measurement, len(measurements))
+	for i, m := range measurements {
+		m.angleDeg = normalizeAngleDeg(m.angleDeg)
+		sorted[i] = m
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].angleDeg < sorted[j].angleDeg
+	})
+
+	// The measurements within [fromDeg, toDeg), for 0 <= fromDeg <= toDeg <= 360.
+	inRange := func(fromDeg, toDeg float64) []measurement {
+		from := sort.Search(len(sorted), func(i int) bool { return sorted[i].angleDeg >= fromDeg })
+		to := sort.Search(len(sorted), func(i int) bool { return sorted[i].angleDeg >= toDeg })
+		return sorted[from:to]
+	}
+
+	binWidthDeg := 360. / float64(bins)
+	smoothed := make([]measurement, 0, bins)
+	var window []measurement
+	for bin := 0; bin < bins; bin++ {
+		centerDeg := (float64(bin) + 0.5) * binWidthDeg
+		fromDeg, toDeg := centerDeg-windowDeg/2, centerDeg+windowDeg/2
+		window = window[:0]
+		if windowDeg >= 360 {
+			window = append(window, sorted...)
+		} else {
+			window = append(window, inRange(math.Max(fromDeg, 0), math.Min(toDeg, 360))...)
+			if fromDeg < 0 {
+				window = append(window, inRange(fromDeg+360, 360)...)
+			}
+			if toDeg > 360 {
+				window = append(window, inRange(0, toDeg-360)...)
+			}
+		}
+		if len(window) == 0 {
+			continue
+		}
+		smoothed = append(smoothed, measurement{
+			angleDeg:   centerDeg,
+			distanceMM: reduceDistances(window, method),
+			quality:    meanQuality(window),
+		})
+	}
+	return smoothed
+}
+
+// reduceDistances returns the median or mean distance of the measurements, reordering them for the median.
+func reduceDistances(measurements []measurement, method string) float64 {
+	if method == smoothingMean {
+		var sum float64
+		for _, m := range measurements {
+			sum += m.distanceMM
+		}
+		return sum / float64(len(measurements))
+	}
+	sort.Slice(measurements, func(i, j int) bool {
+		return measurements[i].distanceMM < measurements[j].distanceMM
+	})
+	mid := len(measurements) / 2
+	if len(measurements)%2 == 0 {
+		return (measurements[mid-1].distanceMM + measurements[mid].distanceMM) / 2
+	}
+	return measurements[mid].distanceMM
+}
+
+// meanQuality returns the mean quality of the measurements, rounded to the nearest integer.
+func meanQuality(measurements []measurement) uint8 {
+	var sum int
+	for _, m := range measurements {
+		sum += int(m.quality)
+	}
+	return uint8((sum + len(measurements)/2) / len(measurements))
+}
+
+// BlankSector is a range of the rplidar's own angles, in degrees, where near returns are blanked, see
+// blank_below_mm. It spans from StartDeg to EndDeg in the direction the rplidar's angles increase, wrapping around
+// 360 degrees if EndDeg is smaller.
+type BlankSector struct {
+	StartDeg float64 `json:"start_deg"`
+	EndDeg   float64 `json:"end_deg"`
+}
+
+// blankNearField drops the measurements closer than belowMM whose angle lies within one of the sectors.
+func blankNearField(measurements []measurement, belowMM float64, sectors []BlankSector) []measurement {
+	kept := measurements[:0]
+	for _, m := range measurements {
+		if m.distanceMM >= belowMM || !inBlankSector(m.angleDeg, sectors) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// inBlankSector reports whether angleDeg lies within one of the sectors, including their edges.
+func inBlankSector(angleDeg float64, sectors []BlankSector) bool {
+	for _, sector := range sectors {
+		if (AngularSector{StartDeg: sector.StartDeg, EndDeg: sector.EndDeg}).contains(angleDeg) {
+			return true
+		}
+	}
+	return false
+}
+
+// interpolateMissingAngles fills in the angle of measurements the SDK reported at exactly 0 degrees, which it
+// uses to mark a node whose angle was not measured. The measurements must be a whole revolution in the order they
+// were taken, as grabbed and before sorting. Each run of missing angles is spread evenly between the valid angles
+// on either side of it, taking the shorter way around the circle so that runs across the wrap from 360 back to 0
+// degrees stay in place. A run at either end of the revolution lies between its last and its first valid angle,
+// so a genuine 0 degree angle at the start of a revolution, which is indistinguishable from a missing one,
+// interpolates to close to 0 degrees again. Missing angles are only dropped when the revolution has fewer than two
+// valid angles to interpolate between. The measurements are updated in place.
+func interpolateMissingAngles(measurements []measurement) []measurement {
+	var valid []int
+	for i := range measurements {
+		if measurements[i].angleDeg != 0 {
+			valid = append(valid, i)
+		}
+	}
+	if len(valid) < 2 {
+		return validAngles(measurements)
+	}
+
+	// The run wrapping around the end of the revolution is interpolated from its last to its first valid angle.
+	n := len(measurements)
+	valid = append(valid, valid[0]+n)
+	for k := 1; k < len(valid); k++ {
+		prev, next := valid[k-1], valid[k]
+		if next-prev < 2 {
+			continue
+		}
+		startDeg := measurements[prev].angleDeg
+		stepDeg := shortestAngleDiffDeg(startDeg, measurements[next%n].angleDeg) / float64(next-prev)
+		for j := prev + 1; j < next; j++ {
+			measurements[j%n].angleDeg = normalizeAngleDeg(startDeg + stepDeg*float64(j-prev))
+		}
+	}
+	return measurements
+}
+
+// validAngles drops the measurements with a missing angle, reusing their storage.
+func validAngles(measurements []measurement) []measurement {
+	valid := measurements[:0]
+	for _, m := range measurements {
+		if m.angleDeg != 0 {
+			valid = append(valid, m)
+		}
+	}
+	return valid
+}
+
+// shortestAngleDiffDeg returns the signed difference in degrees from one angle to another, taking the
+// shorter way around the circle. The result is in the range [-180, 180).
+func shortestAngleDiffDeg(fromDeg, toDeg float64) float64 {
+	return normalizeAngleDeg(toDeg-fromDeg+180) - 180
+}
+
+// normalizeAngleDeg maps an angle in degrees into the range [0, 360).
+func normalizeAngleDeg(angleDeg float64) float64 {
+	angleDeg = math.Mod(angleDeg, 360)
+	if angleDeg < 0 {
+		angleDeg += 360
+	}
+	return angleDeg
+}
+
+// sortByAngle sorts measurements in place by ascending angle, normalizing every angle into [0, 360). The input
+// is expected to be mostly sorted already, apart from the wrap from 360 back to 0 degrees and the odd sample out
+// of order, so the largest backwards step is treated as the seam and rotated to the front before an insertion
+// sort fixes up the rest. For such input this is close to linear.
+func sortByAngle(measurements []measurement) {
+	if len(measurements) < 2 {
+		return
+	}
+
+	seam, largestStep := 0, 0.
+	for i := range measurements {
+		measurements[i].angleDeg = normalizeAngleDeg(measurements[i].angleDeg)
+		if i > 0 {
+			if step := measurements[i-1].angleDeg - measurements[i].angleDeg; step > largestStep {
+				seam, largestStep = i, step
+			}
+		}
+	}
+	rotateMeasurements(measurements, seam)
+
+	for i := 1; i < len(measurements); i++ {
+		for j := i; j > 0 && measurements[j].angleDeg < measurements[j-1].angleDeg; j-- {
+			measurements[j], measurements[j-1] = measurements[j-1], measurements[j]
+		}
+	}
+}
+
+// rotateMeasurements rotates measurements in place so that the measurement at index first becomes the first one.
+func rotateMeasurements(measurements []measurement, first int) {
+	reverseMeasurements(measurements[:first])
+	reverseMeasurements(measurements[first:])
+	reverseMeasurements(measurements)
+}
+
+func reverseMeasurements(measurements []measurement) {
+	for i, j := 0, len(measurements)-1; i < j; i, j = i+1, j-1 {
+		measurements[i], measurements[j] = measurements[j], measurements[i]
+	}
+}
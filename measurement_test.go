@@ -0,0 +1,266 @@
+package rplidar
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestNearestPerSector(t *testing.T) {
+	measurements := []measurement{
+		{angleDeg: 10, distanceMM: 500},
+		{angleDeg: 20, distanceMM: 300},
+		{angleDeg: 80, distanceMM: 400},
+		{angleDeg: 100, distanceMM: 900},
+		{angleDeg: 359.9, distanceMM: 700},
+		{angleDeg: 300, distanceMM: 100},
+	}
+
+	t.Run("keeps the nearest return of each sector", func(t *testing.T) {
+		filtered := nearestPerSector(measurements, 4)
+		test.That(t, filtered, test.ShouldResemble, []measurement{
+			{angleDeg: 20, distanceMM: 300},
+			{angleDeg: 100, distanceMM: 900},
+			{angleDeg: 300, distanceMM: 100},
+		})
+	})
+
+	t.Run("single sector keeps the nearest return overall", func(t *testing.T) {
+		filtered := nearestPerSector(measurements, 1)
+		test.That(t, filtered, test.ShouldResemble, []measurement{{angleDeg: 300, distanceMM: 100}})
+	})
+
+	t.Run("more sectors than measurements keeps every measurement", func(t *testing.T) {
+		filtered := nearestPerSector(measurements, 360)
+		test.That(t, len(filtered), test.ShouldEqual, len(measurements))
+	})
+
+	t.Run("no measurements", func(t *testing.T) {
+		filtered := nearestPerSector(nil, 8)
+		test.That(t, filtered, test.ShouldBeEmpty)
+	})
+}
+
+func TestSmoothPerBin(t *testing.T) {
+	measurements := []measurement{
+		{angleDeg: 10, distanceMM: 1000, quality: 10},
+		{angleDeg: 20, distanceMM: 1010, quality: 20},
+		{angleDeg: 30, distanceMM: 5000, quality: 30},
+		{angleDeg: 100, distanceMM: 2000, quality: 40},
+		{angleDeg: 359, distanceMM: 3000, quality: 50},
+		{angleDeg: 1, distanceMM: 3100, quality: 61},
+	}
+
+	t.Run("median per bin", func(t *testing.T) {
+		smoothed := smoothPerBin(measurements, 4, 90, smoothingMedian)
+		test.That(t, smoothed, test.ShouldResemble, []measurement{
+			{angleDeg: 45, distanceMM: 2055, quality: 30},
+			{angleDeg: 135, distanceMM: 2000, quality: 40},
+			{angleDeg: 315, distanceMM: 3000, quality: 50},
+		})
+	})
+
+	t.Run("mean per bin", func(t *testing.T) {
+		smoothed := smoothPerBin(measurements[:4], 4, 90, smoothingMean)
+		test.That(t, smoothed[0], test.ShouldResemble, measurement{angleDeg: 45, distanceMM: 7010. / 3, quality: 20})
+	})
+
+	t.Run("windows wrap around 0 degrees", func(t *testing.T) {
+		// The bin centered on 0 degrees gathers the returns on both sides of it.
+		smoothed := smoothPerBin(measurements, 180, 4, smoothingMedian)
+		test.That(t, smoothed[0], test.ShouldResemble, measurement{angleDeg: 1, distanceMM: 3050, quality: 56})
+		// Windows are half open, so the return at 1 degree is just outside of the one centered on 359 degrees.
+		test.That(t, smoothed[len(smoothed)-1], test.ShouldResemble, measurement{angleDeg: 359, distanceMM: 3000, quality: 50})
+	})
+
+	t.Run("the median ignores outliers", func(t *testing.T) {
+		smoothed := smoothPerBin(measurements[:3], 1, 360, smoothingMedian)
+		test.That(t, smoothed, test.ShouldResemble, []measurement{{angleDeg: 180, distanceMM: 1010, quality: 20}})
+	})
+
+	t.Run("no measurements", func(t *testing.T) {
+		test.That(t, smoothPerBin(nil, 8, 45, smoothingMedian), test.ShouldBeEmpty)
+	})
+}
+
+func TestDecimate(t *testing.T) {
+	measurements := []measurement{{angleDeg: 1}, {angleDeg: 2}, {angleDeg: 3}, {angleDeg: 4}, {angleDeg: 5}}
+	test.That(t, decimate(measurements, 2), test.ShouldResemble, []measurement{{angleDeg: 1}, {angleDeg: 3}, {angleDeg: 5}})
+	test.That(t, decimate(measurements, 1), test.ShouldResemble, measurements)
+	test.That(t, decimate(measurements, 10), test.ShouldResemble, measurements[:1])
+	test.That(t, decimate(nil, 3), test.ShouldBeEmpty)
+}
+
+func TestInterpolateMissingAngles(t *testing.T) {
+	t.Run("zero angles in a cabin are interpolated from neighbors", func(t *testing.T) {
+		// Reproduces a grab where the SDK left the angles of a cabin's nodes unset.
+		measurements := []measurement{
+			{angleDeg: 90, distanceMM: 1000},
+			{angleDeg: 0, distanceMM: 1010},
+			{angleDeg: 0, distanceMM: 1020},
+			{angleDeg: 0, distanceMM: 1030},
+			{angleDeg: 92, distanceMM: 1040},
+		}
+		test.That(t, interpolateMissingAngles(measurements), test.ShouldResemble, []measurement{
+			{angleDeg: 90, distanceMM: 1000},
+			{angleDeg: 90.5, distanceMM: 1010},
+			{angleDeg: 91, distanceMM: 1020},
+			{angleDeg: 91.5, distanceMM: 1030},
+			{angleDeg: 92, distanceMM: 1040},
+		})
+	})
+
+	t.Run("interpolation across the wrap", func(t *testing.T) {
+		measurements := []measurement{
+			{angleDeg: 359, distanceMM: 1000},
+			{angleDeg: 0, distanceMM: 1010},
+			{angleDeg: 0, distanceMM: 1020},
+			{angleDeg: 0, distanceMM: 1030},
+			{angleDeg: 1, distanceMM: 1040},
+		}
+		interpolated := interpolateMissingAngles(measurements)
+		test.That(t, len(interpolated), test.ShouldEqual, 5)
+		test.That(t, interpolated[1].angleDeg, test.ShouldAlmostEqual, 359.5)
+		test.That(t, interpolated[2].angleDeg, test.ShouldAlmostEqual, 0)
+		test.That(t, interpolated[3].angleDeg, test.ShouldAlmostEqual, 0.5)
+	})
+
+	t.Run("runs at either end of the revolution are interpolated across its start", func(t *testing.T) {
+		// The first sample is a genuine return at 0 degrees, the last one is missing its angle.
+		measurements := []measurement{
+			{angleDeg: 0, distanceMM: 1000},
+			{angleDeg: 45, distanceMM: 1010},
+			{angleDeg: 90, distanceMM: 1020},
+			{angleDeg: 135, distanceMM: 1030},
+			{angleDeg: 180, distanceMM: 1040},
+			{angleDeg: 225, distanceMM: 1050},
+			{angleDeg: 270, distanceMM: 1060},
+			{angleDeg: 0, distanceMM: 1070},
+		}
+		interpolated := interpolateMissingAngles(measurements)
+		test.That(t, len(interpolated), test.ShouldEqual, 8)
+		test.That(t, interpolated[0].angleDeg, test.ShouldAlmostEqual, 0)
+		test.That(t, interpolated[7].angleDeg, test.ShouldAlmostEqual, 315)
+	})
+
+	t.Run("zero angles without two valid angles to interpolate between are dropped", func(t *testing.T) {
+		measurements := []measurement{
+			{angleDeg: 0, distanceMM: 1000},
+			{angleDeg: 10, distanceMM: 1010},
+			{angleDeg: 0, distanceMM: 1020},
+		}
+		test.That(t, interpolateMissingAngles(measurements), test.ShouldResemble, []measurement{
+			{angleDeg: 10, distanceMM: 1010},
+		})
+	})
+
+	t.Run("only zero angles", func(t *testing.T) {
+		measurements := []measurement{{angleDeg: 0, distanceMM: 1000}, {angleDeg: 0, distanceMM: 1010}}
+		test.That(t, interpolateMissingAngles(measurements), test.ShouldBeEmpty)
+	})
+}
+
+func TestNormalizeAngleDeg(t *testing.T) {
+	test.That(t, normalizeAngleDeg(0), test.ShouldEqual, 0)
+	test.That(t, normalizeAngleDeg(360), test.ShouldEqual, 0)
+	test.That(t, normalizeAngleDeg(370), test.ShouldEqual, 10)
+	test.That(t, normalizeAngleDeg(-10), test.ShouldEqual, 350)
+}
+
+func TestOrderAngles(t *testing.T) {
+	// A revolution wrapping around 360 degrees, with one sample jittering backward after the wrap.
+	revolution := []measurement{
+		{angleDeg: 359, distanceMM: 1},
+		{angleDeg: 359.5, distanceMM: 2},
+		{angleDeg: 0.5, distanceMM: 3},
+		{angleDeg: 0.2, distanceMM: 4},
+		{angleDeg: 1, distanceMM: 5},
+	}
+
+	for _, tc := range []struct {
+		policy   string
+		expected []measurement
+	}{
+		{
+			policy:   "",
+			expected: []measurement{revolution[0], revolution[1], revolution[2], revolution[4]},
+		},
+		{
+			policy:   outOfOrderDrop,
+			expected: []measurement{revolution[0], revolution[1], revolution[2], revolution[4]},
+		},
+		{
+			policy: outOfOrderClamp,
+			expected: []measurement{
+				revolution[0], revolution[1], revolution[2], {angleDeg: 0.5, distanceMM: 4}, revolution[4],
+			},
+		},
+		{
+			policy:   outOfOrderKeep,
+			expected: revolution,
+		},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			ordered, outOfOrder := orderAngles(append([]measurement(nil), revolution...), tc.policy)
+			test.That(t, ordered, test.ShouldResemble, tc.expected)
+			test.That(t, outOfOrder, test.ShouldEqual, 1)
+		})
+	}
+
+	t.Run("monotonic angles are left alone", func(t *testing.T) {
+		ordered, outOfOrder := orderAngles(append([]measurement(nil), revolution[:3]...), outOfOrderDrop)
+		test.That(t, ordered, test.ShouldResemble, revolution[:3])
+		test.That(t, outOfOrder, test.ShouldEqual, 0)
+	})
+}
+
+func TestSortByAngle(t *testing.T) {
+	anglesOf := func(measurements []measurement) []float64 {
+		angles := make([]float64, 0, len(measurements))
+		for _, m := range measurements {
+			angles = append(angles, m.angleDeg)
+		}
+		return angles
+	}
+
+	t.Run("seam in the middle of the revolution", func(t *testing.T) {
+		measurements := []measurement{
+			{angleDeg: 350, distanceMM: 1},
+			{angleDeg: 355, distanceMM: 2},
+			{angleDeg: 359.5, distanceMM: 3},
+			{angleDeg: 360.5, distanceMM: 4},
+			{angleDeg: 2, distanceMM: 5},
+			{angleDeg: 7, distanceMM: 6},
+			{angleDeg: 5, distanceMM: 7},
+			{angleDeg: 90, distanceMM: 8},
+		}
+		sortByAngle(measurements)
+
+		test.That(t, anglesOf(measurements), test.ShouldResemble, []float64{0.5, 2, 5, 7, 90, 350, 355, 359.5})
+		for i := 1; i < len(measurements); i++ {
+			test.That(t, measurements[i].angleDeg, test.ShouldBeGreaterThanOrEqualTo, measurements[i-1].angleDeg)
+		}
+		// The measurements move together with their angles.
+		test.That(t, measurements[0].distanceMM, test.ShouldEqual, 4)
+		test.That(t, measurements[2].distanceMM, test.ShouldEqual, 7)
+	})
+
+	t.Run("already sorted", func(t *testing.T) {
+		measurements := []measurement{{angleDeg: 0}, {angleDeg: 120}, {angleDeg: 240}}
+		sortByAngle(measurements)
+		test.That(t, anglesOf(measurements), test.ShouldResemble, []float64{0, 120, 240})
+	})
+
+	t.Run("reverse sorted", func(t *testing.T) {
+		measurements := []measurement{{angleDeg: 300}, {angleDeg: 200}, {angleDeg: 100}, {angleDeg: 0}}
+		sortByAngle(measurements)
+		test.That(t, anglesOf(measurements), test.ShouldResemble, []float64{0, 100, 200, 300})
+	})
+
+	t.Run("empty and single measurement", func(t *testing.T) {
+		sortByAngle(nil)
+		measurements := []measurement{{angleDeg: 400}}
+		sortByAngle(measurements)
+		test.That(t, anglesOf(measurements), test.ShouldResemble, []float64{400})
+	})
+}
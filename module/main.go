@@ -33,9 +33,9 @@ func mainWithArgs(ctx context.Context, args []string, logger logging.Logger) err
 		versionFields = append(versionFields, "git_rev", GitRevision)
 	}
 	if len(versionFields) != 0 {
-		logger.Infow(rplidar.Model.String(), versionFields...)
+		logger.Infow(rplidar.Model.String(), append(versionFields, "sdk_version", rplidar.SDKVersion())...)
 	} else {
-		logger.Info(rplidar.Model.String() + " built from source; version unknown")
+		logger.Infow(rplidar.Model.String()+" built from source; version unknown", "sdk_version", rplidar.SDKVersion())
 	}
 
 	if len(args) == 2 && strings.HasSuffix(args[1], "-version") {
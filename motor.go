@@ -0,0 +1,91 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"go.viam.com/rplidar/gen"
+)
+
+const (
+	// The interval between PWM updates while ramping up the motor.
+	defaultMotorRampStep = 20 * time.Millisecond
+	// The time the rotation period is given to settle after ramping up the motor.
+	defaultMotorSettleTimeout = 5 * time.Second
+	// The fraction by which consecutive rotation periods may differ once the motor speed is stable.
+	motorSettleTolerance = 0.05
+)
+
+// startMotor starts the motor at the SDK's default PWM. If motorRamp is set and the device supports motor speed
+// control, the PWM is raised from 0 to the default in even steps over motorRamp instead, avoiding the current
+// spike of starting at full speed, and startMotor only returns once the rotation period settled. Devices without
+// speed control, like the A1, are started at full speed. If ramping fails, e.g. because ctx is cancelled, the
+// motor is stopped.
+func (rp *rplidar) startMotor(ctx context.Context) error {
+	targetPWM := uint16(gen.DEFAULT_MOTOR_PWM)
+	if rp.motorRamp <= 0 {
+		rp.device.driver.StartMotor()
+		return nil
+	}
+
+	var supported bool
+	if result := rp.device.driver.CheckMotorCtrlSupport(&supported, rp.device.timeoutMs); Result(result) != ResultOk ||
+		!supported {
+		rp.logger.Warn("the device does not support motor speed control, starting the motor without motor_ramp_ms")
+		rp.device.driver.StartMotor()
+		return nil
+	}
+
+	steps := int(rp.motorRamp / defaultMotorRampStep)
+	for i := 1; i < steps; i++ {
+		rp.device.driver.SetMotorPWM(uint16(int(targetPWM) * i / steps))
+		if !clockOrReal(rp.clock).Wait(ctx, defaultMotorRampStep) {
+			rp.device.driver.StopMotor()
+			return ctx.Err()
+		}
+	}
+	rp.device.driver.SetMotorPWM(targetPWM)
+	if err := rp.waitForStableRotation(ctx); err != nil {
+		rp.device.driver.StopMotor()
+		return err
+	}
+	return nil
+}
+
+// waitForStableRotation scans until two consecutive revolutions take the same time, within
+// motorSettleTolerance, and logs a warning if the rotation period does not settle within
+// defaultMotorSettleTimeout. Scanning is stopped afterwards, so it has to be started again in the configured mode.
+func (rp *rplidar) waitForStableRotation(ctx context.Context) error {
+	usedScanMode := gen.NewRplidarScanMode()
+	defer gen.DeleteRplidarScanMode(usedScanMode)
+	if err := Result(rp.device.driver.StartScan(false, true, uint(0), usedScanMode)).Failed(); err != nil {
+		return fmt.Errorf("failed to start scan: %w", err)
+	}
+	defer rp.device.driver.Stop()
+
+	clk := clockOrReal(rp.clock)
+	start := clk.Now()
+	var lastGrab time.Time
+	var lastPeriod time.Duration
+	for clk.Now().Sub(start) < defaultMotorSettleTimeout {
+		// Every grab returns once the next revolution completed, so grabs are a rotation period apart.
+		if _, err := rp.grabRevolutions(ctx, 1); err != nil {
+			return err
+		}
+		now := clk.Now()
+		if !lastGrab.IsZero() {
+			period := now.Sub(lastGrab)
+			if lastPeriod > 0 && math.Abs(float64(period-lastPeriod)) <= motorSettleTolerance*float64(lastPeriod) {
+				rp.logger.Debugf("the motor settled at a rotation period of %v", period)
+				return nil
+			}
+			lastPeriod = period
+		}
+		lastGrab = now
+	}
+	rp.logger.Warnf("the rotation period did not settle within %v of ramping up the motor", defaultMotorSettleTimeout)
+	return nil
+}
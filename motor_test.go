@@ -0,0 +1,122 @@
+package rplidar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rplidar/gen"
+	"go.viam.com/rplidar/inject"
+	"go.viam.com/test"
+)
+
+func TestStartMotor(t *testing.T) {
+	ctx := context.Background()
+
+	type motorDriver struct {
+		gen.RPlidarDriver
+		pwms      []uint16
+		starts    int
+		stops     int
+		scanStops int
+		grabs     int
+		rotations []time.Duration
+		clk       *fakeClock
+	}
+	newDriver := func(supportsMotorCtrl bool, rotations ...time.Duration) *motorDriver {
+		driver := inject.NewRPLiDARDriver()
+		m := &motorDriver{RPlidarDriver: &driver, rotations: rotations, clk: newFakeClock()}
+		driver.SetMotorPWMFunc = func(pwm uint16) uint {
+			m.pwms = append(m.pwms, pwm)
+			return uint(gen.RESULT_OK)
+		}
+		driver.StartMotorFunc = func() uint {
+			m.starts++
+			return uint(gen.RESULT_OK)
+		}
+		driver.StopMotorFunc = func() uint {
+			m.stops++
+			return uint(gen.RESULT_OK)
+		}
+		driver.CheckMotorCtrlSupportFunc = func(a ...interface{}) uint {
+			*(a[0].([]interface{})[0].(*bool)) = supportsMotorCtrl
+			return uint(gen.RESULT_OK)
+		}
+		driver.StartScanFunc = func(a ...interface{}) uint {
+			return uint(gen.RESULT_OK)
+		}
+		driver.StopFunc = func(a ...interface{}) uint {
+			m.scanStops++
+			return uint(gen.RESULT_OK)
+		}
+		// Every grab takes the next rotation period, cycling through them.
+		driver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+			m.clk.Advance(m.rotations[m.grabs%len(m.rotations)])
+			m.grabs++
+			*(a[0].([]interface{})[1].(*int64)) = 400
+			return uint(gen.RESULT_OK)
+		}
+		return m
+	}
+	newRplidar := func(driver *motorDriver, motorRamp time.Duration, logger logging.Logger) *rplidar {
+		return &rplidar{
+			device:    &rplidarDevice{driver: driver.RPlidarDriver},
+			nodes:     gen.New_measurementNodeHqArray(defaultNodeSize),
+			motorRamp: motorRamp,
+			clock:     driver.clk,
+			logger:    logger,
+		}
+	}
+
+	t.Run("no ramp starts the motor directly", func(t *testing.T) {
+		driver := newDriver(true, 100*time.Millisecond)
+		rp := newRplidar(driver, 0, logging.NewTestLogger(t))
+		test.That(t, rp.startMotor(ctx), test.ShouldBeNil)
+		test.That(t, driver.starts, test.ShouldEqual, 1)
+		test.That(t, driver.pwms, test.ShouldBeEmpty)
+		test.That(t, driver.grabs, test.ShouldEqual, 0)
+	})
+
+	t.Run("ramp raises the pwm to the target and waits for the rotation to settle", func(t *testing.T) {
+		driver := newDriver(true, 80*time.Millisecond, 150*time.Millisecond, 120*time.Millisecond,
+			102*time.Millisecond, 100*time.Millisecond)
+		rp := newRplidar(driver, 5*defaultMotorRampStep, logging.NewTestLogger(t))
+		start := driver.clk.Now()
+		test.That(t, rp.startMotor(ctx), test.ShouldBeNil)
+		test.That(t, driver.starts, test.ShouldEqual, 0)
+		test.That(t, driver.pwms, test.ShouldResemble, []uint16{132, 264, 396, 528, uint16(gen.DEFAULT_MOTOR_PWM)})
+		// The periods between grabs are 150, 120, 102 and 100ms, the last two within the tolerance.
+		test.That(t, driver.grabs, test.ShouldEqual, 5)
+		test.That(t, driver.scanStops, test.ShouldEqual, 1)
+		test.That(t, driver.clk.Now().Sub(start), test.ShouldEqual, 4*defaultMotorRampStep+552*time.Millisecond)
+	})
+
+	t.Run("a rotation that does not settle times out", func(t *testing.T) {
+		driver := newDriver(true, 100*time.Millisecond, 200*time.Millisecond)
+		logger, logs := logging.NewObservedTestLogger(t)
+		rp := newRplidar(driver, 5*defaultMotorRampStep, logger)
+		test.That(t, rp.startMotor(ctx), test.ShouldBeNil)
+		test.That(t, driver.stops, test.ShouldEqual, 0)
+		test.That(t, logs.FilterMessageSnippet("did not settle").Len(), test.ShouldEqual, 1)
+	})
+
+	t.Run("cancelled ramp stops the motor", func(t *testing.T) {
+		driver := newDriver(true, 100*time.Millisecond)
+		rp := newRplidar(driver, time.Minute, logging.NewTestLogger(t))
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		test.That(t, rp.startMotor(cancelledCtx), test.ShouldBeError, context.Canceled)
+		test.That(t, driver.pwms, test.ShouldHaveLength, 1)
+		test.That(t, driver.pwms[0], test.ShouldBeLessThan, uint16(gen.DEFAULT_MOTOR_PWM))
+		test.That(t, driver.stops, test.ShouldEqual, 1)
+	})
+
+	t.Run("devices without motor control start at full speed", func(t *testing.T) {
+		driver := newDriver(false, 100*time.Millisecond)
+		rp := newRplidar(driver, 5*defaultMotorRampStep, logging.NewTestLogger(t))
+		test.That(t, rp.startMotor(ctx), test.ShouldBeNil)
+		test.That(t, driver.starts, test.ShouldEqual, 1)
+		test.That(t, driver.pwms, test.ShouldBeEmpty)
+	})
+}
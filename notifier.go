@@ -0,0 +1,149 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"sync"
+)
+
+// The buffer policies of an asyncNotifier, deciding what happens to a value arriving while its queue is full.
+const (
+	// The queued values are kept and the new one is dropped, so the callback receives the oldest undelivered
+	// values, without gaps until the queue fills up.
+	bufferPolicyOldest = "oldest"
+	// The oldest queued value is dropped to make room for the new one, so the callback catches up on the newest
+	// values.
+	bufferPolicyLatest = "latest"
+	// The caller waits until the callback takes a value off the queue, so nothing is dropped but a slow callback
+	// holds up the scan loop.
+	bufferPolicyBlock = "block"
+)
+
+// asyncNotifier delivers values to a registered callback from its own goroutine, buffering up to the queue size
+// of them for a slow callback. What happens to values arriving while the queue is full is decided by its buffer
+// policy; unless it is bufferPolicyBlock, a slow callback never blocks the scan loop. All methods are safe to call
+// on a nil notifier, in which case they do nothing.
+type asyncNotifier[T any] struct {
+	mutex   sync.RWMutex
+	fn      func(T)
+	queue   chan T
+	policy  string
+	dropped int
+	stalls  int
+	// Closed once run returns, releasing a caller waiting under bufferPolicyBlock.
+	stopped chan struct{}
+}
+
+// newAsyncNotifier creates a notifier able to buffer up to queueSize values for a slow callback, handling a full
+// queue according to policy, bufferPolicyOldest if empty.
+func newAsyncNotifier[T any](queueSize int, policy string) *asyncNotifier[T] {
+	if policy == "" {
+		policy = bufferPolicyOldest
+	}
+	return &asyncNotifier[T]{queue: make(chan T, queueSize), policy: policy, stopped: make(chan struct{})}
+}
+
+// register sets the callback values are delivered to, replacing any previous one. A nil fn unregisters it.
+func (n *asyncNotifier[T]) register(fn func(T)) {
+	if n == nil {
+		return
+	}
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.fn = fn
+}
+
+// active reports whether a callback is registered, allowing callers to skip building values nobody consumes.
+func (n *asyncNotifier[T]) active() bool {
+	if n == nil {
+		return false
+	}
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return n.fn != nil
+}
+
+// notify queues v for delivery. If the queue is full, v or the oldest queued value is dropped and counted, or
+// under bufferPolicyBlock, notify waits for room and counts the stall.
+func (n *asyncNotifier[T]) notify(v T) {
+	if !n.active() {
+		return
+	}
+	select {
+	case n.queue <- v:
+		return
+	default:
+	}
+
+	switch n.policy {
+	case bufferPolicyLatest:
+		for {
+			select {
+			case <-n.queue:
+				n.countDrop()
+			default:
+			}
+			select {
+			case n.queue <- v:
+				return
+			default:
+			}
+		}
+	case bufferPolicyBlock:
+		n.mutex.Lock()
+		n.stalls++
+		n.mutex.Unlock()
+		select {
+		case n.queue <- v:
+		case <-n.stopped:
+		}
+	default:
+		n.countDrop()
+	}
+}
+
+// countDrop counts a dropped value.
+func (n *asyncNotifier[T]) countDrop() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.dropped++
+}
+
+// droppedCount returns the number of values dropped because the callback could not keep up, the new ones under
+// bufferPolicyOldest and the superseded ones under bufferPolicyLatest.
+func (n *asyncNotifier[T]) droppedCount() int {
+	if n == nil {
+		return 0
+	}
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return n.dropped
+}
+
+// stallCount returns the number of times a caller waited for the callback under bufferPolicyBlock.
+func (n *asyncNotifier[T]) stallCount() int {
+	if n == nil {
+		return 0
+	}
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return n.stalls
+}
+
+// run delivers queued values to the registered callback until ctx is done.
+func (n *asyncNotifier[T]) run(ctx context.Context) {
+	defer close(n.stopped)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v := <-n.queue:
+			n.mutex.RLock()
+			fn := n.fn
+			n.mutex.RUnlock()
+			if fn != nil {
+				fn(v)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package rplidar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func TestAsyncNotifier(t *testing.T) {
+	t.Run("nil notifier is a no-op", func(t *testing.T) {
+		var n *asyncNotifier[int]
+		n.register(func(int) {})
+		n.notify(1)
+		test.That(t, n.active(), test.ShouldBeFalse)
+		test.That(t, n.droppedCount(), test.ShouldEqual, 0)
+	})
+
+	t.Run("values are not queued without a callback", func(t *testing.T) {
+		n := newAsyncNotifier[int](1, bufferPolicyOldest)
+		test.That(t, n.active(), test.ShouldBeFalse)
+		n.notify(1)
+		n.notify(2)
+		test.That(t, len(n.queue), test.ShouldEqual, 0)
+		test.That(t, n.droppedCount(), test.ShouldEqual, 0)
+	})
+
+	t.Run("values are delivered in order", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		n := newAsyncNotifier[int](4, bufferPolicyOldest)
+		received := make(chan int, 4)
+		n.register(func(v int) { received <- v })
+		test.That(t, n.active(), test.ShouldBeTrue)
+		go n.run(ctx)
+
+		for i := 0; i < 3; i++ {
+			n.notify(i)
+		}
+		for i := 0; i < 3; i++ {
+			select {
+			case v := <-received:
+				test.That(t, v, test.ShouldEqual, i)
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for value")
+			}
+		}
+	})
+
+	t.Run("values are dropped instead of blocking on a slow callback", func(t *testing.T) {
+		n := newAsyncNotifier[int](2, bufferPolicyOldest)
+		n.register(func(int) {})
+
+		startTime := time.Now()
+		for i := 0; i < 5; i++ {
+			n.notify(i)
+		}
+		test.That(t, time.Since(startTime), test.ShouldBeLessThan, time.Second)
+		test.That(t, len(n.queue), test.ShouldEqual, 2)
+		test.That(t, n.droppedCount(), test.ShouldEqual, 3)
+	})
+
+	t.Run("latest policy drops the oldest queued values", func(t *testing.T) {
+		n := newAsyncNotifier[int](2, bufferPolicyLatest)
+		n.register(func(int) {})
+
+		for i := 0; i < 5; i++ {
+			n.notify(i)
+		}
+		test.That(t, n.droppedCount(), test.ShouldEqual, 3)
+		test.That(t, <-n.queue, test.ShouldEqual, 3)
+		test.That(t, <-n.queue, test.ShouldEqual, 4)
+	})
+
+	t.Run("block policy waits for the callback", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		n := newAsyncNotifier[int](1, bufferPolicyBlock)
+		release := make(chan struct{})
+		received := make(chan int, 3)
+		n.register(func(v int) {
+			<-release
+			received <- v
+		})
+		go n.run(ctx)
+
+		notified := make(chan struct{})
+		go func() {
+			defer close(notified)
+			for i := 0; i < 3; i++ {
+				n.notify(i)
+			}
+		}()
+		select {
+		case <-notified:
+			t.Fatal("notify did not wait for the slow callback")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		select {
+		case <-notified:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for notify")
+		}
+		for i := 0; i < 3; i++ {
+			test.That(t, <-received, test.ShouldEqual, i)
+		}
+		test.That(t, n.droppedCount(), test.ShouldEqual, 0)
+		test.That(t, n.stallCount(), test.ShouldBeGreaterThan, 0)
+	})
+
+	t.Run("block policy stops waiting once run returns", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		n := newAsyncNotifier[int](1, bufferPolicyBlock)
+		n.register(func(int) {})
+		cancel()
+		n.run(ctx)
+
+		notified := make(chan struct{})
+		go func() {
+			defer close(notified)
+			for i := 0; i < 3; i++ {
+				n.notify(i)
+			}
+		}()
+		select {
+		case <-notified:
+		case <-time.After(time.Second):
+			t.Fatal("notify kept waiting after run returned")
+		}
+		test.That(t, n.stallCount(), test.ShouldEqual, 2)
+	})
+}
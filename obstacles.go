@@ -0,0 +1,71 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// AngularSector is a named range of directions in the XY plane of a point cloud. Angles are in degrees,
+// measured from the point cloud's X axis towards its Y axis, and the sector spans counterclockwise from StartDeg
+// to EndDeg, wrapping around 360 degrees if EndDeg is smaller, ex. from 350 to 10 degrees. In a point cloud
+// returned by the camera with the default right-handed convention, the rplidar's 0 degree direction, marked on
+// the device, is at 180 degrees and its clockwise angles map to 180 minus the angle.
+type AngularSector struct {
+	Name     string
+	StartDeg float64
+	EndDeg   float64
+}
+
+// widthDeg returns the angular width of the sector, which is 360 for a sector covering a full revolution.
+func (s AngularSector) widthDeg() float64 {
+	width := normalizeAngleDeg(s.EndDeg - s.StartDeg)
+	if width == 0 && s.EndDeg != s.StartDeg {
+		return 360
+	}
+	return width
+}
+
+// contains reports whether the direction at angleDeg lies within the sector, including its edges.
+func (s AngularSector) contains(angleDeg float64) bool {
+	return normalizeAngleDeg(angleDeg-s.StartDeg) <= s.widthDeg()
+}
+
+// ObstacleDistances returns the distance in millimeters, within the XY plane, to the nearest point of scan in
+// each of the sectors, keyed by the sector's name. Points at the origin are not valid returns and are ignored.
+// Sectors without any point are +Inf. Sectors may overlap, in which case a point counts towards every sector it
+// lies in.
+func ObstacleDistances(scan pointcloud.PointCloud, sectors []AngularSector) (map[string]float64, error) {
+	if scan == nil {
+		return nil, errors.New("no point cloud to find obstacles in")
+	}
+
+	distances := make(map[string]float64, len(sectors))
+	for _, sector := range sectors {
+		if sector.Name == "" {
+			return nil, errors.New("every sector must have a name")
+		}
+		if _, ok := distances[sector.Name]; ok {
+			return nil, errors.Errorf("sector name %q is not unique", sector.Name)
+		}
+		distances[sector.Name] = math.Inf(1)
+	}
+
+	scan.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		distanceMM := math.Hypot(p.X, p.Y)
+		if distanceMM == 0 {
+			return true
+		}
+		angleDeg := math.Atan2(p.Y, p.X) * 180 / math.Pi
+		for _, sector := range sectors {
+			if sector.contains(angleDeg) && distanceMM < distances[sector.Name] {
+				distances[sector.Name] = distanceMM
+			}
+		}
+		return true
+	})
+	return distances, nil
+}
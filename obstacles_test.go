@@ -0,0 +1,55 @@
+package rplidar
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/test"
+)
+
+func TestObstacleDistances(t *testing.T) {
+	scan := pointcloud.New()
+	test.That(t, scan.Set(r3.Vector{X: 1000, Y: -50}, nil), test.ShouldBeNil)
+	test.That(t, scan.Set(r3.Vector{X: 2000, Y: 100}, nil), test.ShouldBeNil)
+	test.That(t, scan.Set(r3.Vector{X: 0, Y: 700}, nil), test.ShouldBeNil)
+	test.That(t, scan.Set(r3.Vector{X: 0, Y: 0}, nil), test.ShouldBeNil)
+
+	sectors := []AngularSector{
+		{Name: "front", StartDeg: 350, EndDeg: 10},
+		{Name: "left", StartDeg: 45, EndDeg: 135},
+		{Name: "back", StartDeg: 170, EndDeg: 190},
+		{Name: "everywhere", StartDeg: -90, EndDeg: 270},
+	}
+
+	t.Run("nearest point per sector", func(t *testing.T) {
+		distances, err := ObstacleDistances(scan, sectors)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, distances["front"], test.ShouldAlmostEqual, math.Hypot(1000, 50))
+		test.That(t, distances["left"], test.ShouldAlmostEqual, 700)
+		test.That(t, math.IsInf(distances["back"], 1), test.ShouldBeTrue)
+		test.That(t, distances["everywhere"], test.ShouldAlmostEqual, 700)
+	})
+
+	t.Run("sectors match the rplidar's angles", func(t *testing.T) {
+		// A return 1m away at 90 degrees from the rplidar's zero angle.
+		pc := pointcloud.New()
+		p, d := pointFrom(utils.DegToRad(90), 0, 1, 255, rightHanded)
+		test.That(t, pc.Set(p, d), test.ShouldBeNil)
+
+		distances, err := ObstacleDistances(pc, []AngularSector{{Name: "sensor 90", StartDeg: 85, EndDeg: 95}})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, distances["sensor 90"], test.ShouldAlmostEqual, 1000)
+	})
+
+	t.Run("invalid sectors", func(t *testing.T) {
+		_, err := ObstacleDistances(scan, []AngularSector{{StartDeg: 0, EndDeg: 10}})
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = ObstacleDistances(scan, []AngularSector{{Name: "a"}, {Name: "a"}})
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = ObstacleDistances(nil, sectors)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,77 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import "math"
+
+// overlongTrimmer cuts revolutions that are longer than the scan mode can deliver in one rotation, which happens
+// when the SDK merged the start of the next revolution into a grab, e.g. in boost mode on a fast host. The samples
+// from where the angles wrap back to 0 degrees on are held back and start the next revolution instead. A nil
+// overlongTrimmer leaves the revolutions as they are. It is only used by the scan loop.
+type overlongTrimmer struct {
+	// The fraction by which a revolution may exceed the expected number of samples before it is trimmed.
+	margin float64
+	// The samples trimmed off the end of the previous grab.
+	pending []measurement
+}
+
+// newOverlongTrimmer creates a trimmer for revolutions exceeding the expected number of samples by more than
+// margin, or returns nil if margin is zero.
+func newOverlongTrimmer(margin float64) *overlongTrimmer {
+	if margin == 0 {
+		return nil
+	}
+	return &overlongTrimmer{margin: margin}
+}
+
+// trim returns the revolution made of the samples held back from the previous grab followed by the samples of this
+// one, which must be in the order they were taken, as grabbed and before sorting. If there are more than expected
+// samples by more than the margin, the revolution ran into the next one: the samples from where the angles wrap
+// from 360 back to 0 degrees are held back for the next revolution instead, and trim reports true. Of several
+// wraps, the one closest to the expected count is taken; a revolution without any is left as it is. An expected
+// count of zero, while it is not known yet, never trims. The samples include the ones without a valid distance,
+// which still take up a sample slot.
+func (t *overlongTrimmer) trim(samples []measurement, expected int) ([]measurement, bool) {
+	if t == nil {
+		return samples, false
+	}
+	revolution := append(t.pending, samples...)
+	t.pending = nil
+	if expected <= 0 || float64(len(revolution)) <= math.Ceil(float64(expected)*(1+t.margin)) {
+		return revolution, false
+	}
+	seam := -1
+	for i := 1; i < len(revolution); i++ {
+		if revolution[i].angleDeg-revolution[i-1].angleDeg < -180 &&
+			(seam < 0 || math.Abs(float64(i-expected)) < math.Abs(float64(seam-expected))) {
+			seam = i
+		}
+	}
+	if seam < 0 {
+		return revolution, false
+	}
+	// The filters reuse the storage of the revolution, so the held back samples need storage of their own.
+	t.pending = append([]measurement(nil), revolution[seam:]...)
+	return revolution[:seam], true
+}
+
+// reset forgets the held back samples after a failed grab, which breaks the continuity of the revolutions.
+func (t *overlongTrimmer) reset() {
+	if t == nil {
+		return
+	}
+	t.pending = nil
+}
+
+// expectedRevolutionSamples returns the number of samples a revolution should contain, from the sample rate of the
+// scan mode the device is scanning in and the measured rotation period, or zero if either is not known.
+func (rp *rplidar) expectedRevolutionSamples() int {
+	samplesPerSec, err := rp.ModeSampleRate(ScanMode(rp.device.currentScanMode()))
+	if err != nil || samplesPerSec <= 0 {
+		return 0
+	}
+	period, err := rp.stats.rotationPeriod()
+	if err != nil {
+		return 0
+	}
+	return int(math.Round(samplesPerSec * period.Seconds()))
+}
@@ -0,0 +1,159 @@
+package rplidar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.viam.com/rplidar/gen"
+	"go.viam.com/rplidar/inject"
+	"go.viam.com/test"
+)
+
+func TestOverlongTrimmer(t *testing.T) {
+	// arc returns the samples from the from-th up to the to-th in the order they were taken, ten per revolution.
+	arc := func(from, to int) []measurement {
+		samples := make([]measurement, 0, to-from)
+		for i := from; i < to; i++ {
+			samples = append(samples, measurement{angleDeg: float64(i%10*36 + 1), distanceMM: float64(i + 1)})
+		}
+		return samples
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		trimmer := newOverlongTrimmer(0)
+		revolution, trimmed := trimmer.trim(arc(0, 13), 10)
+		test.That(t, trimmed, test.ShouldBeFalse)
+		test.That(t, revolution, test.ShouldResemble, arc(0, 13))
+		trimmer.reset()
+	})
+
+	t.Run("within the margin", func(t *testing.T) {
+		trimmer := newOverlongTrimmer(0.1)
+		revolution, trimmed := trimmer.trim(arc(0, 11), 10)
+		test.That(t, trimmed, test.ShouldBeFalse)
+		test.That(t, revolution, test.ShouldResemble, arc(0, 11))
+	})
+
+	t.Run("expected count not known yet", func(t *testing.T) {
+		trimmer := newOverlongTrimmer(0.1)
+		revolution, trimmed := trimmer.trim(arc(0, 20), 0)
+		test.That(t, trimmed, test.ShouldBeFalse)
+		test.That(t, revolution, test.ShouldResemble, arc(0, 20))
+	})
+
+	t.Run("the samples after the wrap start the next revolution", func(t *testing.T) {
+		trimmer := newOverlongTrimmer(0.1)
+		revolution, trimmed := trimmer.trim(arc(0, 13), 10)
+		test.That(t, trimmed, test.ShouldBeTrue)
+		test.That(t, revolution, test.ShouldResemble, arc(0, 10))
+
+		revolution, trimmed = trimmer.trim(arc(13, 20), 10)
+		test.That(t, trimmed, test.ShouldBeFalse)
+		test.That(t, revolution, test.ShouldResemble, arc(10, 20))
+
+		// After a failed grab, the samples held back from before it are not joined with the next grab.
+		trimmer.trim(arc(0, 13), 10)
+		trimmer.reset()
+		revolution, trimmed = trimmer.trim(arc(13, 20), 10)
+		test.That(t, trimmed, test.ShouldBeFalse)
+		test.That(t, revolution, test.ShouldResemble, arc(13, 20))
+	})
+
+	t.Run("the revolution is split at the wrap rather than the expected count", func(t *testing.T) {
+		// The device slowed down, so the revolution has one sample more than expected before it wraps.
+		samples := make([]measurement, 0, 14)
+		for i := 0; i < 11; i++ {
+			samples = append(samples, measurement{angleDeg: float64(i*32 + 1), distanceMM: 1000})
+		}
+		samples = append(samples, arc(0, 3)...)
+		trimmer := newOverlongTrimmer(0.1)
+		revolution, trimmed := trimmer.trim(samples, 10)
+		test.That(t, trimmed, test.ShouldBeTrue)
+		test.That(t, revolution, test.ShouldResemble, samples[:11])
+		test.That(t, trimmer.pending, test.ShouldResemble, arc(0, 3))
+	})
+
+	t.Run("a revolution without a wrap is left as it is", func(t *testing.T) {
+		samples := make([]measurement, 0, 13)
+		for i := 0; i < 13; i++ {
+			samples = append(samples, measurement{angleDeg: float64(i*20 + 1), distanceMM: 1000})
+		}
+		trimmer := newOverlongTrimmer(0.1)
+		revolution, trimmed := trimmer.trim(samples, 10)
+		test.That(t, trimmed, test.ShouldBeFalse)
+		test.That(t, revolution, test.ShouldResemble, samples)
+	})
+}
+
+func TestScanOverlongRevolution(t *testing.T) {
+	// q14 converts an angle in degrees that is a multiple of 90/2^14 to the node's fixed point angle.
+	q14 := func(deg float64) uint16 { return uint16(deg * (1 << 14) / 90) }
+	// The first grab is a revolution of five samples that runs two samples into the next one, which the second
+	// grab then lacks. Both are in the order the samples were taken.
+	grabs := [][]rawNode{
+		{
+			{angleQ14: q14(11.25), distQ2: 4000},
+			{angleQ14: q14(101.25), distQ2: 4004},
+			{angleQ14: q14(191.25), distQ2: 4008},
+			{angleQ14: q14(281.25), distQ2: 4012},
+			{angleQ14: q14(348.75), distQ2: 4016},
+			{angleQ14: q14(22.5), distQ2: 4020},
+			{angleQ14: q14(112.5), distQ2: 4024},
+		},
+		{
+			{angleQ14: q14(202.5), distQ2: 4028},
+			{angleQ14: q14(292.5), distQ2: 4032},
+		},
+	}
+	driver := inject.NewRPLiDARDriver()
+	var grabCount int
+	driver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+		grab := grabs[grabCount%len(grabs)]
+		fillNodes(a[0].([]interface{})[0].(gen.Rplidar_response_measurement_node_hq_t), grab)
+		*(a[0].([]interface{})[1].(*int64)) = int64(len(grab))
+		grabCount++
+		return uint(gen.RESULT_OK)
+	}
+	rp := &rplidar{
+		device: &rplidarDevice{
+			driver:       &driver,
+			scanModes:    []scanModeInfo{{mode: "Boost", samplesPerSec: 40}},
+			scanModeName: "Boost",
+		},
+		nodes:           gen.New_measurementNodeHqArray(defaultNodeSize),
+		cache:           &dataCache{},
+		overlongTrimmer: newOverlongTrimmer(0.1),
+	}
+	defer gen.Delete_measurementNodeHqArray(rp.nodes)
+	// A rotation period of 100ms at 40 samples per second makes 4 samples per revolution.
+	rp.stats.periods = []time.Duration{100 * time.Millisecond}
+
+	anglesAndDistances := func(measurements []Measurement) [][2]float64 {
+		out := make([][2]float64, 0, len(measurements))
+		for _, m := range measurements {
+			out = append(out, [2]float64{m.AngleDeg, m.DistanceMM})
+		}
+		return out
+	}
+
+	// The revolution ends at the wrap, even though it has more samples than expected.
+	pc, info, err := rp.scan(context.Background(), 1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 5)
+	test.That(t, info.samples, test.ShouldEqual, 5)
+	test.That(t, anglesAndDistances(info.measurements), test.ShouldResemble, [][2]float64{
+		{11.25, 1000}, {101.25, 1001}, {191.25, 1002}, {281.25, 1003}, {348.75, 1004},
+	})
+	test.That(t, rp.Stats().OverlongScans, test.ShouldEqual, 1)
+
+	// The held back samples start the next revolution, in their place by angle.
+	pc, info, err = rp.scan(context.Background(), 1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 4)
+	test.That(t, info.samples, test.ShouldEqual, 4)
+	test.That(t, anglesAndDistances(info.measurements), test.ShouldResemble, [][2]float64{
+		{22.5, 1005}, {112.5, 1006}, {202.5, 1007}, {292.5, 1008},
+	})
+	test.That(t, rp.Stats().OverlongScans, test.ShouldEqual, 1)
+}
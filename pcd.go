@@ -0,0 +1,195 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// The encodings of the quality channel of a point cloud.
+const (
+	// The quality is stored as the point's intensity and written to PCD as a float "intensity" field.
+	qualityEncodingIntensity = "intensity"
+	// The quality is stored as the point's value and written to PCD as a 1 byte unsigned "quality" field.
+	qualityEncodingValue = "value"
+	// The quality is stored as a gray color and written to PCD as a packed 4 byte "rgb" field.
+	qualityEncodingRGB = "rgb"
+)
+
+// PCDPrecision selects the floating point precision of the fields ToPCD writes.
+type PCDPrecision int
+
+const (
+	// PCDFloat32 writes the position, and the intensity, as 4 byte floats.
+	PCDFloat32 PCDPrecision = iota
+	// PCDFloat64 writes the position, and the intensity, as 8 byte floats.
+	PCDFloat64
+)
+
+// size returns the size in bytes of a float of the precision.
+func (p PCDPrecision) size() int {
+	if p == PCDFloat64 {
+		return 8
+	}
+	return 4
+}
+
+// setQuality stores the quality of a measurement in d using the given quality encoding. The intensity is set by
+// pointFrom, so the intensity encoding needs nothing further.
+func setQuality(d pointcloud.Data, quality uint8, encoding string) {
+	switch encoding {
+	case qualityEncodingValue:
+		d.SetValue(int(quality))
+	case qualityEncodingRGB:
+		d.SetColor(color.NRGBA{R: quality, G: quality, B: quality, A: 255})
+	}
+}
+
+// pcdFieldsHeader returns the FIELDS, SIZE, TYPE and COUNT lines of a PCD header for the given quality encoding
+// and precision.
+func pcdFieldsHeader(encoding string, precision PCDPrecision) (string, error) {
+	var qualityField, qualitySize, qualityType string
+	switch encoding {
+	case "", qualityEncodingIntensity:
+		qualityField, qualitySize, qualityType = "intensity", fmt.Sprint(precision.size()), "F"
+	case qualityEncodingValue:
+		qualityField, qualitySize, qualityType = "quality", "1", "U"
+	case qualityEncodingRGB:
+		qualityField, qualitySize, qualityType = "rgb", "4", "I"
+	default:
+		return "", errors.Errorf("unknown quality encoding %q", encoding)
+	}
+	if precision != PCDFloat32 && precision != PCDFloat64 {
+		return "", errors.Errorf("unknown PCD precision %v", precision)
+	}
+	size := precision.size()
+	return fmt.Sprintf("FIELDS x y z %s\nSIZE %d %d %d %s\nTYPE F F F %s\nCOUNT 1 1 1 1\n",
+		qualityField, size, size, size, qualitySize, qualityType), nil
+}
+
+// ToPCD writes the point cloud to out as a PCD file of the given type, including the quality channel in the
+// given quality encoding. Unlike pointcloud.ToPCD, which drops everything but color, the written fields match
+// the quality_encoding attribute the point cloud was produced with. The position, and the intensity, are written
+// with the given precision. Binary data is always little endian, regardless of the host. Compressed PCD is not
+// supported.
+func ToPCD(
+	cloud pointcloud.PointCloud,
+	out io.Writer,
+	outputType pointcloud.PCDType,
+	qualityEncoding string,
+	precision PCDPrecision,
+) error {
+	fields, err := pcdFieldsHeader(qualityEncoding, precision)
+	if err != nil {
+		return err
+	}
+	var dataType string
+	switch outputType {
+	case pointcloud.PCDBinary:
+		dataType = "binary"
+	case pointcloud.PCDAscii:
+		dataType = "ascii"
+	case pointcloud.PCDCompressed:
+		return errors.New("compressed PCD not supported")
+	default:
+		return errors.Errorf("unknown PCD type %v", outputType)
+	}
+
+	if _, err := fmt.Fprintf(out, "VERSION .7\n%sWIDTH %d\nHEIGHT 1\nVIEWPOINT 0 0 0 1 0 0 0\nPOINTS %d\nDATA %s\n",
+		fields, cloud.Size(), cloud.Size(), dataType); err != nil {
+		return err
+	}
+
+	cloud.Iterate(0, 0, func(pos r3.Vector, d pointcloud.Data) bool {
+		err = writePCDPoint(out, pos, d, outputType, qualityEncoding, precision)
+		return err == nil
+	})
+	return err
+}
+
+// writePCDPoint writes a single point to out, converting its position from millimeters to meters.
+func writePCDPoint(
+	out io.Writer,
+	pos r3.Vector,
+	d pointcloud.Data,
+	outputType pointcloud.PCDType,
+	encoding string,
+	precision PCDPrecision,
+) error {
+	x, y, z := pos.X/1000, pos.Y/1000, pos.Z/1000
+	if precision == PCDFloat32 {
+		// Round to the written precision so ascii and binary output agree.
+		x, y, z = float64(float32(x)), float64(float32(y)), float64(float32(z))
+	}
+
+	if outputType == pointcloud.PCDAscii {
+		var err error
+		switch encoding {
+		case qualityEncodingValue:
+			_, err = fmt.Fprintf(out, "%f %f %f %d\n", x, y, z, pcdQualityValue(d))
+		case qualityEncodingRGB:
+			_, err = fmt.Fprintf(out, "%f %f %f %d\n", x, y, z, pcdPackedRGB(d))
+		default:
+			_, err = fmt.Fprintf(out, "%f %f %f %f\n", x, y, z, pcdIntensity(d))
+		}
+		return err
+	}
+
+	size := precision.size()
+	buf := make([]byte, 4*size)
+	n := 0
+	putFloat := func(v float64) {
+		if precision == PCDFloat64 {
+			binary.LittleEndian.PutUint64(buf[n:], math.Float64bits(v))
+		} else {
+			binary.LittleEndian.PutUint32(buf[n:], math.Float32bits(float32(v)))
+		}
+		n += size
+	}
+	putFloat(x)
+	putFloat(y)
+	putFloat(z)
+	switch encoding {
+	case qualityEncodingValue:
+		buf[n] = pcdQualityValue(d)
+		n++
+	case qualityEncodingRGB:
+		binary.LittleEndian.PutUint32(buf[n:], uint32(pcdPackedRGB(d)))
+		n += 4
+	default:
+		putFloat(pcdIntensity(d))
+	}
+	buf = buf[:n]
+	_, err := out.Write(buf)
+	return err
+}
+
+func pcdIntensity(d pointcloud.Data) float64 {
+	if d == nil {
+		return 0
+	}
+	return float64(d.Intensity())
+}
+
+func pcdQualityValue(d pointcloud.Data) uint8 {
+	if d == nil || !d.HasValue() {
+		return 0
+	}
+	return uint8(d.Value())
+}
+
+// pcdPackedRGB packs the color of d into the 0x00RRGGBB layout used by the PCD rgb field.
+func pcdPackedRGB(d pointcloud.Data) int32 {
+	if d == nil || !d.HasColor() {
+		return 0
+	}
+	r, g, b := d.RGB255()
+	return int32(r)<<16 | int32(g)<<8 | int32(b)
+}
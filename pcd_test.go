@@ -0,0 +1,153 @@
+package rplidar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestToPCD(t *testing.T) {
+	cloudWithQuality := func(t *testing.T, encoding string) pointcloud.PointCloud {
+		t.Helper()
+		pc := pointcloud.New()
+		d := pointcloud.NewBasicData()
+		d.SetIntensity(200)
+		setQuality(d, 200, encoding)
+		test.That(t, pc.Set(r3.Vector{X: 1000, Y: 2000, Z: 0}, d), test.ShouldBeNil)
+		return pc
+	}
+
+	headerLines := func(pcd string) []string {
+		return strings.Split(pcd, "\n")[1:5]
+	}
+
+	t.Run("intensity", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := ToPCD(cloudWithQuality(t, qualityEncodingIntensity), &buf, pointcloud.PCDAscii, qualityEncodingIntensity, PCDFloat32)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, headerLines(buf.String()), test.ShouldResemble, []string{
+			"FIELDS x y z intensity", "SIZE 4 4 4 4", "TYPE F F F F", "COUNT 1 1 1 1",
+		})
+		test.That(t, buf.String(), test.ShouldEndWith, "DATA ascii\n1.000000 2.000000 0.000000 200.000000\n")
+	})
+
+	t.Run("default encoding is intensity", func(t *testing.T) {
+		var defaultBuf, intensityBuf bytes.Buffer
+		pc := cloudWithQuality(t, "")
+		test.That(t, ToPCD(pc, &defaultBuf, pointcloud.PCDAscii, "", PCDFloat32), test.ShouldBeNil)
+		test.That(t, ToPCD(pc, &intensityBuf, pointcloud.PCDAscii, qualityEncodingIntensity, PCDFloat32), test.ShouldBeNil)
+		test.That(t, defaultBuf.String(), test.ShouldEqual, intensityBuf.String())
+	})
+
+	t.Run("value", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := ToPCD(cloudWithQuality(t, qualityEncodingValue), &buf, pointcloud.PCDBinary, qualityEncodingValue, PCDFloat32)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, headerLines(buf.String()), test.ShouldResemble, []string{
+			"FIELDS x y z quality", "SIZE 4 4 4 1", "TYPE F F F U", "COUNT 1 1 1 1",
+		})
+		data := buf.Bytes()[strings.Index(buf.String(), "DATA binary\n")+len("DATA binary\n"):]
+		test.That(t, len(data), test.ShouldEqual, 13)
+		test.That(t, math.Float32frombits(binary.LittleEndian.Uint32(data[4:])), test.ShouldEqual, 2)
+		test.That(t, data[12], test.ShouldEqual, 200)
+	})
+
+	t.Run("rgb", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := ToPCD(cloudWithQuality(t, qualityEncodingRGB), &buf, pointcloud.PCDBinary, qualityEncodingRGB, PCDFloat32)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, headerLines(buf.String()), test.ShouldResemble, []string{
+			"FIELDS x y z rgb", "SIZE 4 4 4 4", "TYPE F F F I", "COUNT 1 1 1 1",
+		})
+		data := buf.Bytes()[strings.Index(buf.String(), "DATA binary\n")+len("DATA binary\n"):]
+		test.That(t, len(data), test.ShouldEqual, 16)
+		test.That(t, binary.LittleEndian.Uint32(data[12:]), test.ShouldEqual, 200<<16|200<<8|200)
+	})
+
+	t.Run("float64 round trip", func(t *testing.T) {
+		pc := pointcloud.New()
+		d := pointcloud.NewBasicData()
+		d.SetIntensity(123)
+		// A position float32 cannot represent exactly.
+		pos := r3.Vector{X: 1234.5678901, Y: -9876.5432109, Z: 0.1}
+		test.That(t, pc.Set(pos, d), test.ShouldBeNil)
+
+		var buf bytes.Buffer
+		err := ToPCD(pc, &buf, pointcloud.PCDBinary, qualityEncodingIntensity, PCDFloat64)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, headerLines(buf.String()), test.ShouldResemble, []string{
+			"FIELDS x y z intensity", "SIZE 8 8 8 8", "TYPE F F F F", "COUNT 1 1 1 1",
+		})
+		data := buf.Bytes()[strings.Index(buf.String(), "DATA binary\n")+len("DATA binary\n"):]
+		test.That(t, len(data), test.ShouldEqual, 32)
+		readFloat := func(i int) float64 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+		test.That(t, readFloat(0), test.ShouldEqual, pos.X/1000)
+		test.That(t, readFloat(1), test.ShouldEqual, pos.Y/1000)
+		test.That(t, readFloat(2), test.ShouldEqual, pos.Z/1000)
+		test.That(t, readFloat(3), test.ShouldEqual, 123)
+	})
+
+	t.Run("float32 round trip", func(t *testing.T) {
+		pc := pointcloud.New()
+		pos := r3.Vector{X: 1234.5678901, Y: -9876.5432109, Z: 0.1}
+		test.That(t, pc.Set(pos, pointcloud.NewBasicData()), test.ShouldBeNil)
+
+		var buf bytes.Buffer
+		err := ToPCD(pc, &buf, pointcloud.PCDBinary, qualityEncodingIntensity, PCDFloat32)
+		test.That(t, err, test.ShouldBeNil)
+		data := buf.Bytes()[strings.Index(buf.String(), "DATA binary\n")+len("DATA binary\n"):]
+		test.That(t, len(data), test.ShouldEqual, 16)
+		test.That(t, math.Float32frombits(binary.LittleEndian.Uint32(data[0:])), test.ShouldEqual, float32(pos.X/1000))
+		test.That(t, math.Float32frombits(binary.LittleEndian.Uint32(data[4:])), test.ShouldEqual, float32(pos.Y/1000))
+	})
+
+	t.Run("binary data is little endian on any host", func(t *testing.T) {
+		pc := pointcloud.New()
+		d := pointcloud.NewBasicData()
+		d.SetIntensity(200)
+		test.That(t, pc.Set(r3.Vector{X: 1000, Y: -2000, Z: 500}, d), test.ShouldBeNil)
+		binaryData := func(precision PCDPrecision) []byte {
+			var buf bytes.Buffer
+			test.That(t, ToPCD(pc, &buf, pointcloud.PCDBinary, qualityEncodingIntensity, precision), test.ShouldBeNil)
+			return buf.Bytes()[strings.Index(buf.String(), "DATA binary\n")+len("DATA binary\n"):]
+		}
+
+		test.That(t, binaryData(PCDFloat32), test.ShouldResemble, []byte{
+			0x00, 0x00, 0x80, 0x3f, // 1
+			0x00, 0x00, 0x00, 0xc0, // -2
+			0x00, 0x00, 0x00, 0x3f, // 0.5
+			0x00, 0x00, 0x48, 0x43, // 200
+		})
+		test.That(t, binaryData(PCDFloat64), test.ShouldResemble, []byte{
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf0, 0x3f, // 1
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xc0, // -2
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xe0, 0x3f, // 0.5
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x69, 0x40, // 200
+		})
+	})
+
+	t.Run("unknown precision", func(t *testing.T) {
+		err := ToPCD(pointcloud.New(), &bytes.Buffer{}, pointcloud.PCDAscii, qualityEncodingIntensity, PCDPrecision(3))
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unknown PCD precision")
+	})
+
+	t.Run("unknown encoding", func(t *testing.T) {
+		err := ToPCD(pointcloud.New(), &bytes.Buffer{}, pointcloud.PCDAscii, "float", PCDFloat32)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unknown quality encoding")
+	})
+
+	t.Run("compressed is not supported", func(t *testing.T) {
+		err := ToPCD(pointcloud.New(), &bytes.Buffer{}, pointcloud.PCDCompressed, qualityEncodingIntensity, PCDFloat32)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,359 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// LoadPCDDir loads every PCD file in the directory at path, in the order of the timestamps their names are made of,
+// and returns the point clouds along with their timestamps. A file is named by its timestamp, either in RFC 3339,
+// ex. "2026-10-15T12:00:00.123456789Z.pcd", or in nanoseconds since the Unix epoch, ex.
+// "1792065600123456789.pcd", and may be gzipped, ex. "1792065600123456789.pcd.gz". Other files are ignored.
+//
+// Ascii and binary PCD files are read, with their positions converted from meters to millimeters, and the quality
+// channel restored from an "intensity", "quality" or "rgb" field as ToPCD writes them. Compressed PCD is not
+// supported. Files that cannot be read or are not named by a timestamp are skipped: the point clouds of the others
+// are still returned, along with an error listing every skipped file.
+func LoadPCDDir(path string) ([]pointcloud.PointCloud, []time.Time, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type loadedPCD struct {
+		cloud     pointcloud.PointCloud
+		timestamp time.Time
+	}
+	var loaded []loadedPCD
+	var errs error
+	for _, entry := range entries {
+		name := entry.Name()
+		gzipped := strings.HasSuffix(name, ".pcd.gz")
+		if entry.IsDir() || !(gzipped || strings.HasSuffix(name, ".pcd")) {
+			continue
+		}
+		stem := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".pcd")
+		timestamp, err := parsePCDTimestamp(stem)
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "skipping %v", name))
+			continue
+		}
+		cloud, err := readPCDFile(filepath.Join(path, name), gzipped)
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "skipping %v", name))
+			continue
+		}
+		loaded = append(loaded, loadedPCD{cloud: cloud, timestamp: timestamp})
+	}
+
+	sort.SliceStable(loaded, func(i, j int) bool {
+		return loaded[i].timestamp.Before(loaded[j].timestamp)
+	})
+	clouds := make([]pointcloud.PointCloud, len(loaded))
+	timestamps := make([]time.Time, len(loaded))
+	for i, l := range loaded {
+		clouds[i], timestamps[i] = l.cloud, l.timestamp
+	}
+	return clouds, timestamps, errs
+}
+
+// parsePCDTimestamp parses the name of a PCD file, without its extension, as a timestamp in RFC 3339 or in
+// nanoseconds since the Unix epoch.
+func parsePCDTimestamp(stem string) (time.Time, error) {
+	if ns, err := strconv.ParseInt(stem, 10, 64); err == nil {
+		return time.Unix(0, ns), nil
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, stem)
+	if err != nil {
+		return time.Time{}, errors.Errorf("name %q is not a timestamp", stem)
+	}
+	return timestamp, nil
+}
+
+// readPCDFile reads the PCD file at path, decompressing it first if it is gzipped.
+func readPCDFile(path string, gzipped bool) (_ pointcloud.PointCloud, err error) {
+	//nolint:gosec
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = multierr.Combine(err, f.Close())
+	}()
+
+	var in io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			err = multierr.Combine(err, gz.Close())
+		}()
+		in = gz
+	}
+	return readPCD(in)
+}
+
+// pcdField is a field of the points of a PCD file, as declared by its header.
+type pcdField struct {
+	name  string
+	size  int
+	kind  byte
+	count int
+}
+
+// readPCD reads an ascii or binary PCD file. Fields other than x, y, z and the quality channel are skipped.
+func readPCD(r io.Reader) (pointcloud.PointCloud, error) {
+	in := bufio.NewReader(r)
+	fields, points, dataType, err := readPCDHeader(in)
+	if err != nil {
+		return nil, err
+	}
+
+	// The index of the first value of every field among the values of a point.
+	offsets := map[string]int{}
+	var valueCount int
+	for _, f := range fields {
+		offsets[f.name] = valueCount
+		valueCount += f.count
+	}
+	for _, axis := range []string{"x", "y", "z"} {
+		if _, ok := offsets[axis]; !ok {
+			return nil, errors.Errorf("PCD has no %q field", axis)
+		}
+	}
+
+	var readValues func(values []float64) error
+	switch dataType {
+	case "ascii":
+		readValues = func(values []float64) error {
+			line, err := in.ReadString('\n')
+			if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+				return err
+			}
+			tokens := strings.Fields(line)
+			if len(tokens) != len(values) {
+				return errors.Errorf("expected %d values, got %d", len(values), len(tokens))
+			}
+			for i, token := range tokens {
+				if values[i], err = strconv.ParseFloat(token, 64); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case "binary":
+		var recordSize int
+		for _, f := range fields {
+			recordSize += f.size * f.count
+		}
+		record := make([]byte, recordSize)
+		readValues = func(values []float64) error {
+			if _, err := io.ReadFull(in, record); err != nil {
+				return err
+			}
+			pos, i := 0, 0
+			for _, f := range fields {
+				for c := 0; c < f.count; c++ {
+					values[i] = decodePCDValue(record[pos:pos+f.size], f.kind)
+					pos += f.size
+					i++
+				}
+			}
+			return nil
+		}
+	default:
+		return nil, errors.Errorf("unsupported PCD data type %q", dataType)
+	}
+
+	// PCL writes the packed color of an rgb field into the bits of a float.
+	rgbIsFloat := false
+	for _, f := range fields {
+		if f.name == "rgb" {
+			rgbIsFloat = f.kind == 'F'
+		}
+	}
+
+	cloud := pointcloud.New()
+	values := make([]float64, valueCount)
+	for i := 0; i < points; i++ {
+		if err := readValues(values); err != nil {
+			return nil, errors.Wrapf(err, "reading point %d", i)
+		}
+		pos := r3.Vector{X: values[offsets["x"]], Y: values[offsets["y"]], Z: values[offsets["z"]]}.Mul(1000)
+		d := pointcloud.NewBasicData()
+		if idx, ok := offsets["intensity"]; ok {
+			d.SetIntensity(uint16(math.Max(0, math.Min(values[idx], math.MaxUint16))))
+		}
+		if idx, ok := offsets["quality"]; ok {
+			d.SetValue(int(values[idx]))
+		}
+		if idx, ok := offsets["rgb"]; ok {
+			packed := uint32(values[idx])
+			if rgbIsFloat {
+				packed = math.Float32bits(float32(values[idx]))
+			}
+			d.SetColor(color.NRGBA{R: uint8(packed >> 16), G: uint8(packed >> 8), B: uint8(packed), A: 255})
+		}
+		if err := cloud.Set(pos, d); err != nil {
+			return nil, err
+		}
+	}
+	return cloud, nil
+}
+
+// readPCDHeader reads the header of a PCD file up to and including its DATA line, returning its fields, the number
+// of points and the data type.
+func readPCDHeader(in *bufio.Reader) ([]pcdField, int, string, error) {
+	var fields []pcdField
+	var sizes, kinds, counts []string
+	points := -1
+	width, height := 0, 1
+	for {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return nil, 0, "", errors.Wrap(err, "reading PCD header")
+		}
+		tokens := strings.Fields(line)
+		if len(tokens) == 0 || strings.HasPrefix(tokens[0], "#") {
+			continue
+		}
+		switch tokens[0] {
+		case "FIELDS":
+			for _, name := range tokens[1:] {
+				fields = append(fields, pcdField{name: name, size: 4, kind: 'F', count: 1})
+			}
+		case "SIZE":
+			sizes = tokens[1:]
+		case "TYPE":
+			kinds = tokens[1:]
+		case "COUNT":
+			counts = tokens[1:]
+		case "WIDTH":
+			width, err = pcdHeaderInt(tokens)
+		case "HEIGHT":
+			height, err = pcdHeaderInt(tokens)
+		case "POINTS":
+			points, err = pcdHeaderInt(tokens)
+		case "DATA":
+			if len(tokens) != 2 {
+				return nil, 0, "", errors.New("malformed PCD DATA line")
+			}
+			if len(fields) == 0 {
+				return nil, 0, "", errors.New("PCD header has no FIELDS line")
+			}
+			if err := applyPCDFieldLines(fields, sizes, kinds, counts); err != nil {
+				return nil, 0, "", err
+			}
+			if points < 0 {
+				points = width * height
+			}
+			return fields, points, tokens[1], nil
+		}
+		if err != nil {
+			return nil, 0, "", err
+		}
+	}
+}
+
+// applyPCDFieldLines sets the size, type and count of the fields from the SIZE, TYPE and COUNT lines of a PCD
+// header. Missing lines leave the defaults of a single 4 byte float.
+func applyPCDFieldLines(fields []pcdField, sizes, kinds, counts []string) error {
+	for _, line := range []struct {
+		name   string
+		tokens []string
+	}{{"SIZE", sizes}, {"TYPE", kinds}, {"COUNT", counts}} {
+		if line.tokens != nil && len(line.tokens) != len(fields) {
+			return errors.Errorf("PCD %s line has %d values for %d fields", line.name, len(line.tokens), len(fields))
+		}
+	}
+	for i := range fields {
+		if sizes != nil {
+			size, err := strconv.Atoi(sizes[i])
+			if err != nil || (size != 1 && size != 2 && size != 4 && size != 8) {
+				return errors.Errorf("invalid PCD field size %q", sizes[i])
+			}
+			fields[i].size = size
+		}
+		if kinds != nil {
+			if kind := kinds[i]; kind == "F" || kind == "U" || kind == "I" {
+				fields[i].kind = kind[0]
+			} else {
+				return errors.Errorf("invalid PCD field type %q", kind)
+			}
+		}
+		if counts != nil {
+			count, err := strconv.Atoi(counts[i])
+			if err != nil || count < 1 {
+				return errors.Errorf("invalid PCD field count %q", counts[i])
+			}
+			fields[i].count = count
+		}
+		if fields[i].kind == 'F' && fields[i].size != 4 && fields[i].size != 8 {
+			return errors.Errorf("invalid PCD float field size %d", fields[i].size)
+		}
+	}
+	return nil
+}
+
+// pcdHeaderInt parses the single non-negative integer of a PCD header line.
+func pcdHeaderInt(tokens []string) (int, error) {
+	if len(tokens) != 2 {
+		return 0, errors.Errorf("malformed PCD %s line", tokens[0])
+	}
+	n, err := strconv.Atoi(tokens[1])
+	if err != nil || n < 0 {
+		return 0, errors.Errorf("invalid PCD %s %q", tokens[0], tokens[1])
+	}
+	return n, nil
+}
+
+// decodePCDValue decodes a little endian binary PCD value of the given size and type.
+func decodePCDValue(b []byte, kind byte) float64 {
+	switch kind {
+	case 'F':
+		if len(b) == 8 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	case 'I':
+		switch len(b) {
+		case 1:
+			return float64(int8(b[0]))
+		case 2:
+			return float64(int16(binary.LittleEndian.Uint16(b)))
+		case 4:
+			return float64(int32(binary.LittleEndian.Uint32(b)))
+		default:
+			return float64(int64(binary.LittleEndian.Uint64(b)))
+		}
+	default:
+		switch len(b) {
+		case 1:
+			return float64(b[0])
+		case 2:
+			return float64(binary.LittleEndian.Uint16(b))
+		case 4:
+			return float64(binary.LittleEndian.Uint32(b))
+		default:
+			return float64(binary.LittleEndian.Uint64(b))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package rplidar
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestLoadPCDDir(t *testing.T) {
+	cloudOf := func(t *testing.T, xMM float64, quality uint8, encoding string) pointcloud.PointCloud {
+		t.Helper()
+		pc := pointcloud.New()
+		d := pointcloud.NewBasicData()
+		d.SetIntensity(uint16(quality))
+		setQuality(d, quality, encoding)
+		test.That(t, pc.Set(r3.Vector{X: xMM, Y: -500}, d), test.ShouldBeNil)
+		return pc
+	}
+	writePCD := func(
+		t *testing.T,
+		path string,
+		pc pointcloud.PointCloud,
+		outputType pointcloud.PCDType,
+		encoding string,
+		precision PCDPrecision,
+	) {
+		t.Helper()
+		var buf bytes.Buffer
+		test.That(t, ToPCD(pc, &buf, outputType, encoding, precision), test.ShouldBeNil)
+		data := buf.Bytes()
+		if filepath.Ext(path) == ".gz" {
+			var gzipped bytes.Buffer
+			gz := gzip.NewWriter(&gzipped)
+			_, err := gz.Write(data)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, gz.Close(), test.ShouldBeNil)
+			data = gzipped.Bytes()
+		}
+		test.That(t, os.WriteFile(path, data, 0o600), test.ShouldBeNil)
+	}
+
+	t.Run("ascii, binary and gzip in timestamp order", func(t *testing.T) {
+		dir := t.TempDir()
+		start := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+		writePCD(t, filepath.Join(dir, start.Add(2*time.Second).Format(time.RFC3339Nano)+".pcd"),
+			cloudOf(t, 3000, 30, qualityEncodingIntensity), pointcloud.PCDAscii, qualityEncodingIntensity, PCDFloat32)
+		writePCD(t, filepath.Join(dir, start.Format(time.RFC3339Nano)+".pcd"),
+			cloudOf(t, 1000, 10, qualityEncodingValue), pointcloud.PCDBinary, qualityEncodingValue, PCDFloat64)
+		writePCD(t, filepath.Join(dir, "1792065601000000000.pcd.gz"),
+			cloudOf(t, 2000, 20, qualityEncodingRGB), pointcloud.PCDBinary, qualityEncodingRGB, PCDFloat32)
+		test.That(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a scan"), 0o600), test.ShouldBeNil)
+
+		clouds, timestamps, err := LoadPCDDir(dir)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, timestamps, test.ShouldHaveLength, 3)
+		test.That(t, timestamps[0].Equal(start), test.ShouldBeTrue)
+		test.That(t, timestamps[1].Equal(time.Unix(0, 1792065601000000000)), test.ShouldBeTrue)
+		test.That(t, timestamps[2].Equal(start.Add(2*time.Second)), test.ShouldBeTrue)
+
+		test.That(t, clouds, test.ShouldHaveLength, 3)
+		d, ok := clouds[0].At(1000, -500, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Value(), test.ShouldEqual, 10)
+		d, ok = clouds[1].At(2000, -500, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		r, g, b := d.RGB255()
+		test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{20, 20, 20})
+		d, ok = clouds[2].At(3000, -500, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Intensity(), test.ShouldEqual, 30)
+	})
+
+	t.Run("malformed files are skipped", func(t *testing.T) {
+		dir := t.TempDir()
+		writePCD(t, filepath.Join(dir, "1792065600000000000.pcd"),
+			cloudOf(t, 1000, 10, qualityEncodingIntensity), pointcloud.PCDBinary, qualityEncodingIntensity, PCDFloat32)
+		test.That(t, os.WriteFile(filepath.Join(dir, "1792065601000000000.pcd"),
+			[]byte("VERSION .7\nFIELDS x y z\nPOINTS 2\nDATA ascii\n1 2 3\n"), 0o600), test.ShouldBeNil)
+		writePCD(t, filepath.Join(dir, "scan.pcd"),
+			cloudOf(t, 1000, 10, qualityEncodingIntensity), pointcloud.PCDAscii, qualityEncodingIntensity, PCDFloat32)
+
+		clouds, timestamps, err := LoadPCDDir(dir)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "skipping 1792065601000000000.pcd: reading point 1")
+		test.That(t, err.Error(), test.ShouldContainSubstring, `skipping scan.pcd: name "scan" is not a timestamp`)
+		test.That(t, clouds, test.ShouldHaveLength, 1)
+		test.That(t, clouds[0].Size(), test.ShouldEqual, 1)
+		test.That(t, timestamps, test.ShouldHaveLength, 1)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, _, err := LoadPCDDir(filepath.Join(t.TempDir(), "missing"))
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
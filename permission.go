@@ -0,0 +1,47 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// ErrPermissionDenied is returned when the user running the module may not read and write the rplidar's serial
+// port. The SDK only reports a failure to open the port, so access is checked before connecting.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// The group serial ports belong to on Debian and Ubuntu, suggested when the group of the port cannot be looked up.
+const defaultSerialGroup = "dialout"
+
+// checkDeviceAccess returns an error wrapping ErrPermissionDenied if the current user may not read and write
+// devicePath, telling how to grant access. It checks access without opening the port, since opening it toggles
+// the DTR line, which controls the motor of the A-series.
+func checkDeviceAccess(devicePath string) error {
+	return deviceAccessError(devicePath, unix.Access(devicePath, unix.R_OK|unix.W_OK))
+}
+
+// deviceAccessError turns the error of checking access to devicePath into an error wrapping ErrPermissionDenied,
+// and ignores any other error, e.g. a missing device, for connecting to report.
+func deviceAccessError(devicePath string, err error) error {
+	if !errors.Is(err, fs.ErrPermission) {
+		return nil
+	}
+	group := defaultSerialGroup
+	if info, statErr := os.Stat(devicePath); statErr == nil {
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			if g, lookupErr := user.LookupGroupId(strconv.FormatUint(uint64(stat.Gid), 10)); lookupErr == nil {
+				group = g.Name
+			}
+		}
+	}
+	return fmt.Errorf("%w: the user running the module may not read and write %v, add it to the %q group the port "+
+		"belongs to, e.g. with `sudo usermod -aG %v $USER`, then log in again or restart viam-server",
+		ErrPermissionDenied, devicePath, group, group)
+}
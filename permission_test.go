@@ -0,0 +1,37 @@
+package rplidar
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestDeviceAccessError(t *testing.T) {
+	device := filepath.Join(t.TempDir(), "ttyUSB0")
+	f, err := os.Create(device)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, f.Close(), test.ShouldBeNil)
+
+	test.That(t, deviceAccessError(device, nil), test.ShouldBeNil)
+	// A missing device is for connecting to report.
+	test.That(t, deviceAccessError(device, syscall.ENOENT), test.ShouldBeNil)
+
+	err = deviceAccessError(device, syscall.EACCES)
+	test.That(t, errors.Is(err, ErrPermissionDenied), test.ShouldBeTrue)
+	test.That(t, errors.Is(err, ErrDeviceBusy), test.ShouldBeFalse)
+	test.That(t, err.Error(), test.ShouldContainSubstring, device)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "sudo usermod -aG")
+
+	t.Run("checks read and write access", func(t *testing.T) {
+		test.That(t, checkDeviceAccess(device), test.ShouldBeNil)
+		if os.Geteuid() == 0 {
+			t.Skip("root may access any file")
+		}
+		test.That(t, os.Chmod(device, 0o400), test.ShouldBeNil)
+		test.That(t, errors.Is(checkDeviceAccess(device), ErrPermissionDenied), test.ShouldBeTrue)
+	})
+}
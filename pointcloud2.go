@@ -0,0 +1,79 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// PointCloud2Float32 is the sensor_msgs/PointField datatype of a 32 bit float.
+const PointCloud2Float32 = 7
+
+// pointCloud2PointStep is the size in bytes of a single point: x, y, z and intensity as 32 bit floats.
+const pointCloud2PointStep = 16
+
+// PointCloud2Field describes a single field of a point, as in a sensor_msgs/PointField message.
+type PointCloud2Field struct {
+	Name     string
+	Offset   uint32
+	Datatype uint8
+	Count    uint32
+}
+
+// PointCloud2Fields holds everything besides the data needed to build a sensor_msgs/PointCloud2 message for
+// the data returned by MarshalPointCloud2.
+type PointCloud2Fields struct {
+	Fields      []PointCloud2Field
+	Height      uint32
+	Width       uint32
+	IsBigEndian bool
+	PointStep   uint32
+	RowStep     uint32
+	IsDense     bool
+}
+
+// MarshalPointCloud2 packs scan in the layout of a ROS2 sensor_msgs/PointCloud2 message: an unordered cloud with
+// a height of 1, where each point is its x, y and z in meters followed by its intensity, all float32. The data
+// is always little endian, regardless of the host, and the returned fields report it with IsBigEndian set to
+// false.
+func MarshalPointCloud2(scan pointcloud.PointCloud) ([]byte, PointCloud2Fields, error) {
+	if scan == nil {
+		return nil, PointCloud2Fields{}, errors.New("no point cloud to marshal")
+	}
+
+	data := make([]byte, 0, scan.Size()*pointCloud2PointStep)
+	var point [pointCloud2PointStep]byte
+	scan.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		var intensity float32
+		if d != nil {
+			intensity = float32(d.Intensity())
+		}
+		// Positions are in millimeters, ROS uses meters.
+		binary.LittleEndian.PutUint32(point[0:], math.Float32bits(float32(p.X/1000)))
+		binary.LittleEndian.PutUint32(point[4:], math.Float32bits(float32(p.Y/1000)))
+		binary.LittleEndian.PutUint32(point[8:], math.Float32bits(float32(p.Z/1000)))
+		binary.LittleEndian.PutUint32(point[12:], math.Float32bits(intensity))
+		data = append(data, point[:]...)
+		return true
+	})
+
+	width := uint32(len(data) / pointCloud2PointStep)
+	return data, PointCloud2Fields{
+		Fields: []PointCloud2Field{
+			{Name: "x", Offset: 0, Datatype: PointCloud2Float32, Count: 1},
+			{Name: "y", Offset: 4, Datatype: PointCloud2Float32, Count: 1},
+			{Name: "z", Offset: 8, Datatype: PointCloud2Float32, Count: 1},
+			{Name: "intensity", Offset: 12, Datatype: PointCloud2Float32, Count: 1},
+		},
+		Height:      1,
+		Width:       width,
+		IsBigEndian: false,
+		PointStep:   pointCloud2PointStep,
+		RowStep:     width * pointCloud2PointStep,
+		IsDense:     true,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package rplidar
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestMarshalPointCloud2(t *testing.T) {
+	t.Run("packs points little endian", func(t *testing.T) {
+		scan := pointcloud.New()
+		d := pointcloud.NewBasicData()
+		d.SetIntensity(300)
+		test.That(t, scan.Set(r3.Vector{X: 1500, Y: -250, Z: 0}, d), test.ShouldBeNil)
+
+		data, fields, err := MarshalPointCloud2(scan)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, fields.IsBigEndian, test.ShouldBeFalse)
+		test.That(t, fields.Height, test.ShouldEqual, 1)
+		test.That(t, fields.Width, test.ShouldEqual, 1)
+		test.That(t, fields.PointStep, test.ShouldEqual, 16)
+		test.That(t, fields.RowStep, test.ShouldEqual, 16)
+		test.That(t, fields.Fields, test.ShouldResemble, []PointCloud2Field{
+			{Name: "x", Offset: 0, Datatype: PointCloud2Float32, Count: 1},
+			{Name: "y", Offset: 4, Datatype: PointCloud2Float32, Count: 1},
+			{Name: "z", Offset: 8, Datatype: PointCloud2Float32, Count: 1},
+			{Name: "intensity", Offset: 12, Datatype: PointCloud2Float32, Count: 1},
+		})
+
+		test.That(t, data, test.ShouldResemble, []byte{
+			0x00, 0x00, 0xc0, 0x3f, // 1.5
+			0x00, 0x00, 0x80, 0xbe, // -0.25
+			0x00, 0x00, 0x00, 0x00, // 0
+			0x00, 0x00, 0x96, 0x43, // 300
+		})
+	})
+
+	t.Run("every point is packed", func(t *testing.T) {
+		scan := pointcloud.New()
+		for i := 0; i < 10; i++ {
+			test.That(t, scan.Set(r3.Vector{X: float64(i) * 1000}, nil), test.ShouldBeNil)
+		}
+
+		data, fields, err := MarshalPointCloud2(scan)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, fields.Width, test.ShouldEqual, 10)
+		test.That(t, len(data), test.ShouldEqual, int(fields.RowStep))
+
+		xs := make(map[float32]bool)
+		for offset := 0; offset < len(data); offset += int(fields.PointStep) {
+			xs[math.Float32frombits(binary.LittleEndian.Uint32(data[offset:]))] = true
+		}
+		test.That(t, len(xs), test.ShouldEqual, 10)
+	})
+
+	t.Run("no point cloud", func(t *testing.T) {
+		_, _, err := MarshalPointCloud2(nil)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,102 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rplidar/gen"
+)
+
+const (
+	// The number of revolutions discarded after switching to a scan mode while probing, before measuring it.
+	probeDiscardScans = 2
+	// The number of revolutions the sample rate of every scan mode is measured over while probing.
+	probeMeasureScans = 5
+)
+
+// scanModeProbe is the result of briefly scanning in one of the device's scan modes.
+type scanModeProbe struct {
+	info                  scanModeInfo
+	measuredSamplesPerSec float64
+	err                   error
+}
+
+// startScanMode starts scanning in the scan mode with the given id, as reported by querySupportedScanModes, and
+// records the scan mode the device ended up using.
+func (device *rplidarDevice) startScanMode(id uint16) error {
+	usedScanMode := gen.NewRplidarScanMode()
+	defer gen.DeleteRplidarScanMode(usedScanMode)
+
+	if err := Result(device.driver.StartScanExpress(false, id, uint(0), usedScanMode)).Failed(); err != nil {
+		return fmt.Errorf("failed to start scan: %w", err)
+	}
+	device.setScanMode(usedScanMode)
+	return nil
+}
+
+// probeScanModes briefly scans in every scan mode the device supports, measuring the sample rate it achieves,
+// and logs a report of the results. Scanning is stopped afterwards, so it has to be started again in the
+// configured mode. The motor must already be spinning.
+func (rp *rplidar) probeScanModes(ctx context.Context) []scanModeProbe {
+	probes := make([]scanModeProbe, 0, len(rp.device.scanModes))
+	for _, info := range rp.device.scanModes {
+		probe := scanModeProbe{info: info}
+		probe.measuredSamplesPerSec, probe.err = rp.probeScanMode(ctx, info.id)
+		probes = append(probes, probe)
+		if probe.err != nil {
+			rp.logger.Warnf("scan mode %v: probing failed: %v", info.mode, probe.err)
+			continue
+		}
+		rp.logger.Infof("scan mode %v: measured %.0f samples/s, specified %.0f samples/s, max distance %vm",
+			info.mode, probe.measuredSamplesPerSec, info.samplesPerSec, info.maxDistanceM)
+	}
+	rp.device.driver.Stop()
+	return probes
+}
+
+// probeScanMode scans in the scan mode with the given id and returns the number of samples per second the device
+// delivered.
+func (rp *rplidar) probeScanMode(ctx context.Context, id uint16) (float64, error) {
+	rp.device.driver.Stop()
+	if err := rp.device.startScanMode(id); err != nil {
+		return 0, err
+	}
+	if _, err := rp.grabRevolutions(ctx, probeDiscardScans); err != nil {
+		return 0, err
+	}
+
+	start := clockOrReal(rp.clock).Now()
+	samples, err := rp.grabRevolutions(ctx, probeMeasureScans)
+	if err != nil {
+		return 0, err
+	}
+	elapsed := clockOrReal(rp.clock).Now().Sub(start)
+	if elapsed <= 0 {
+		return 0, errors.New("no time passed while measuring the sample rate")
+	}
+	return float64(samples) / elapsed.Seconds(), nil
+}
+
+// grabRevolutions grabs numScans revolutions from the device and returns the number of samples it reported,
+// including samples without a valid distance. Unlike scan, the revolutions bypass the scan pipeline, so probing a
+// scan mode or waiting for the motor to settle neither counts towards the statistics nor reaches the callbacks,
+// filters and the revolutions that follow.
+func (rp *rplidar) grabRevolutions(ctx context.Context, numScans int) (int64, error) {
+	rp.device.mutex.Lock()
+	defer rp.device.mutex.Unlock()
+
+	var samples int64
+	for i := 0; i < numScans; i++ {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		nodeCount := int64(defaultNodeSize)
+		if err := Result(rp.device.driver.GrabScanDataHq(rp.nodes, &nodeCount, rp.device.timeoutMs)).Failed(); err != nil {
+			return 0, fmt.Errorf("bad scan: %w", err)
+		}
+		samples += nodeCount
+	}
+	return samples, nil
+}
@@ -0,0 +1,71 @@
+package rplidar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rplidar/gen"
+	"go.viam.com/rplidar/inject"
+	"go.viam.com/test"
+)
+
+func TestProbeScanModes(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+
+	driver := inject.NewRPLiDARDriver()
+	var startedIDs []uint16
+	driver.StartScanExpressFunc = func(a ...interface{}) uint {
+		id := a[0].([]interface{})[1].(uint16)
+		startedIDs = append(startedIDs, id)
+		if id == 2 {
+			return uint(gen.RESULT_OPERATION_NOT_SUPPORT)
+		}
+		return uint(gen.RESULT_OK)
+	}
+	var stopCount int
+	driver.StopFunc = func(a ...interface{}) uint {
+		stopCount++
+		return uint(gen.RESULT_OK)
+	}
+	// Every revolution delivers 400 samples over 100ms.
+	driver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+		*(a[0].([]interface{})[1].(*int64)) = 400
+		clock.Advance(100 * time.Millisecond)
+		return uint(gen.RESULT_OK)
+	}
+
+	logger, logs := logging.NewObservedTestLogger(t)
+	rp := &rplidar{
+		device: &rplidarDevice{
+			driver: &driver,
+			scanModes: []scanModeInfo{
+				{id: 0, mode: "Standard", samplesPerSec: 4000, maxDistanceM: 12},
+				{id: 2, mode: "Boost", samplesPerSec: 8000, maxDistanceM: 12},
+			},
+		},
+		nodes:           gen.New_measurementNodeHqArray(defaultNodeSize),
+		clock:           clock,
+		logger:          logger,
+		rawMeasurements: newAsyncNotifier[[]Measurement](defaultMeasurementQueueSize, bufferPolicyOldest),
+	}
+	rp.OnMeasurements(func([]Measurement) {})
+
+	probes := rp.probeScanModes(ctx)
+	test.That(t, startedIDs, test.ShouldResemble, []uint16{0, 2})
+	test.That(t, len(probes), test.ShouldEqual, 2)
+	test.That(t, probes[0].err, test.ShouldBeNil)
+	test.That(t, probes[0].measuredSamplesPerSec, test.ShouldAlmostEqual, 4000)
+	test.That(t, probes[1].err, test.ShouldNotBeNil)
+	// Scanning is stopped before every mode and once all are probed, so the configured mode can be started.
+	test.That(t, stopCount, test.ShouldEqual, 3)
+	test.That(t, logs.FilterMessageSnippet("measured 4000 samples/s, specified 4000 samples/s").Len(),
+		test.ShouldEqual, 1)
+	test.That(t, logs.FilterMessageSnippet("scan mode Boost: probing failed").Len(), test.ShouldEqual, 1)
+
+	// The probing revolutions do not reach the scan pipeline.
+	test.That(t, rp.rawMeasurements.queue, test.ShouldBeEmpty)
+	test.That(t, rp.stats.session(clock.Now()).Scans, test.ShouldEqual, 0)
+}
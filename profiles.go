@@ -0,0 +1,66 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The name of the profile applied to rplidars without a profile of their own.
+const defaultProfileName = "default"
+
+// Profile overrides the attributes that depend on how a single rplidar is mounted, see Config.Profiles. Fields
+// that are not set keep the value of the base config.
+type Profile struct {
+	MinRangeMM     *float64      `json:"min_range_mm,omitempty"`
+	AngleOffsetDeg *float64      `json:"angle_offset_deg,omitempty"`
+	BlankBelowMM   *float64      `json:"blank_below_mm,omitempty"`
+	BlankSectors   []BlankSector `json:"blank_sectors,omitempty"`
+}
+
+// profileFor returns the name and the profile to apply to the rplidar with the given serial number: the profile
+// keyed by the serial number, compared case insensitively, or else the default profile. It reports false if
+// neither exists.
+func (conf *Config) profileFor(serialNumber string) (string, Profile, bool) {
+	for name, profile := range conf.Profiles {
+		if serialNumber != "" && strings.EqualFold(name, serialNumber) {
+			return name, profile, true
+		}
+	}
+	profile, ok := conf.Profiles[defaultProfileName]
+	return defaultProfileName, profile, ok
+}
+
+// withProfile returns a copy of the config with the fields set in profile replacing those of the config, and
+// without any profiles.
+func (conf Config) withProfile(profile Profile) Config {
+	conf.Profiles = nil
+	if profile.MinRangeMM != nil {
+		conf.MinRangeMM = *profile.MinRangeMM
+	}
+	if profile.AngleOffsetDeg != nil {
+		conf.AngleOffsetDeg = *profile.AngleOffsetDeg
+	}
+	if profile.BlankBelowMM != nil {
+		conf.BlankBelowMM = *profile.BlankBelowMM
+	}
+	if profile.BlankSectors != nil {
+		conf.BlankSectors = profile.BlankSectors
+	}
+	return conf
+}
+
+// validateProfiles checks that every profile yields a valid config when applied.
+func (conf *Config) validateProfiles(path string) error {
+	for name, profile := range conf.Profiles {
+		if name == "" {
+			return errors.New("profiles must be keyed by a serial number or \"default\"")
+		}
+		merged := conf.withProfile(profile)
+		if _, err := merged.Validate(path); err != nil {
+			return errors.Wrapf(err, "profile %q", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package rplidar
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestProfiles(t *testing.T) {
+	offset, blankBelow := 12.5, 80.
+	conf := Config{
+		MinRangeMM:     50,
+		AngleOffsetDeg: 3,
+		Profiles: map[string]Profile{
+			"0123abcd": {AngleOffsetDeg: &offset, BlankBelowMM: &blankBelow, BlankSectors: []BlankSector{{StartDeg: 10, EndDeg: 20}}},
+			"default":  {BlankBelowMM: &blankBelow, BlankSectors: []BlankSector{{StartDeg: 30, EndDeg: 40}}},
+		},
+	}
+	_, err := conf.Validate("")
+	test.That(t, err, test.ShouldBeNil)
+
+	t.Run("the serial number's profile is merged over the base config", func(t *testing.T) {
+		name, profile, ok := conf.profileFor("0123ABCD")
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, name, test.ShouldEqual, "0123abcd")
+		merged := conf.withProfile(profile)
+		test.That(t, merged.MinRangeMM, test.ShouldEqual, 50)
+		test.That(t, merged.AngleOffsetDeg, test.ShouldEqual, 12.5)
+		test.That(t, merged.BlankBelowMM, test.ShouldEqual, 80)
+		test.That(t, merged.BlankSectors, test.ShouldResemble, []BlankSector{{StartDeg: 10, EndDeg: 20}})
+		test.That(t, merged.Profiles, test.ShouldBeNil)
+	})
+
+	t.Run("other serial numbers get the default profile", func(t *testing.T) {
+		name, profile, ok := conf.profileFor("FFFF")
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, name, test.ShouldEqual, defaultProfileName)
+		merged := conf.withProfile(profile)
+		test.That(t, merged.AngleOffsetDeg, test.ShouldEqual, 3)
+		test.That(t, merged.BlankSectors, test.ShouldResemble, []BlankSector{{StartDeg: 30, EndDeg: 40}})
+	})
+
+	t.Run("no profile applies without a default", func(t *testing.T) {
+		conf := Config{Profiles: map[string]Profile{"0123abcd": {}}}
+		_, _, ok := conf.profileFor("FFFF")
+		test.That(t, ok, test.ShouldBeFalse)
+	})
+
+	t.Run("profiles are validated after merging", func(t *testing.T) {
+		invalidOffset := 200.
+		conf := Config{Profiles: map[string]Profile{"0123abcd": {AngleOffsetDeg: &invalidOffset}}}
+		_, err := conf.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual,
+			`profile "0123abcd": angle_offset_deg must be between -180 and 180`)
+
+		conf = Config{Profiles: map[string]Profile{"": {}}}
+		_, err = conf.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,212 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rplidar/gen"
+)
+
+const (
+	// sysDir is where the kernel exposes its devices.
+	sysDir = "/sys"
+	// The number of failed reconnect attempts after which the USB device is reset, unless configured otherwise.
+	defaultUSBResetAfterAttempts = 3
+	// How long a reset USB device stays deauthorized, long enough for the kernel to unbind its drivers.
+	usbResetHold = time.Second
+	// How long to wait for the device to enumerate again after a USB reset before reconnecting.
+	usbResetSettle = 2 * time.Second
+)
+
+// reconnector decides when the scan loop reconnects to the device because scanning keeps failing, and when a
+// reconnect keeps failing badly enough to reset the USB device first. A nil reconnector never reconnects. It is
+// only used by the scan loop.
+type reconnector struct {
+	afterErrors int
+	// The number of failed reconnect attempts after which the USB device is reset, zero to never reset it.
+	usbResetAfter int
+
+	consecutiveErrors int
+	failedAttempts    int
+}
+
+// newReconnector creates a reconnector reconnecting after the given number of consecutive scan errors and
+// resetting the USB device after usbResetAfter consecutive failed reconnect attempts. A non-positive number of
+// errors disables it and returns nil, and a non-positive usbResetAfter never resets the USB device.
+func newReconnector(afterErrors, usbResetAfter int) *reconnector {
+	if afterErrors <= 0 {
+		return nil
+	}
+	return &reconnector{afterErrors: afterErrors, usbResetAfter: usbResetAfter}
+}
+
+// record records the outcome of a scan and reports whether to reconnect now.
+func (r *reconnector) record(err error) bool {
+	if r == nil {
+		return false
+	}
+	if err == nil {
+		r.consecutiveErrors = 0
+		r.failedAttempts = 0
+		return false
+	}
+	if r.consecutiveErrors++; r.consecutiveErrors < r.afterErrors {
+		return false
+	}
+	r.consecutiveErrors = 0
+	return true
+}
+
+// attemptFailed records a failed reconnect attempt and reports whether to reset the USB device before the next one.
+func (r *reconnector) attemptFailed() bool {
+	r.failedAttempts++
+	if r.usbResetAfter <= 0 || r.failedAttempts < r.usbResetAfter {
+		return false
+	}
+	r.failedAttempts = 0
+	return true
+}
+
+// deviceConnector returns a function connecting to the device at devicePath again at baudRate first, for
+// reconnecting to it.
+func deviceConnector(devicePath string, timeoutMs, baudRate uint) func() (*rplidarDevice, error) {
+	return func() (*rplidarDevice, error) {
+		return getRplidarDevice(devicePath, timeoutMs, baudRate)
+	}
+}
+
+// checkReconnect reconnects to the device after every scan that makes the reconnector ask for it, resetting the
+// USB device first when reconnecting keeps failing. It is only called from the scan loop.
+func (rp *rplidar) checkReconnect(ctx context.Context, err error) {
+	// A reconnect attempt that failed after restarting scanning can still leave the device scanning.
+	if err == nil && rp.State() == StateReconnecting {
+		rp.setState(StateScanning)
+	}
+	if !rp.reconnector.record(err) {
+		return
+	}
+	rp.logger.Warnf("reconnecting to the rplidar after %d consecutive scan errors, last: %v",
+		rp.reconnector.afterErrors, err)
+	rp.setState(StateReconnecting)
+	reconnectErr := rp.reconnect(ctx)
+	if reconnectErr == nil {
+		rp.setState(StateScanning)
+		rp.stats.addReconnect()
+		rp.logger.Info("reconnected to the rplidar")
+		return
+	}
+	rp.logResultCode(reconnectErr)
+	if !rp.reconnector.attemptFailed() {
+		rp.logger.Errorf("failed to reconnect to the rplidar: %v", reconnectErr)
+		return
+	}
+
+	rp.logger.Warnf("reconnecting failed %d times in a row, resetting the rplidar's USB device",
+		rp.reconnector.usbResetAfter)
+	rp.setState(StateResetting)
+	resetErr := rp.resetUSB(ctx)
+	rp.setState(StateReconnecting)
+	if resetErr != nil {
+		rp.logger.Errorf("failed to reset the rplidar's USB device: %v", resetErr)
+		return
+	}
+	rp.stats.addUSBReset()
+	if reconnectErr := rp.reconnect(ctx); reconnectErr != nil {
+		rp.logResultCode(reconnectErr)
+		rp.logger.Errorf("failed to reconnect to the rplidar after resetting its USB device: %v", reconnectErr)
+		return
+	}
+	rp.setState(StateScanning)
+	rp.stats.addReconnect()
+	rp.logger.Info("reconnected to the rplidar after resetting its USB device")
+}
+
+// reconnect closes the serial connection, connects to the device again and restarts scanning with the configured
+// protocol, as when the camera was created. The previous driver is only disposed of once a new one connected, so
+// the scan loop keeps a driver to fail on until then.
+func (rp *rplidar) reconnect(ctx context.Context) error {
+	rp.device.mutex.Lock()
+	rp.device.driver.Stop()
+	rp.device.driver.Disconnect()
+	rp.device.mutex.Unlock()
+
+	device, err := rp.connectDevice()
+	if err != nil {
+		return err
+	}
+	if device.serialNumber != rp.device.serialNumber {
+		rp.logger.Warnf("reconnected to an rplidar with serial number %v instead of %v",
+			device.serialNumber, rp.device.serialNumber)
+	}
+
+	rp.device.mutex.Lock()
+	previous := rp.device.driver
+	rp.device.driver = device.driver
+	rp.device.baudRate = device.baudRate
+	rp.device.mutex.Unlock()
+	rp.device.replaceIdentity(device)
+	gen.RPlidarDriverDisposeDriver(previous)
+
+	// Scanning starts over with the configured protocol, so does stepping down from it.
+	if rp.degrader != nil {
+		rp.degrader = newScanDegrader(rp.degrader.afterErrors, rp.degrader.recoverAfter)
+	}
+	if rp.controlsMotor() {
+		if err := rp.startMotor(ctx); err != nil {
+			return err
+		}
+	}
+	if err := rp.device.startScan(rp.expressProtocol, rp.forceScan); err != nil {
+		return err
+	}
+	clockOrReal(rp.clock).Wait(ctx, defaultWarmUpTimeout)
+	if _, _, err := rp.scan(ctx, rp.discardFirstScans); err != nil {
+		return err
+	}
+	rp.recordLastGood()
+	return nil
+}
+
+// resetUSBDevice power cycles the USB device the serial device at devicePath belongs to, found in sysDir, by
+// deauthorizing and authorizing it again. Only that device, and any device behind it, is reset. Writing its
+// authorized attribute requires root or a udev rule granting write access to it.
+func resetUSBDevice(ctx context.Context, sysDir, devicePath string, clk clock) error {
+	usbDevice, err := usbDeviceDir(sysDir, devicePath)
+	if err != nil {
+		return err
+	}
+	authorized := filepath.Join(usbDevice, "authorized")
+	//nolint:gosec
+	if err := os.WriteFile(authorized, []byte("0"), 0o644); err != nil {
+		return errors.Wrapf(err, "failed to deauthorize %v", usbDevice)
+	}
+	clockOrReal(clk).Wait(ctx, usbResetHold)
+	//nolint:gosec
+	if err := os.WriteFile(authorized, []byte("1"), 0o644); err != nil {
+		return errors.Wrapf(err, "failed to authorize %v", usbDevice)
+	}
+	clockOrReal(clk).Wait(ctx, usbResetSettle)
+	return nil
+}
+
+// usbDeviceDir returns the directory in sysDir of the USB device the serial device at devicePath belongs to: the
+// nearest parent of its tty device that is a USB device rather than one of its interfaces, as told by its busnum
+// attribute.
+func usbDeviceDir(sysDir, devicePath string) (string, error) {
+	name := filepath.Base(resolveDeviceLink(devicePath))
+	dir, err := filepath.EvalSymlinks(filepath.Join(sysDir, "class", "tty", name, "device"))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the device of %v", name)
+	}
+	root := filepath.Clean(sysDir)
+	for ; dir != root && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "busnum")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", errors.Errorf("%v is not a USB device", name)
+}
@@ -0,0 +1,222 @@
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rplidar/gen"
+	"go.viam.com/rplidar/inject"
+	"go.viam.com/test"
+)
+
+func TestReconnector(t *testing.T) {
+	errScan := errors.New("bad scan")
+
+	t.Run("disabled", func(t *testing.T) {
+		test.That(t, newReconnector(0, 3), test.ShouldBeNil)
+		var r *reconnector
+		test.That(t, r.record(errScan), test.ShouldBeFalse)
+	})
+
+	t.Run("reconnects after consecutive errors", func(t *testing.T) {
+		r := newReconnector(2, 0)
+		test.That(t, r.record(errScan), test.ShouldBeFalse)
+		// A successful scan resets the count.
+		test.That(t, r.record(nil), test.ShouldBeFalse)
+		test.That(t, r.record(errScan), test.ShouldBeFalse)
+		test.That(t, r.record(errScan), test.ShouldBeTrue)
+		test.That(t, r.record(errScan), test.ShouldBeFalse)
+		test.That(t, r.record(errScan), test.ShouldBeTrue)
+
+		// Without usb_reset_on_failure the USB device is never reset.
+		for i := 0; i < 5; i++ {
+			test.That(t, r.attemptFailed(), test.ShouldBeFalse)
+		}
+	})
+
+	t.Run("resets the USB device after failed attempts", func(t *testing.T) {
+		r := newReconnector(1, 2)
+		test.That(t, r.attemptFailed(), test.ShouldBeFalse)
+		test.That(t, r.attemptFailed(), test.ShouldBeTrue)
+		test.That(t, r.attemptFailed(), test.ShouldBeFalse)
+		// A successful scan restarts the count of failed attempts.
+		test.That(t, r.record(nil), test.ShouldBeFalse)
+		test.That(t, r.attemptFailed(), test.ShouldBeFalse)
+		test.That(t, r.attemptFailed(), test.ShouldBeTrue)
+	})
+}
+
+// fakeUSBSysDir lays out the sysfs entries of an rplidar at /dev/ttyUSB0 behind USB device 1-1.2 and returns the
+// sys dir and the directory of the USB device.
+func fakeUSBSysDir(t *testing.T) (string, string) {
+	t.Helper()
+	sys := t.TempDir()
+	hub := filepath.Join(sys, "devices", "pci0000:00", "usb1", "1-1")
+	usbDevice := filepath.Join(hub, "1-1.2")
+	tty := filepath.Join(usbDevice, "1-1.2:1.0", "ttyUSB0")
+	test.That(t, os.MkdirAll(tty, 0o755), test.ShouldBeNil)
+	for _, dir := range []string{hub, usbDevice} {
+		test.That(t, os.WriteFile(filepath.Join(dir, "busnum"), []byte("1\n"), 0o644), test.ShouldBeNil)
+		test.That(t, os.WriteFile(filepath.Join(dir, "authorized"), []byte("1\n"), 0o644), test.ShouldBeNil)
+	}
+	class := filepath.Join(sys, "class", "tty", "ttyUSB0")
+	test.That(t, os.MkdirAll(class, 0o755), test.ShouldBeNil)
+	test.That(t, os.Symlink(tty, filepath.Join(class, "device")), test.ShouldBeNil)
+	return sys, usbDevice
+}
+
+func TestUSBDeviceDir(t *testing.T) {
+	sys, usbDevice := fakeUSBSysDir(t)
+
+	dir, err := usbDeviceDir(sys, "/dev/ttyUSB0")
+	test.That(t, err, test.ShouldBeNil)
+	// The nearest USB device, not the hub it is plugged into.
+	test.That(t, dir, test.ShouldEqual, usbDevice)
+
+	_, err = usbDeviceDir(sys, "/dev/ttyUSB1")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// A serial device that is not behind USB.
+	serial := filepath.Join(sys, "devices", "platform", "serial8250", "tty", "ttyS0")
+	test.That(t, os.MkdirAll(serial, 0o755), test.ShouldBeNil)
+	test.That(t, os.MkdirAll(filepath.Join(sys, "class", "tty", "ttyS0"), 0o755), test.ShouldBeNil)
+	test.That(t, os.Symlink(serial, filepath.Join(sys, "class", "tty", "ttyS0", "device")), test.ShouldBeNil)
+	_, err = usbDeviceDir(sys, "/dev/ttyS0")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestResetUSBDevice(t *testing.T) {
+	sys, usbDevice := fakeUSBSysDir(t)
+	clock := newFakeClock()
+	start := clock.Now()
+
+	test.That(t, resetUSBDevice(context.Background(), sys, "/dev/ttyUSB0", clock), test.ShouldBeNil)
+	authorized, err := os.ReadFile(filepath.Join(usbDevice, "authorized"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(authorized), test.ShouldEqual, "1")
+	test.That(t, clock.Now().Sub(start), test.ShouldEqual, usbResetHold+usbResetSettle)
+
+	// The hub the lidar is plugged into is left alone.
+	authorized, err = os.ReadFile(filepath.Join(filepath.Dir(usbDevice), "authorized"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(authorized), test.ShouldEqual, "1\n")
+}
+
+func TestCheckReconnect(t *testing.T) {
+	ctx := context.Background()
+	errScan := errors.New("bad scan")
+
+	newDriver := func(events *[]string, name string) gen.RPlidarDriver {
+		driver := inject.NewRPLiDARDriver()
+		driver.StopFunc = func(a ...interface{}) uint {
+			*events = append(*events, "stop "+name)
+			return uint(gen.RESULT_OK)
+		}
+		driver.DisconnectFunc = func() {
+			*events = append(*events, "disconnect "+name)
+		}
+		driver.StartScanFunc = func(a ...interface{}) uint {
+			*events = append(*events, "start "+name)
+			return uint(gen.RESULT_OK)
+		}
+		driver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+			*(a[0].([]interface{})[1].(*int64)) = 0
+			return uint(gen.RESULT_OK)
+		}
+		return &driver
+	}
+
+	t.Run("reconnects after consecutive errors", func(t *testing.T) {
+		var events []string
+		rp := &rplidar{
+			device: &rplidarDevice{
+				driver: newDriver(&events, "old"), serialNumber: "abc",
+				firmwareVersionRaw: 1<<8 | 29, expressProtocol: expressProtocolStandard,
+			},
+			expressProtocol: expressProtocolStandard,
+			motorControl:    motorControlExternal,
+			reconnector:     newReconnector(2, 0),
+			connectDevice: func() (*rplidarDevice, error) {
+				// Another unit was plugged into the port.
+				return &rplidarDevice{
+					driver: newDriver(&events, "new"), model: 24, serialNumber: "def", firmwareVersion: "1.29",
+					firmwareVersionRaw: 1<<8 | 29, hardwareRevision: 7, healthStatus: 1,
+					scanModes: []scanModeInfo{{mode: "Boost", samplesPerSec: 8000}},
+				}, nil
+			},
+			resetUSB: func(ctx context.Context) error {
+				t.Fatal("the USB device must not be reset")
+				return nil
+			},
+			nodes:  gen.New_measurementNodeHqArray(defaultNodeSize),
+			clock:  newFakeClock(),
+			logger: logging.NewTestLogger(t),
+		}
+
+		rp.setState(StateScanning)
+		rp.checkReconnect(ctx, errScan)
+		test.That(t, events, test.ShouldBeEmpty)
+		rp.checkReconnect(ctx, errScan)
+		test.That(t, events, test.ShouldResemble, []string{"stop old", "disconnect old", "start new"})
+		test.That(t, rp.State(), test.ShouldEqual, StateScanning)
+		test.That(t, rp.Stats().Reconnects, test.ShouldEqual, 1)
+		test.That(t, rp.Stats().USBResets, test.ShouldEqual, 0)
+
+		// The cached identity describes the unit reconnected to.
+		test.That(t, rp.DeviceInfo(), test.ShouldResemble, DeviceInfo{
+			Model: "A1", ModelID: 24, Family: FamilyA, SerialNumber: "def", FirmwareVersion: "1.29", HardwareRevision: 7,
+		})
+		test.That(t, rp.ScanModes(), test.ShouldResemble, []ScanMode{"Boost"})
+		readings, err := rp.Readings(ctx, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, readings["serial_number"], test.ShouldEqual, "def")
+		test.That(t, readings["health"], test.ShouldEqual, healthStatusToString(1))
+	})
+
+	t.Run("resets the USB device when reconnecting keeps failing", func(t *testing.T) {
+		var events []string
+		connectErr := errors.New("no device")
+		rp := &rplidar{
+			device: &rplidarDevice{
+				driver: newDriver(&events, "old"), serialNumber: "abc",
+				firmwareVersionRaw: 1<<8 | 29, expressProtocol: expressProtocolStandard,
+			},
+			expressProtocol: expressProtocolStandard,
+			motorControl:    motorControlExternal,
+			reconnector:     newReconnector(1, 2),
+			connectDevice: func() (*rplidarDevice, error) {
+				if connectErr != nil {
+					return nil, connectErr
+				}
+				return &rplidarDevice{driver: newDriver(&events, "new"), serialNumber: "abc"}, nil
+			},
+			resetUSB: func(ctx context.Context) error {
+				events = append(events, "reset")
+				connectErr = nil
+				return nil
+			},
+			nodes:  gen.New_measurementNodeHqArray(defaultNodeSize),
+			clock:  newFakeClock(),
+			logger: logging.NewTestLogger(t),
+		}
+
+		rp.setState(StateScanning)
+		rp.checkReconnect(ctx, errScan)
+		test.That(t, events, test.ShouldResemble, []string{"stop old", "disconnect old"})
+		test.That(t, rp.Stats().Reconnects, test.ShouldEqual, 0)
+		test.That(t, rp.State(), test.ShouldEqual, StateReconnecting)
+
+		events = nil
+		rp.checkReconnect(ctx, errScan)
+		test.That(t, events, test.ShouldResemble, []string{
+			"stop old", "disconnect old", "reset", "stop old", "disconnect old", "start new",
+		})
+		test.That(t, rp.Stats().Reconnects, test.ShouldEqual, 1)
+		test.That(t, rp.Stats().USBResets, test.ShouldEqual, 1)
+		test.That(t, rp.State(), test.ShouldEqual, StateScanning)
+	})
+}
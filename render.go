@@ -0,0 +1,52 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+const (
+	// The scale of the top-down render served as the camera's image, in pixels per meter of the scan plane.
+	defaultRenderPxPerMeter = 40.
+	// The width and height in pixels of the top-down render served as the camera's image, covering 20m across.
+	defaultRenderSize = 800
+)
+
+// RenderTopDown rasterizes scan into a size by size top-down occupancy image, centered on the sensor, with
+// pxPerMeter pixels per meter. Occupied pixels are black on a white background of an *image.RGBA, matching the
+// color stream the camera reports in its properties. The X axis of the scan points to the right of the image and
+// the Y axis to the top; points outside of the image are left out.
+func RenderTopDown(scan pointcloud.PointCloud, pxPerMeter float64, size int) (image.Image, error) {
+	if scan == nil {
+		return nil, errors.New("no point cloud to render")
+	}
+	if pxPerMeter <= 0 {
+		return nil, errors.New("pxPerMeter must be positive")
+	}
+	if size <= 0 {
+		return nil, errors.New("size must be positive")
+	}
+
+	// Every channel at full scale is opaque white.
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+
+	center := float64(size) / 2
+	scan.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		// Positions are in millimeters and the image's Y axis points down.
+		x := int(center + p.X/1000*pxPerMeter)
+		y := int(center - p.Y/1000*pxPerMeter)
+		if image.Pt(x, y).In(img.Rect) {
+			img.SetRGBA(x, y, color.RGBA{A: 255})
+		}
+		return true
+	})
+	return img, nil
+}
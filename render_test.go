@@ -0,0 +1,49 @@
+package rplidar
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestRenderTopDown(t *testing.T) {
+	scan := pointcloud.New()
+	test.That(t, scan.Set(r3.Vector{X: 1000, Y: 0}, nil), test.ShouldBeNil)
+	test.That(t, scan.Set(r3.Vector{X: 0, Y: 2000}, nil), test.ShouldBeNil)
+	test.That(t, scan.Set(r3.Vector{X: -100000, Y: 0}, nil), test.ShouldBeNil)
+
+	t.Run("points are drawn around the center", func(t *testing.T) {
+		img, err := RenderTopDown(scan, 10, 100)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, img.Bounds(), test.ShouldResemble, image.Rect(0, 0, 100, 100))
+
+		rgba := img.(*image.RGBA)
+		black, white := color.RGBA{A: 255}, color.RGBA{R: 255, G: 255, B: 255, A: 255}
+		test.That(t, rgba.RGBAAt(60, 50), test.ShouldResemble, black)
+		test.That(t, rgba.RGBAAt(50, 30), test.ShouldResemble, black)
+		test.That(t, rgba.RGBAAt(50, 50), test.ShouldResemble, white)
+
+		var occupied int
+		for y := 0; y < 100; y++ {
+			for x := 0; x < 100; x++ {
+				if rgba.RGBAAt(x, y) == black {
+					occupied++
+				}
+			}
+		}
+		test.That(t, occupied, test.ShouldEqual, 2)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := RenderTopDown(nil, 10, 100)
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = RenderTopDown(scan, 0, 100)
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = RenderTopDown(scan, 10, 0)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
@@ -1,13 +1,25 @@
 // Package rplidar implements a general rplidar LIDAR as a camera.
 package rplidar
 
-import "go.viam.com/rplidar/gen"
+import (
+	"errors"
+	"fmt"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rplidar/gen"
+)
+
+// ErrSerialTimeout is wrapped by the errors returned when the device does not answer a request or deliver a
+// revolution within the configured serial_timeout_ms, e.g. because of a flaky cable.
+var ErrSerialTimeout = errors.New("timed out waiting for the rplidar")
 
 type (
-	// Result describes the status of an rplidar operation.
+	// Result describes the status of an rplidar operation. It is the raw numeric result code of the SDK, whose
+	// highest bit is set for failures, e.g. 0x80008002 for a timed out operation.
 	Result uint32
 
-	// ResultError is a result that encodes an error.
+	// ResultError is a result that encodes an error. Its Result is the raw SDK result code, for matching against
+	// the vendor's documentation.
 	ResultError struct {
 		Result
 	}
@@ -56,11 +68,35 @@ func (r Result) String() string {
 	case ResultInsufficientMemory:
 		return "InsufficientMemory"
 	default:
-		return "Unknown"
+		return fmt.Sprintf("Unknown(0x%08X)", uint32(r))
 	}
 }
 
+// Is reports whether the result error matches target, making timed out operations match ErrSerialTimeout.
+func (r ResultError) Is(target error) bool {
+	return target == ErrSerialTimeout && r.Result == ResultOpTimeout
+}
+
 // Error returns the error as a human readable string.
 func (r ResultError) Error() string {
 	return r.String()
 }
+
+// logResultCode logs the raw SDK result code of the ResultError err wraps, if any, along with err. It is meant for
+// matching an intermittent failure against the vendor's documentation, see log_result_codes.
+func logResultCode(logger logging.Logger, err error) {
+	var resultErr ResultError
+	if !errors.As(err, &resultErr) {
+		return
+	}
+	code := uint32(resultErr.Result)
+	logger.Infof("rplidar SDK result code 0x%08X (code 0x%04X, fail bit %v) for: %v",
+		code, code&^uint32(gen.RESULT_FAIL_BIT), resultErr.Failed() != nil, err)
+}
+
+// logResultCode logs the raw SDK result code err wraps if log_result_codes is enabled.
+func (rp *rplidar) logResultCode(err error) {
+	if rp.logResultCodes {
+		logResultCode(rp.logger, err)
+	}
+}
@@ -0,0 +1,46 @@
+package rplidar
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+func TestResultError(t *testing.T) {
+	err := fmt.Errorf("bad scan: %w", ResultOpTimeout.Failed())
+	test.That(t, err.Error(), test.ShouldEqual, "bad scan: OpTimeout")
+	test.That(t, errors.Is(err, ErrSerialTimeout), test.ShouldBeTrue)
+
+	// Programmatic callers get the raw SDK result code from the error.
+	var resultErr ResultError
+	test.That(t, errors.As(err, &resultErr), test.ShouldBeTrue)
+	test.That(t, uint32(resultErr.Result), test.ShouldEqual, 0x80008002)
+
+	// Codes without a name keep their number in the message.
+	test.That(t, Result(0x80008009).Failed().Error(), test.ShouldEqual, "Unknown(0x80008009)")
+	test.That(t, ResultAlreadyDone.Failed(), test.ShouldBeNil)
+}
+
+func TestLogResultCodes(t *testing.T) {
+	err := fmt.Errorf("bad scan: %w", ResultOpTimeout.Failed())
+
+	t.Run("disabled by default", func(t *testing.T) {
+		logger, logs := logging.NewObservedTestLogger(t)
+		rp := &rplidar{logger: logger}
+		rp.logResultCode(err)
+		test.That(t, logs.Len(), test.ShouldEqual, 0)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		logger, logs := logging.NewObservedTestLogger(t)
+		rp := &rplidar{logger: logger, logResultCodes: true}
+		rp.logResultCode(err)
+		rp.logResultCode(errors.New("bad health"))
+		test.That(t, logs.Len(), test.ShouldEqual, 1)
+		test.That(t, logs.All()[0].Message, test.ShouldEqual,
+			"rplidar SDK result code 0x80008002 (code 0x8002, fail bit true) for: bad scan: OpTimeout")
+	})
+}
@@ -0,0 +1,78 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// RotationSense is the direction in which an rplidar's angles increase, seen from above the device.
+type RotationSense string
+
+const (
+	// RotationClockwise is the sense of angles that increase clockwise.
+	RotationClockwise RotationSense = "clockwise"
+	// RotationCounterclockwise is the sense of angles that increase counterclockwise.
+	RotationCounterclockwise RotationSense = "counterclockwise"
+)
+
+// normalizedRotationSense is the sense every scan is normalized to before any attribute interprets its angles, so
+// that angle_offset_deg, blank_sectors and the other angles of the config mean the same on every model.
+const normalizedRotationSense = RotationClockwise
+
+// nativeRotationSenseByModel is the sense each known model reports its angles in, according to its protocol
+// documentation. Models missing here, and devices configured with rotation_sense, use their own.
+var nativeRotationSenseByModel = map[RPLiDARModel]RotationSense{
+	A1: RotationClockwise,
+	A3: RotationClockwise,
+	S1: RotationClockwise,
+}
+
+// parseRotationSense returns the rotation sense configured as rotation_sense, or the empty sense if none is
+// configured.
+func parseRotationSense(sense string) (RotationSense, error) {
+	switch RotationSense(sense) {
+	case "", RotationClockwise, RotationCounterclockwise:
+		return RotationSense(sense), nil
+	default:
+		return "", errors.Errorf("rotation_sense must be %q or %q", RotationClockwise, RotationCounterclockwise)
+	}
+}
+
+// nativeRotationSense returns the sense a device of model reports its angles in: configured if not empty, the
+// documented sense of model otherwise, and the normalized sense for a model without one.
+func nativeRotationSense(model RPLiDARModel, configured RotationSense) RotationSense {
+	if configured != "" {
+		return configured
+	}
+	if sense, ok := nativeRotationSenseByModel[model]; ok {
+		return sense
+	}
+	return normalizedRotationSense
+}
+
+// normalizeRotation converts measurements of a revolution taken in the native sense to the normalized sense in
+// place, treating the empty sense as the normalized one. Mirrored angles decrease, so the measurements are sorted
+// by angle again afterwards.
+func normalizeRotation(measurements []measurement, native RotationSense) []measurement {
+	if native == "" || native == normalizedRotationSense {
+		return measurements
+	}
+	for i := range measurements {
+		measurements[i].angleDeg = normalizeAngleDeg(360 - measurements[i].angleDeg)
+	}
+	sort.SliceStable(measurements, func(i, j int) bool {
+		return measurements[i].angleDeg < measurements[j].angleDeg
+	})
+	return measurements
+}
+
+// RotationSense returns the sense the connected rplidar reports its angles in and the sense they are normalized to
+// before any attribute applies, which is always clockwise.
+func (rp *rplidar) RotationSense() (native, normalized RotationSense) {
+	if rp.nativeRotationSense == "" {
+		return normalizedRotationSense, normalizedRotationSense
+	}
+	return rp.nativeRotationSense, normalizedRotationSense
+}
@@ -0,0 +1,46 @@
+package rplidar
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestNativeRotationSense(t *testing.T) {
+	for _, model := range []RPLiDARModel{A1, A3, S1} {
+		test.That(t, nativeRotationSense(model, ""), test.ShouldEqual, RotationClockwise)
+		test.That(t, nativeRotationSense(model, RotationCounterclockwise), test.ShouldEqual, RotationCounterclockwise)
+	}
+	test.That(t, nativeRotationSense(RPLiDARModel(42), ""), test.ShouldEqual, normalizedRotationSense)
+}
+
+func TestNormalizeRotation(t *testing.T) {
+	measurements := func() []measurement {
+		return []measurement{
+			{angleDeg: 0, distanceMM: 1000},
+			{angleDeg: 10, distanceMM: 1100},
+			{angleDeg: 90, distanceMM: 1200},
+			{angleDeg: 350, distanceMM: 1300},
+		}
+	}
+
+	test.That(t, normalizeRotation(measurements(), RotationClockwise), test.ShouldResemble, measurements())
+	test.That(t, normalizeRotation(measurements(), ""), test.ShouldResemble, measurements())
+	test.That(t, normalizeRotation(measurements(), RotationCounterclockwise), test.ShouldResemble, []measurement{
+		{angleDeg: 0, distanceMM: 1000},
+		{angleDeg: 10, distanceMM: 1300},
+		{angleDeg: 270, distanceMM: 1200},
+		{angleDeg: 350, distanceMM: 1100},
+	})
+
+	t.Run("decoded before the filters", func(t *testing.T) {
+		// A counterclockwise return 90 degrees from the 0 mark is 270 degrees clockwise from it.
+		nodes := []rawNode{{angleQ14: 1 << 14, distQ2: 4000, quality: 40}}
+		rp := rplidar{nodes: nodesOf(nodes), nativeRotationSense: RotationCounterclockwise}
+		test.That(t, rp.decodeNodes(1), test.ShouldResemble, []measurement{{angleDeg: 270, distanceMM: 1000, quality: 40}})
+
+		native, normalized := rp.RotationSense()
+		test.That(t, native, test.ShouldEqual, RotationCounterclockwise)
+		test.That(t, normalized, test.ShouldEqual, RotationClockwise)
+	})
+}
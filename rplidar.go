@@ -4,6 +4,8 @@ package rplidar
 import (
 	"context"
 	"fmt"
+	"image"
+	"math"
 	"os"
 	"sort"
 	"strings"
@@ -14,10 +16,9 @@ import (
 
 	ps "github.com/mitchellh/go-ps"
 
-	goutils "go.viam.com/utils"
-
 	"github.com/golang/geo/r3"
 	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
 	"go.viam.com/rdk/components/camera"
 	"go.viam.com/rdk/gostream"
 	"go.viam.com/rdk/logging"
@@ -27,6 +28,7 @@ import (
 	"go.viam.com/rdk/spatialmath"
 	"go.viam.com/rdk/utils"
 	rputils "go.viam.com/rplidar/utils"
+	goutils "go.viam.com/utils"
 )
 
 // RPLiDARModel represents the model of rplidar being used
@@ -35,20 +37,29 @@ import (
 type RPLiDARModel int64
 
 const (
-	// The max time in milliseconds it should take for the RPlidar to get scan data.
+	// The max time in milliseconds it should take for the RPlidar to get scan data, unless configured otherwise.
+	// A single revolution takes 200ms at the slowest motor speed, so this leaves ample margin for any scan mode.
 	defaultDeviceTimeoutMs = uint(1000)
 	// The number of full 360 scans to complete before returning a point cloud.
 	defaultNumScans = 1
-	// The number of scans to discard at startup to ensure valid data is returned to the user.
+	// The number of scans to discard at startup to ensure valid data is returned to the user, unless configured
+	// otherwise.
 	defaultWarmupNumDiscardedScans = 5
 	// The number of max nodes or data points returned in each scan.
 	defaultNodeSize = 8192
 	// The amount of time to wait after the motor start before scanning can begin.
 	defaultWarmUpTimeout = time.Second
+	// The angular width in degrees of each partial arc handed to a partial scan callback.
+	defaultPartialScanArcDeg = 45.
+	// The number of consecutive overflowing grabs tolerated in a single scan before giving up on it.
+	defaultMaxOverflowRetries = 3
+	// The number of partial arcs that can be queued for a slow partial scan callback before new ones are dropped.
+	defaultPartialScanQueueSize = 16
+	// The number of grabs that can be queued for a slow measurements callback before new ones are dropped.
+	defaultMeasurementQueueSize = 16
 
 	rplidarModuleLockDir      = "/tmp/"
 	rplidarModuleLockFileName = "rplidar_pid%v_dv%v.lock"
-	devicePathPrefixOffset    = len(`\dev\`)
 
 	// A1 rplidar model
 	A1 RPLiDARModel = iota
@@ -58,6 +69,20 @@ const (
 	S1
 )
 
+const (
+	// rightHanded is the default coordinate convention of the returned point clouds.
+	rightHanded = "right"
+	// leftHanded mirrors the returned point clouds across the X axis, i.e. every point's Y value is negated.
+	leftHanded = "left"
+)
+
+const (
+	// motorControlSDK starts and stops the motor through the SDK, except for S1 rplidars whose motor always runs.
+	motorControlSDK = "sdk"
+	// motorControlExternal leaves the motor alone, assuming it is powered and driven by separate hardware.
+	motorControlExternal = "external"
+)
+
 var (
 	// Model is the model of the RPLiDAR
 	Model = resource.NewModel("viam", "lidar", "rplidar")
@@ -81,10 +106,103 @@ func modelToString(model RPLiDARModel) string {
 	return "unsupported model"
 }
 
+// The policies for a device reporting a model ID that is not in rplidarModelByteMap.
+const (
+	// unknownModelWarn logs a warning and proceeds as if the device was an A1.
+	unknownModelWarn = "warn"
+	// unknownModelError refuses to use the device.
+	unknownModelError = "error"
+	// unknownModelAssume proceeds as if the device was the model following it, ex. "assume S1".
+	unknownModelAssume = "assume"
+)
+
+// parseUnknownModelPolicy parses an on_unknown_model policy, returning the policy and the model to assume for
+// an unknown device. An empty policy is the same as "warn".
+func parseUnknownModelPolicy(policy string) (string, RPLiDARModel, error) {
+	fields := strings.Fields(policy)
+	switch {
+	case len(fields) == 0:
+		return unknownModelWarn, A1, nil
+	case len(fields) == 1 && (fields[0] == unknownModelWarn || fields[0] == unknownModelError):
+		return fields[0], A1, nil
+	case len(fields) == 2 && fields[0] == unknownModelAssume:
+		for _, model := range []RPLiDARModel{A1, A3, S1} {
+			if fields[1] == modelToString(model) {
+				return unknownModelAssume, model, nil
+			}
+		}
+	}
+	return "", A1, errors.Errorf("on_unknown_model must be one of %q, %q or %q followed by A1, A3 or S1",
+		unknownModelWarn, unknownModelError, unknownModelAssume)
+}
+
+// PartialScanNotifier is implemented by the rplidar camera. It allows callers that hold the camera to observe
+// each scan in arcs as it is being assembled, e.g. to render a sweep, in addition to the complete point cloud
+// returned by NextPointCloud. The SDK only hands over complete revolutions, so the arcs of a revolution are all
+// delivered once it was grabbed, up to a full rotation period after its first arc was scanned, just before the
+// complete point cloud. They do not lower the latency.
+type PartialScanNotifier interface {
+	// OnPartialScan registers fn to be called with every partial arc of new points, in ascending angle order,
+	// as a scan is assembled. Arcs are delivered from a separate goroutine; if fn falls behind, arcs are
+	// dropped rather than delaying the scan. Passing nil unregisters the callback.
+	OnPartialScan(fn func(pointcloud.PointCloud))
+}
+
+// MeasurementNotifier is implemented by the rplidar camera. It is the lowest latency hook into the scan pipeline,
+// for callers that process the raw returns themselves.
+type MeasurementNotifier interface {
+	// OnMeasurements registers fn to be called with the valid returns of every grab from the device, as soon as
+	// they are decoded and before the out of order policy, any filter, or the assembly of the scan. Grabs are
+	// delivered in the order they were taken, each in ascending angle order, from a separate goroutine; if fn
+	// falls behind, grabs are dropped rather than delaying scanning, and counted in MeasurementDrops of Stats.
+	// Every grab is passed in a slice of its own. Passing nil unregisters the callback, as does closing the camera.
+	OnMeasurements(fn func([]Measurement))
+}
+
 // dataCache stores pointcloud data returned from the RPLiDAR for later access. This data is under mutex protection.
 type dataCache struct {
 	mutex      sync.RWMutex
 	pointCloud pointcloud.PointCloud
+	// The unfiltered point cloud of the same revolutions, only stored when keep_raw_scans is enabled.
+	rawPointCloud pointcloud.PointCloud
+	// The decimated point cloud of the same revolutions, only stored when preview_decimation is set.
+	previewPointCloud pointcloud.PointCloud
+	// The valid returns of the last revolution of the same scan before filtering, see MeasureAtAngle.
+	measurements []Measurement
+	meta         ScanMeta
+	// Closed and reset whenever a new scan is stored, to wake up callers waiting for it.
+	updated chan struct{}
+	// Receive every new scan, see subscribe.
+	subscribers map[chan cachedScan]struct{}
+}
+
+// ScanMeta describes the most recent scan stored in the cache.
+type ScanMeta struct {
+	// Seq counts the scans stored in the cache, starting at 1 for the first one.
+	Seq uint64
+	// Timestamp is the time the scan was completed.
+	Timestamp time.Time
+	// PointCount is the number of points in the scan's point cloud, after filtering.
+	PointCount int
+	// AngularResolutionDeg is the measured angle between consecutive samples, derived from the number of
+	// samples the device reported per revolution, including samples without a valid distance.
+	AngularResolutionDeg float64
+	// Coverage is the fraction of the revolution with at least one valid return beyond the near range, before
+	// any filtering.
+	Coverage float64
+	// BlockedFraction is the fraction of the revolution where every valid return was within the near range,
+	// which is what a blocked lens looks like.
+	BlockedFraction float64
+	// DroppedPoints is the number of samples each stage between the device and the point cloud removed: "invalid"
+	// for samples without a valid distance or angle, followed by the enabled filters keyed by their attribute,
+	// e.g. "min_range_mm", "nearest_per_sector" and "target_points_per_sec".
+	DroppedPoints map[string]int
+	// InterArrival is the wall clock time since the previous scan arrived from the device, zero for the first scan
+	// and the first one after a failed scan.
+	InterArrival time.Duration
+	// ArrivalJitter is the standard deviation of the last 32 inter-arrival times, which is well below a
+	// millisecond unless the host delivers scans unevenly, e.g. because of how it schedules USB transfers.
+	ArrivalJitter time.Duration
 }
 
 // rplidar contains the connection, filters and data cached used to interface with an RPLiDAR device.
@@ -96,18 +214,275 @@ type rplidar struct {
 	device       *rplidarDevice
 	nodes        gen.Rplidar_response_measurement_node_hq_t
 	minRangeMM   float64
+	// Whether to drop returns with a distance but a quality of zero, see KeepZeroQuality.
+	dropZeroQuality bool
+	// What to do with samples whose angle steps backward, see OutOfOrderPolicy.
+	outOfOrderPolicy string
+	// The sense the device reports its angles in, normalized to normalizedRotationSense when decoding them.
+	nativeRotationSense RotationSense
+	blankBelowMM        float64
+	blankSectors        []BlankSector
+	// Degrees added to every measured angle before it is converted to a point.
+	angleOffsetDeg float64
+	handedness     string
+	// Remaps the axes of every point after it is converted, see AxisMap.
+	axisMap *axisMap
+	// Added to every point after it is converted, see OriginOffset.
+	originOffset r3.Vector
+	// Moves the start of every revolution to start_angle_deg, nil if not configured.
+	startAligner *startAngleAligner
+	// Moves the samples of revolutions longer than their scan mode allows to the next one, nil if not configured.
+	overlongTrimmer *overlongTrimmer
+	// The number of sectors each revolution is divided into when keeping only the nearest return per sector.
+	nearestPerSector int
+	// The number of bins each revolution is smoothed into, the width of their windows and how they are combined.
+	smoothingBins      int
+	smoothingWindowDeg float64
+	smoothingMethod    string
+	sortByAngle        bool
+	qualityEncoding    string
+	// The point cloud implementation scans are built with.
+	pointCloudBackend string
+	keepRawScans      bool
+	previewDecimation int
+	blackBox          *blackBox
+	rateThinner       *rateThinner
+	fractionThinner   *fractionThinner
+	// The express protocol requested in the config; the protocol actually in use is kept on the device.
+	expressProtocol string
+	forceScan       bool
+	probeModes      bool
+	motorControl    string
+	motorRamp       time.Duration
+	// The number of scans discarded every time scanning is started.
+	discardFirstScans int
+	coverageNearMM    float64
+	minCoverage       float64
+	// The state of the coverage of the previous scan, only accessed by the scan loop.
+	coverageState string
+	// Times the arrivals of scans in the scan loop, flagged when their jitter exceeds maxArrivalJitterMs.
+	arrivals           arrivalTimer
+	maxArrivalJitterMs float64
+	degrader           *scanDegrader
+	reconnector        *reconnector
+	state              stateMachine
+	// Connect to the device again, and reset its USB device, for the reconnector.
+	connectDevice func() (*rplidarDevice, error)
+	resetUSB      func(ctx context.Context) error
+	// The serial path the device was connected at, and the state file the last good state is saved to along with
+	// what was last saved, see StateFile.
+	devicePath    string
+	stateFilePath string
+	lastGood      lastGoodState
 
 	cancelFunc             func()
 	cacheBackgroundWorkers sync.WaitGroup
-	cache                  *dataCache
+	// Set when auto_start is disabled. backgroundCtx holds the context to start the scan loop with on the first
+	// scan request, and after a standby. Whether the scan loop runs, whether it was put in standby and the
+	// sequence number of the last point cloud before it was last started are guarded by startMutex.
+	deferredStart   bool
+	startMutex      sync.Mutex
+	backgroundCtx   context.Context
+	running         bool
+	standbyEntered  bool
+	startedAfterSeq uint64
+	// Asks the scan loop to enter a warm standby and stop, see Standby.
+	standbyRequests chan chan error
+	cache           *dataCache
+	scanInterval    *scanIntervalLimiter
+	// Picks the revolution NextPointCloud returns when trigger_mode is external, nil if free running.
+	scanTrigger  *scanTrigger
+	partialScans *asyncNotifier[pointcloud.PointCloud]
+	// The CPUs the scan loop is pinned to, see GrabberCPUs, and the warning about failing to pin it.
+	grabberCPUs     []int
+	affinityWarning sync.Once
+	// Receives the valid returns of every grab, see OnMeasurements.
+	rawMeasurements *asyncNotifier[[]Measurement]
+	scanGate        scanGate
+	stats           scanStats
+	faults          faultInjector
+	// Whether to log the raw SDK result code of every failure, see LogResultCodes.
+	logResultCodes bool
+	// The clock of the scan timestamps, the scan rate and every wait, the real one if nil.
+	clock clock
 
 	logger logging.Logger
 }
 
 // Config describes how to configure the RPLiDAR component.
 type Config struct {
-	SerialPath string  `json:"serial_path"`
-	MinRangeMM float64 `json:"min_range_mm"`
+	SerialPath string `json:"serial_path"`
+	// DeviceIndex selects which of the detected rplidars to use when no serial path is given, counting the
+	// devices in order of their path. Defaults to the first one.
+	DeviceIndex int     `json:"device_index"`
+	MinRangeMM  float64 `json:"min_range_mm"`
+	// BlankBelowMM drops returns closer than this range, like min_range_mm, but only within BlankSectors, to
+	// suppress reflections off the mount without losing near returns elsewhere. Both must be set together.
+	BlankBelowMM float64       `json:"blank_below_mm"`
+	BlankSectors []BlankSector `json:"blank_sectors"`
+	// Handedness selects the coordinate convention of the returned point clouds, either "right" (default)
+	// or "left". A left-handed point cloud is the right-handed one with the sign of every Y value flipped.
+	Handedness string `json:"handedness"`
+	// ExpressProtocol overrides the express scan protocol selected by the SDK: "auto" (default), "legacy" or
+	// "extended". Forcing a protocol the device handles poorly can result in fewer or invalid samples.
+	ExpressProtocol string `json:"express_protocol"`
+	// ForceScan starts a standard scan with the SDK's force scan command, which makes the device send data even if
+	// it does not detect the motor rotating. The data is invalid unless the motor is actually spinning.
+	ForceScan bool `json:"force_scan"`
+	// MotorControl is either "sdk" (default), starting and stopping the motor through the SDK, or "external",
+	// never touching the motor and assuming it is kept spinning by separate hardware.
+	MotorControl string `json:"motor_control"`
+	// MotorRampMs is the time in milliseconds over which the motor's PWM is raised from 0 to its target when
+	// starting, on devices supporting motor speed control. Zero starts the motor at full speed.
+	MotorRampMs int `json:"motor_ramp_ms"`
+	// DegradeAfterErrors is the number of consecutive failed scans, e.g. because of overflows, after which scanning
+	// steps down from the extended to the legacy and then to the standard protocol. Zero disables it.
+	DegradeAfterErrors int `json:"degrade_after_errors"`
+	// DegradeRecoverMs is the time in milliseconds without failed scans after which scanning steps back up to the
+	// protocol it stepped down from. Zero never steps back up.
+	DegradeRecoverMs int `json:"degrade_recover_ms"`
+	// ReconnectAfterErrors is the number of consecutive failed scans after which the device is reconnected and
+	// scanning restarted. Zero disables it.
+	ReconnectAfterErrors int `json:"reconnect_after_errors"`
+	// USBResetOnFailure resets the USB device of the rplidar, and only that, when reconnecting failed
+	// USBResetAfterAttempts times in a row. It requires write access to the device's authorized attribute in sysfs.
+	USBResetOnFailure bool `json:"usb_reset_on_failure"`
+	// USBResetAfterAttempts is the number of consecutive failed reconnect attempts before the USB device is reset.
+	// Defaults to 3.
+	USBResetAfterAttempts int `json:"usb_reset_after_attempts"`
+	// RotationPeriodWindow is the number of recent revolutions the rotation period and the scan rate are
+	// averaged over. Defaults to 10.
+	RotationPeriodWindow int `json:"rotation_period_window"`
+	// PointCloudBackend selects the point cloud implementation scans are built with: "basic" (default), "kdtree"
+	// or "rounding".
+	PointCloudBackend string `json:"pointcloud_backend"`
+	// AngleOffsetDeg is added to every angle the rplidar measures, clockwise like its own angles, to correct for
+	// how it is mounted. CalibrateAngleOffset suggests a value.
+	AngleOffsetDeg float64 `json:"angle_offset_deg"`
+	// StartAngleDeg moves the boundary between consecutive scans to this angle, from 0 up to 360 in the rplidar's
+	// own angles before AngleOffsetDeg, so that every scan starts at the same angle. Defaults to the end of the
+	// revolution reported by the device.
+	StartAngleDeg *float64 `json:"start_angle_deg,omitempty"`
+	// OverlongScanMargin is the fraction, e.g. 0.1 for 10%, by which the samples of a revolution may exceed the
+	// number its scan mode delivers in one measured rotation period before the ones from where the angles wrap
+	// back to 0 degrees are moved to the next scan, since the SDK merged them in from the next revolution. Zero
+	// disables it.
+	OverlongScanMargin float64 `json:"overlong_scan_margin"`
+	// OutOfOrderPolicy is what to do with a sample whose angle steps backward from the previous one within a
+	// revolution: "drop" (default), "clamp" to the previous angle, or "keep".
+	OutOfOrderPolicy string `json:"out_of_order_policy"`
+	// KeepZeroQuality keeps returns that have a distance but a quality of zero, which some models report for
+	// synthetic or interpolated samples. Defaults to true.
+	KeepZeroQuality *bool `json:"keep_zero_quality,omitempty"`
+	// OriginOffset translates every point, in millimeters, after the angle offset and the handedness are applied,
+	// to place the sensor at that point of the point cloud's frame.
+	OriginOffset *OriginOffset `json:"origin_offset,omitempty"`
+	// AxisMap remaps the axes of every point after the angle offset and the handedness are applied and before the
+	// origin offset, as the axes x, y and z become, ex. "y,-x,z" for x to become y and y to become -x. Defaults to
+	// "x,y,z".
+	AxisMap string `json:"axis_map,omitempty"`
+	// KeepRawScans additionally keeps the point cloud of every scan before filtering, returned together with the
+	// filtered one by NextPointCloudPair.
+	KeepRawScans bool `json:"keep_raw_scans"`
+	// PreviewDecimation additionally builds a preview point cloud of every scan from every PreviewDecimation-th
+	// point of the filtered one, returned by NextPreviewPointCloud. 0 disables it.
+	PreviewDecimation int `json:"preview_decimation"`
+	// ProbeModes briefly scans in every supported scan mode while the camera is created and logs the sample rate
+	// each achieves, before scanning in the configured mode.
+	ProbeModes bool `json:"probe_modes"`
+	// ConstructTimeoutMs bounds the time constructing the camera may take in milliseconds, from connecting to the
+	// device to the end of the warmup. Defaults to a minute.
+	ConstructTimeoutMs int `json:"construct_timeout_ms"`
+	// BlackBoxPath is the path of a fixed size ring file the raw measurements of every revolution are continuously
+	// written to, overwriting the oldest ones, for inspecting what the rplidar saw before a failure. Read it back
+	// with ReadBlackBox. Empty disables it.
+	BlackBoxPath string `json:"black_box_path"`
+	// BlackBoxSizeMB is the size of the black box ring file in megabytes. Defaults to 16.
+	BlackBoxSizeMB int `json:"black_box_size_mb"`
+	// NearestPerSector divides each revolution into this many equally sized sectors and keeps only the
+	// closest return of each sector, producing a compact, obstacle focused point cloud. Zero disables it.
+	NearestPerSector int `json:"nearest_per_sector"`
+	// SmoothingBins divides each revolution into this many equally sized bins and replaces its returns with one
+	// per bin, whose distance is the median or mean of the returns within SmoothingWindowDeg of the bin's center.
+	// It reduces range noise at the cost of angular resolution. Zero disables it.
+	SmoothingBins int `json:"smoothing_bins"`
+	// SmoothingWindowDeg is the width in degrees of the window centered on every bin whose returns are smoothed.
+	// Defaults to the width of a bin.
+	SmoothingWindowDeg float64 `json:"smoothing_window_deg"`
+	// SmoothingMethod is how the distances within a window are combined: "median" (default) or "mean".
+	SmoothingMethod string `json:"smoothing_method"`
+	// SortByAngle orders the points of each revolution by ascending angle in [0, 360), starting at 0 degrees.
+	SortByAngle bool `json:"sort_by_angle"`
+	// QualityEncoding selects how the quality of each return is stored in the point cloud: "intensity", "value"
+	// or "rgb". Unset, the quality is not stored and every point has the full-scale intensity.
+	QualityEncoding string `json:"quality_encoding"`
+	// TargetPointsPerSec adaptively thins the scans so that the sustained output approaches this many points
+	// per second. Zero disables it.
+	TargetPointsPerSec float64 `json:"target_points_per_sec"`
+	// DecimationSeed seeds the randomness used when thinning scans, so the same seed and input always keep the
+	// same points, unless target_points_per_sec has to time scans on the host. Defaults to 0.
+	DecimationSeed int64 `json:"decimation_seed"`
+	// KeepFraction thins every scan to this fraction of its points, above 0 and at most 1, e.g. 0.25 to keep a
+	// quarter of them. It cannot be combined with TargetPointsPerSec. Defaults to 1, keeping every point.
+	KeepFraction *float64 `json:"keep_fraction,omitempty"`
+	// MinScanIntervalMs is the minimum time between distinct point clouds returned by NextPointCloud. Calls
+	// arriving sooner are handled according to ScanIntervalPolicy. Zero disables it.
+	MinScanIntervalMs int `json:"min_scan_interval_ms"`
+	// ScanIntervalPolicy is either "cached" (default), returning the previous point cloud again, or "block",
+	// waiting until the interval has passed and a newer point cloud is available.
+	ScanIntervalPolicy string `json:"scan_interval_policy"`
+	// TriggerMode is either "free_running" (default), NextPointCloud returning the most recent revolution, or
+	// "external", returning the revolution whose start is closest to the most recent sync trigger, see SyncTrigger.
+	// It cannot be combined with MinScanIntervalMs.
+	TriggerMode string `json:"trigger_mode"`
+	// BufferPolicy decides what happens to the grabs and partial scans arriving while the OnMeasurements or
+	// OnPartialScan callback is behind and its queue is full: "oldest" (default) drops the new ones, "latest" drops
+	// the oldest queued ones, and "block" holds up the scan loop until the callback catches up.
+	BufferPolicy string `json:"buffer_policy"`
+	// DiscardFirstScans is the number of revolutions dropped after scanning starts, before any point cloud is
+	// returned, since the first ones are often partial or noisy. Defaults to 5.
+	DiscardFirstScans *int `json:"discard_first_scans,omitempty"`
+	// AutoStart starts the motor and scanning when the camera is created. When false, the camera only connects to
+	// the rplidar and starts it on the first scan request or the "start" command. Defaults to true.
+	AutoStart *bool `json:"auto_start,omitempty"`
+	// MinCoverage is the fraction of the revolution, between 0 and 1, that must see returns beyond the near range
+	// before a warning is logged. Zero disables the warning.
+	MinCoverage float64 `json:"min_coverage"`
+	// CoverageNearMM is the range in millimeters within which returns are considered to come from something
+	// blocking the lens. Defaults to 100.
+	CoverageNearMM float64 `json:"coverage_near_mm"`
+	// MaxArrivalJitterMs is the ScanMeta.ArrivalJitter in milliseconds above which get_timing_health reports the
+	// timing of scans as unhealthy. Defaults to 10.
+	MaxArrivalJitterMs float64 `json:"max_arrival_jitter_ms"`
+	// SerialTimeoutMs is the time in milliseconds to wait for the device to answer a request or deliver a
+	// revolution before failing with an error wrapping ErrSerialTimeout. Defaults to 1000.
+	SerialTimeoutMs int `json:"serial_timeout_ms"`
+	// OnUnknownModel is the policy for a device reporting a model ID this module does not know: "warn"
+	// (default), proceeding as an A1, "error", refusing the device, or "assume <model>", ex. "assume S1",
+	// proceeding as the given model.
+	OnUnknownModel string `json:"on_unknown_model"`
+	// MinFirmwareVersion is the oldest firmware, as get_device_info reports it, ex. "1.29", the camera accepts;
+	// constructing it for an rplidar with older firmware fails with an error wrapping ErrFirmwareTooOld.
+	// Defaults to accepting any firmware.
+	MinFirmwareVersion string `json:"min_firmware_version,omitempty"`
+	// StateFile is the file the serial path, baud rate and express protocol the camera last scanned with are
+	// saved to, and preferred from on the next construction. Defaults to <user cache dir>/rplidar/<name>.json.
+	StateFile string `json:"state_file"`
+	// DisableStateFile disables saving and preferring the last good state.
+	DisableStateFile bool `json:"disable_state_file"`
+	// GrabberCPUs are the CPUs the thread scanning in the background is pinned to, e.g. to keep it off the cores
+	// of a real-time control loop. Only supported on Linux, elsewhere a warning is logged. Defaults to any CPU.
+	GrabberCPUs []int `json:"grabber_cpus,omitempty"`
+	// RotationSense overrides the sense the device reports its angles in, "clockwise" or "counterclockwise", for
+	// a model, clone or firmware reporting angles in another sense than documented. Scans are always
+	// normalized to clockwise angles. Defaults to the documented sense of the model.
+	RotationSense string `json:"rotation_sense"`
+	// LogResultCodes logs the raw numeric SDK result code, and its fail bit, alongside every error wrapping a
+	// ResultError, for matching intermittent failures against the vendor's documentation. Defaults to false.
+	LogResultCodes bool `json:"log_result_codes"`
+	// Profiles overrides the mounting related attributes per rplidar, keyed by its serial number as reported by
+	// get_device_info. The "default" profile applies to rplidars without a profile of their own.
+	Profiles map[string]Profile `json:"profiles,omitempty"`
 }
 
 // Validate checks that the config attributes are valid for an RPLiDAR.
@@ -117,6 +492,219 @@ func (conf *Config) Validate(path string) ([]string, error) {
 		return nil, errors.New("min_range must be positive")
 	}
 
+	if conf.BlankBelowMM < 0 {
+		return nil, errors.New("blank_below_mm must be positive")
+	}
+	if (conf.BlankBelowMM > 0) != (len(conf.BlankSectors) > 0) {
+		return nil, errors.New("blank_below_mm and blank_sectors must be set together")
+	}
+	for _, sector := range conf.BlankSectors {
+		if sector.StartDeg < 0 || sector.StartDeg >= 360 || sector.EndDeg < 0 || sector.EndDeg >= 360 {
+			return nil, errors.New("blank_sectors angles must be at least 0 and less than 360")
+		}
+	}
+
+	if conf.DeviceIndex < 0 {
+		return nil, errors.New("device_index must be positive")
+	}
+	if conf.DeviceIndex > 0 && conf.SerialPath != "" {
+		return nil, errors.New("device_index cannot be combined with serial_path")
+	}
+
+	if conf.Handedness != "" && conf.Handedness != rightHanded && conf.Handedness != leftHanded {
+		return nil, errors.Errorf("handedness must be either %q or %q", rightHanded, leftHanded)
+	}
+
+	if conf.NearestPerSector < 0 {
+		return nil, errors.New("nearest_per_sector must be positive")
+	}
+	if conf.SmoothingBins < 0 {
+		return nil, errors.New("smoothing_bins must be positive")
+	}
+	if conf.SmoothingWindowDeg < 0 || conf.SmoothingWindowDeg > 360 || math.IsNaN(conf.SmoothingWindowDeg) {
+		return nil, errors.New("smoothing_window_deg must be between 0 and 360")
+	}
+	switch conf.SmoothingMethod {
+	case "", smoothingMedian, smoothingMean:
+	default:
+		return nil, errors.Errorf("smoothing_method must be either %q or %q", smoothingMedian, smoothingMean)
+	}
+	if (conf.SmoothingWindowDeg > 0 || conf.SmoothingMethod != "") && conf.SmoothingBins == 0 {
+		return nil, errors.New("smoothing_window_deg and smoothing_method require smoothing_bins")
+	}
+
+	if conf.TargetPointsPerSec < 0 {
+		return nil, errors.New("target_points_per_sec must be positive")
+	}
+	if conf.KeepFraction != nil {
+		if *conf.KeepFraction <= 0 || *conf.KeepFraction > 1 {
+			return nil, errors.New("keep_fraction must be above 0 and at most 1")
+		}
+		if conf.TargetPointsPerSec > 0 {
+			return nil, errors.New("keep_fraction and target_points_per_sec cannot be set together")
+		}
+	}
+
+	switch conf.ExpressProtocol {
+	case "", expressProtocolAuto, expressProtocolLegacy, expressProtocolExtended:
+	default:
+		return nil, errors.Errorf("express_protocol must be one of %q, %q or %q",
+			expressProtocolAuto, expressProtocolLegacy, expressProtocolExtended)
+	}
+	if conf.ForceScan && conf.ExpressProtocol != "" && conf.ExpressProtocol != expressProtocolAuto {
+		return nil, errors.New("force_scan cannot be combined with an express_protocol")
+	}
+
+	if conf.MotorControl != "" && conf.MotorControl != motorControlSDK && conf.MotorControl != motorControlExternal {
+		return nil, errors.Errorf("motor_control must be either %q or %q", motorControlSDK, motorControlExternal)
+	}
+
+	if conf.MotorRampMs < 0 {
+		return nil, errors.New("motor_ramp_ms must be positive")
+	}
+
+	if conf.DegradeAfterErrors < 0 {
+		return nil, errors.New("degrade_after_errors must be positive")
+	}
+	if conf.DegradeRecoverMs < 0 {
+		return nil, errors.New("degrade_recover_ms must be positive")
+	}
+	if conf.DegradeAfterErrors > 0 && conf.ForceScan {
+		return nil, errors.New("degrade_after_errors cannot be combined with force_scan")
+	}
+
+	if conf.ReconnectAfterErrors < 0 {
+		return nil, errors.New("reconnect_after_errors must be positive")
+	}
+	if conf.USBResetAfterAttempts < 0 {
+		return nil, errors.New("usb_reset_after_attempts must be positive")
+	}
+	if conf.USBResetOnFailure && conf.ReconnectAfterErrors == 0 {
+		return nil, errors.New("usb_reset_on_failure requires reconnect_after_errors")
+	}
+	if conf.USBResetAfterAttempts > 0 && !conf.USBResetOnFailure {
+		return nil, errors.New("usb_reset_after_attempts requires usb_reset_on_failure")
+	}
+
+	if conf.ProbeModes && conf.ForceScan {
+		return nil, errors.New("probe_modes cannot be combined with force_scan")
+	}
+
+	if conf.BlackBoxSizeMB < 0 {
+		return nil, errors.New("black_box_size_mb must be positive")
+	}
+	if conf.BlackBoxSizeMB > 0 && conf.BlackBoxPath == "" {
+		return nil, errors.New("black_box_size_mb requires black_box_path")
+	}
+
+	if conf.StartAngleDeg != nil && (*conf.StartAngleDeg < 0 || *conf.StartAngleDeg >= 360) {
+		return nil, errors.New("start_angle_deg must be at least 0 and below 360")
+	}
+	if conf.AngleOffsetDeg < -180 || conf.AngleOffsetDeg > 180 {
+		return nil, errors.New("angle_offset_deg must be between -180 and 180")
+	}
+	if conf.OverlongScanMargin < 0 {
+		return nil, errors.New("overlong_scan_margin cannot be negative")
+	}
+	switch conf.OutOfOrderPolicy {
+	case "", outOfOrderDrop, outOfOrderClamp, outOfOrderKeep:
+	default:
+		return nil, errors.Errorf("out_of_order_policy must be one of %q, %q or %q",
+			outOfOrderDrop, outOfOrderClamp, outOfOrderKeep)
+	}
+	if conf.PreviewDecimation < 0 {
+		return nil, errors.New("preview_decimation cannot be negative")
+	}
+	if conf.OriginOffset != nil && !conf.OriginOffset.finite() {
+		return nil, errors.New("origin_offset must be finite")
+	}
+
+	switch conf.PointCloudBackend {
+	case "", pointCloudBackendBasic, pointCloudBackendKDTree, pointCloudBackendRounding:
+	default:
+		return nil, errors.Errorf("pointcloud_backend must be one of %q, %q or %q",
+			pointCloudBackendBasic, pointCloudBackendKDTree, pointCloudBackendRounding)
+	}
+
+	if conf.RotationPeriodWindow < 0 {
+		return nil, errors.New("rotation_period_window must be positive")
+	}
+
+	if conf.DiscardFirstScans != nil && *conf.DiscardFirstScans < 0 {
+		return nil, errors.New("discard_first_scans must be positive")
+	}
+
+	if conf.MinCoverage < 0 || conf.MinCoverage > 1 {
+		return nil, errors.New("min_coverage must be between 0 and 1")
+	}
+	if conf.CoverageNearMM < 0 {
+		return nil, errors.New("coverage_near_mm must be positive")
+	}
+	if conf.MaxArrivalJitterMs < 0 {
+		return nil, errors.New("max_arrival_jitter_ms must be positive")
+	}
+
+	if _, _, err := parseUnknownModelPolicy(conf.OnUnknownModel); err != nil {
+		return nil, err
+	}
+	if _, err := parseRotationSense(conf.RotationSense); err != nil {
+		return nil, err
+	}
+	if _, err := parseAxisMap(conf.AxisMap); err != nil {
+		return nil, err
+	}
+	if _, err := parseFirmwareVersion(conf.MinFirmwareVersion); err != nil {
+		return nil, err
+	}
+
+	for _, cpu := range conf.GrabberCPUs {
+		if cpu < 0 {
+			return nil, errors.New("grabber_cpus must not be negative")
+		}
+	}
+	if conf.SerialTimeoutMs < 0 {
+		return nil, errors.New("serial_timeout_ms must be positive")
+	}
+	if conf.ConstructTimeoutMs < 0 {
+		return nil, errors.New("construct_timeout_ms must be positive")
+	}
+
+	if conf.MinScanIntervalMs < 0 {
+		return nil, errors.New("min_scan_interval_ms must be positive")
+	}
+	switch conf.ScanIntervalPolicy {
+	case "", scanIntervalPolicyCached, scanIntervalPolicyBlock:
+	default:
+		return nil, errors.Errorf("scan_interval_policy must be either %q or %q",
+			scanIntervalPolicyCached, scanIntervalPolicyBlock)
+	}
+	switch conf.TriggerMode {
+	case "", triggerModeFreeRunning:
+	case triggerModeExternal:
+		if conf.MinScanIntervalMs > 0 {
+			return nil, errors.New("trigger_mode external cannot be combined with min_scan_interval_ms")
+		}
+	default:
+		return nil, errors.Errorf("trigger_mode must be either %q or %q", triggerModeFreeRunning, triggerModeExternal)
+	}
+	switch conf.BufferPolicy {
+	case "", bufferPolicyOldest, bufferPolicyLatest, bufferPolicyBlock:
+	default:
+		return nil, errors.Errorf("buffer_policy must be one of %q, %q or %q",
+			bufferPolicyOldest, bufferPolicyLatest, bufferPolicyBlock)
+	}
+
+	switch conf.QualityEncoding {
+	case "", qualityEncodingIntensity, qualityEncodingValue, qualityEncodingRGB:
+	default:
+		return nil, errors.Errorf("quality_encoding must be one of %q, %q or %q",
+			qualityEncodingIntensity, qualityEncodingValue, qualityEncodingRGB)
+	}
+
+	if err := conf.validateProfiles(path); err != nil {
+		return nil, err
+	}
+
 	return nil, nil
 }
 
@@ -129,31 +717,127 @@ func newRplidar(ctx context.Context, _ resource.Dependencies, c resource.Config,
 	if err != nil {
 		return nil, err
 	}
+	timeout := defaultConstructTimeout
+	if svcConf.ConstructTimeoutMs > 0 {
+		timeout = time.Duration(svcConf.ConstructTimeoutMs) * time.Millisecond
+	}
+	return constructWithin(ctx, timeout, logger, func(ctx context.Context) (camera.Camera, error) {
+		return constructRplidar(ctx, c, svcConf, logger)
+	})
+}
 
-	devicePath := svcConf.SerialPath
+// constructRplidar connects to the device and sets up the camera, releasing the lock file and the device again if
+// it fails.
+func constructRplidar(
+	ctx context.Context,
+	c resource.Config,
+	svcConf *Config,
+	logger logging.Logger,
+) (_ camera.Camera, err error) {
+	statePath := stateFilePath(svcConf, c.ResourceName().Name)
+	lastGood := loadLastGoodState(statePath, logger)
+
+	devicePath, err := resolveDevicePath(svcConf.SerialPath)
+	if err != nil {
+		return nil, err
+	}
+	lastGoodPath := lastGoodDevicePath(lastGood, svcConf)
+	if devicePath == "" && lastGoodPath != "" {
+		logger.Infof("trying %v, the serial path the rplidar last scanned at", lastGoodPath)
+		devicePath = lastGoodPath
+	}
 	if devicePath == "" {
-		var err error
-		if devicePath, err = searchForDevicePath(logger); err != nil {
+		if devicePath, err = searchForDevicePath(svcConf.DeviceIndex, logger); err != nil {
 			return nil, errors.Wrap(err, "need to specify a devicePath (ex. /dev/ttyUSB0)")
 		}
 	}
 
-	// Check lock file for conflicting processes
-	lockFilePath, err := checkLockFiles(devicePath)
+	timeoutMs := defaultDeviceTimeoutMs
+	if svcConf.SerialTimeoutMs > 0 {
+		timeoutMs = uint(svcConf.SerialTimeoutMs)
+	}
+	rplidarDevice, lockFilePath, err := openDevice(ctx, devicePath, timeoutMs, lastGood.BaudRate, logger)
+	// The serial path the rplidar last scanned at may lead to another one by now, e.g. after replugging, so it is
+	// only kept for the rplidar with the same serial number.
+	if devicePath == lastGoodPath && (err != nil || rplidarDevice.serialNumber != lastGood.SerialNumber) {
+		if err == nil {
+			logger.Infof("the rplidar at %v is not the one with serial number %v that last scanned there, "+
+				"detecting the rplidar instead", devicePath, lastGood.SerialNumber)
+			closeDevice(rplidarDevice, lockFilePath)
+		} else {
+			logger.Infof("failed to connect at %v, detecting the rplidar instead: %v", devicePath, err)
+		}
+		if devicePath, err = searchForDevicePath(svcConf.DeviceIndex, logger); err != nil {
+			return nil, errors.Wrap(err, "need to specify a devicePath (ex. /dev/ttyUSB0)")
+		}
+		rplidarDevice, lockFilePath, err = openDevice(ctx, devicePath, timeoutMs, lastGood.BaudRate, logger)
+	}
 	if err != nil {
+		if svcConf.LogResultCodes {
+			logResultCode(logger, err)
+		}
 		return nil, err
 	}
+	defer func() {
+		if _, statErr := os.Stat(lockFilePath); err != nil && statErr == nil {
+			goutils.UncheckedError(os.Remove(lockFilePath))
+		}
+	}()
+	// Closing the camera releases the driver, so this only releases it when constructing the camera failed.
+	defer func() {
+		if err != nil && rplidarDevice.driver != nil {
+			releaseDriver(rplidarDevice.driver)
+			rplidarDevice.driver = nil
+		}
+	}()
 
-	// Attempt to connect to rplidar
-	logger.Info("attempting to connect to device at serial_path: " + devicePath)
+	logger.Infof("the rplidar with serial number %v runs firmware %v on hardware revision %d",
+		rplidarDevice.serialNumber, rplidarDevice.firmwareVersion, rplidarDevice.hardwareRevision)
+	if err := checkFirmwareVersion(rplidarDevice, svcConf.MinFirmwareVersion); err != nil {
+		return nil, err
+	}
+
+	if name, profile, ok := svcConf.profileFor(rplidarDevice.serialNumber); ok {
+		logger.Infof("applying the %q profile to the rplidar with serial number %v", name, rplidarDevice.serialNumber)
+		merged := svcConf.withProfile(profile)
+		svcConf = &merged
+	}
+
+	unknownModelPolicy, assumedModel, err := parseUnknownModelPolicy(svcConf.OnUnknownModel)
+	if err != nil {
+		return nil, err
+	}
+	rplidarModel, known := rplidarModelByteMap[rplidarDevice.model]
+	switch {
+	case known:
+		logger.Infof("found and connected to an %v rplidar (model id %d) using rplidar SDK %v",
+			modelToString(rplidarModel), rplidarDevice.model, SDKVersion())
+	case unknownModelPolicy == unknownModelError:
+		return nil, errors.Errorf("connected to an rplidar with unknown model id %d", rplidarDevice.model)
+	default:
+		rplidarDevice.assumedModel = assumedModel
+		rplidarModel = assumedModel
+		logger.Warnf("connected to an rplidar with unknown model id %d, proceeding as an %v",
+			rplidarDevice.model, modelToString(assumedModel))
+	}
 
-	rplidarDevice, err := getRplidarDevice(devicePath)
+	configuredSense, err := parseRotationSense(svcConf.RotationSense)
+	if err != nil {
+		return nil, err
+	}
+	pointAxisMap, err := parseAxisMap(svcConf.AxisMap)
 	if err != nil {
 		return nil, err
 	}
+	rotationSense := nativeRotationSense(rplidarModel, configuredSense)
+	if rotationSense != normalizedRotationSense {
+		logger.Infof("the rplidar reports %v angles, normalizing them to %v", rotationSense, normalizedRotationSense)
+	}
 
-	rplidarModel := rplidarModelByteMap[rplidarDevice.model]
-	logger.Info("found and connected to an " + modelToString(rplidarModel) + " rplidar")
+	expressProtocol := preferredProtocol(lastGood, rplidarDevice.serialNumber, svcConf.ExpressProtocol, svcConf.ForceScan)
+	if expressProtocol != svcConf.ExpressProtocol {
+		logger.Infof("starting with the %v protocol the rplidar last scanned with", expressProtocol)
+	}
 
 	// Check configured capture frequency
 	captureFreqHz, err := getCaptureFrequencyHzFromConfig(c)
@@ -168,125 +852,642 @@ func newRplidar(ctx context.Context, _ resource.Dependencies, c resource.Config,
 			rplidarModel)
 	}
 
+	minScanInterval := time.Duration(svcConf.MinScanIntervalMs) * time.Millisecond
+	maxArrivalJitterMs := defaultMaxArrivalJitterMs
+	if svcConf.MaxArrivalJitterMs > 0 {
+		maxArrivalJitterMs = svcConf.MaxArrivalJitterMs
+	}
+	coverageNearMM := defaultCoverageNearMM
+	if svcConf.CoverageNearMM > 0 {
+		coverageNearMM = svcConf.CoverageNearMM
+	}
+	discardFirstScans := defaultWarmupNumDiscardedScans
+	if svcConf.DiscardFirstScans != nil {
+		discardFirstScans = *svcConf.DiscardFirstScans
+	}
+	smoothingWindowDeg := svcConf.SmoothingWindowDeg
+	if smoothingWindowDeg == 0 && svcConf.SmoothingBins > 0 {
+		smoothingWindowDeg = 360. / float64(svcConf.SmoothingBins)
+	}
+	usbResetAfter := 0
+	if svcConf.USBResetOnFailure {
+		usbResetAfter = defaultUSBResetAfterAttempts
+		if svcConf.USBResetAfterAttempts > 0 {
+			usbResetAfter = svcConf.USBResetAfterAttempts
+		}
+	}
+	var box *blackBox
+	if svcConf.BlackBoxPath != "" {
+		sizeMB := defaultBlackBoxSizeMB
+		if svcConf.BlackBoxSizeMB > 0 {
+			sizeMB = svcConf.BlackBoxSizeMB
+		}
+		if box, err = openBlackBox(svcConf.BlackBoxPath, int64(sizeMB)<<20, logger); err != nil {
+			return nil, err
+		}
+	}
+
 	rp := &rplidar{
-		Named:        c.ResourceName().AsNamed(),
-		device:       rplidarDevice,
-		lockFilePath: lockFilePath,
-		minRangeMM:   svcConf.MinRangeMM,
+		Named:               c.ResourceName().AsNamed(),
+		device:              rplidarDevice,
+		lockFilePath:        lockFilePath,
+		minRangeMM:          svcConf.MinRangeMM,
+		dropZeroQuality:     svcConf.KeepZeroQuality != nil && !*svcConf.KeepZeroQuality,
+		outOfOrderPolicy:    svcConf.OutOfOrderPolicy,
+		nativeRotationSense: rotationSense,
+		blankBelowMM:        svcConf.BlankBelowMM,
+		blankSectors:        svcConf.BlankSectors,
+		angleOffsetDeg:      svcConf.AngleOffsetDeg,
+		handedness:          svcConf.Handedness,
+		axisMap:             pointAxisMap,
+		originOffset:        svcConf.OriginOffset.vector(),
+
+		startAligner:       newStartAngleAligner(svcConf.StartAngleDeg),
+		overlongTrimmer:    newOverlongTrimmer(svcConf.OverlongScanMargin),
+		nearestPerSector:   svcConf.NearestPerSector,
+		smoothingBins:      svcConf.SmoothingBins,
+		smoothingWindowDeg: smoothingWindowDeg,
+		smoothingMethod:    svcConf.SmoothingMethod,
+		sortByAngle:        svcConf.SortByAngle,
+		qualityEncoding:    svcConf.QualityEncoding,
+		pointCloudBackend:  svcConf.PointCloudBackend,
+		keepRawScans:       svcConf.KeepRawScans,
+		previewDecimation:  svcConf.PreviewDecimation,
+		blackBox:           box,
+		rateThinner:        newRateThinner(svcConf.TargetPointsPerSec, newDecimationRand(svcConf.DecimationSeed)),
+		fractionThinner:    newFractionThinner(svcConf.KeepFraction, newDecimationRand(svcConf.DecimationSeed)),
+		expressProtocol:    expressProtocol,
+		forceScan:          svcConf.ForceScan,
+		probeModes:         svcConf.ProbeModes,
+		motorControl:       svcConf.MotorControl,
+		motorRamp:          time.Duration(svcConf.MotorRampMs) * time.Millisecond,
+
+		discardFirstScans:  discardFirstScans,
+		coverageNearMM:     coverageNearMM,
+		minCoverage:        svcConf.MinCoverage,
+		maxArrivalJitterMs: maxArrivalJitterMs,
+		degrader: newScanDegrader(svcConf.DegradeAfterErrors,
+			time.Duration(svcConf.DegradeRecoverMs)*time.Millisecond),
+		reconnector:   newReconnector(svcConf.ReconnectAfterErrors, usbResetAfter),
+		connectDevice: deviceConnector(devicePath, timeoutMs, rplidarDevice.baudRate),
+		devicePath:    devicePath,
+		stateFilePath: statePath,
+		lastGood:      lastGood,
 
 		cache:                  &dataCache{},
+		scanInterval:           newScanIntervalLimiter(minScanInterval, svcConf.ScanIntervalPolicy),
+		scanTrigger:            newScanTrigger(svcConf.TriggerMode),
 		cacheBackgroundWorkers: sync.WaitGroup{},
+		partialScans:           newAsyncNotifier[pointcloud.PointCloud](defaultPartialScanQueueSize, svcConf.BufferPolicy),
+		standbyRequests:        make(chan chan error),
+		rawMeasurements:        newAsyncNotifier[[]Measurement](defaultMeasurementQueueSize, svcConf.BufferPolicy),
+		grabberCPUs:            svcConf.GrabberCPUs,
+		stats:                  scanStats{periodWindow: svcConf.RotationPeriodWindow},
+		logResultCodes:         svcConf.LogResultCodes,
 
 		logger: logger,
 	}
+	// The uptime is measured with the same clock as the session it summarizes.
+	rp.stats.startTime = clockOrReal(rp.clock).Now()
 
-	// Setup RPLiDAR
-	if err := rp.setupRPLidar(ctx); err != nil {
-		return nil, errors.Wrap(err, "there was a problem setting up the rplidar")
+	rp.resetUSB = func(ctx context.Context) error {
+		return resetUSBDevice(ctx, sysDir, devicePath, rp.clock)
 	}
 
 	cancelCtx, cancelFunc := context.WithCancel(context.Background())
 	rp.cancelFunc = cancelFunc
 
-	// Start background caching of pointcloud data
+	rp.backgroundCtx = cancelCtx
+	if svcConf.AutoStart == nil || *svcConf.AutoStart {
+		if err := rp.start(ctx, cancelCtx, time.Time{}); err != nil {
+			// Closing stops the motor and releases the device, the black box and the lock file.
+			goutils.UncheckedError(rp.Close(ctx))
+			return nil, err
+		}
+	} else {
+		logger.Info("auto_start is disabled, the motor and scanning start with the first scan request")
+		rp.setState(StateStandby)
+		rp.deferredStart = true
+	}
+
+	// Start delivery of partial scans to a registered callback
 	rp.cacheBackgroundWorkers.Add(1)
 	go func() {
 		defer rp.cacheBackgroundWorkers.Done()
-		rp.cachePointCloudLoop(cancelCtx)
+		rp.partialScans.run(cancelCtx)
 	}()
 
+	// Start delivery of the measurements of every grab to a registered callback
+	rp.cacheBackgroundWorkers.Add(1)
+	go func() {
+		defer rp.cacheBackgroundWorkers.Done()
+		rp.rawMeasurements.run(cancelCtx)
+	}()
+
+	// Start writing raw scans to the black box
+	if box != nil {
+		rp.cacheBackgroundWorkers.Add(1)
+		go func() {
+			defer rp.cacheBackgroundWorkers.Done()
+			box.run(cancelCtx)
+		}()
+	}
+
 	return rp, nil
 }
 
+// openDevice checks that the device at devicePath is not in use by another process, locks it and connects to it,
+// returning the device along with its lock file. The lock file is removed again if connecting fails.
+func openDevice(
+	ctx context.Context,
+	devicePath string,
+	timeoutMs, preferredBaudRate uint,
+	logger logging.Logger,
+) (*rplidarDevice, string, error) {
+	if err := checkDeviceAccess(devicePath); err != nil {
+		return nil, "", err
+	}
+
+	// Check for other processes holding the serial port open
+	if pids := processesHoldingDevice(procDir, devicePath); len(pids) > 0 {
+		return nil, "", fmt.Errorf("%w: %v is held open by another process, close it there first (PID(s): %v)",
+			ErrDeviceBusy, devicePath, pids)
+	}
+
+	// Check lock file for conflicting processes
+	lockFilePath, err := checkLockFiles(devicePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Attempt to connect to rplidar
+	logger.Info("attempting to connect to device at serial_path: " + devicePath)
+	_, connectSpan := trace.StartSpan(ctx, "rplidar::connect")
+	device, err := getRplidarDevice(devicePath, timeoutMs, preferredBaudRate)
+	connectSpan.End()
+	if err != nil {
+		if _, statErr := os.Stat(lockFilePath); statErr == nil {
+			goutils.UncheckedError(os.Remove(lockFilePath))
+		}
+		return nil, "", err
+	}
+	return device, lockFilePath, nil
+}
+
+// closeDevice disconnects from a device opened with openDevice and removes its lock file.
+func closeDevice(device *rplidarDevice, lockFilePath string) {
+	releaseDriver(device.driver)
+	device.driver = nil
+	if _, statErr := os.Stat(lockFilePath); statErr == nil {
+		goutils.UncheckedError(os.Remove(lockFilePath))
+	}
+}
+
 // setupRPLiDAR starts the motor, if necessary, warms up the device, and ensures data returned to the
-// user is valid.
-func (rp *rplidar) setupRPLidar(ctx context.Context) error {
-	// Note: S1 RPLiDARs do not need to start the motor before scanning can begin
-	if rplidarModelByteMap[rp.device.model] != S1 {
+// user is valid. Resuming from a standby skips probing the scan modes.
+func (rp *rplidar) setupRPLidar(ctx context.Context, resuming bool) error {
+	if rp.controlsMotor() {
 		rp.logger.Debug("starting motor")
-		rp.device.driver.StartMotor()
+		if err := rp.startMotor(ctx); err != nil {
+			return err
+		}
+	} else if rp.motorControl == motorControlExternal {
+		rp.logger.Info("the motor is controlled externally, assuming it is spinning")
+	}
+
+	if rp.nodes == nil {
+		rp.nodes = gen.New_measurementNodeHqArray(defaultNodeSize)
+	}
+	if rp.probeModes && !resuming {
+		rp.logger.Infof("probing %d scan modes", len(rp.device.scanModes))
+		clockOrReal(rp.clock).Wait(ctx, defaultWarmUpTimeout)
+		rp.probeScanModes(ctx)
 	}
 
 	// Perform warmup scans
-	rp.device.driver.StartScan(false, true)
-	rp.nodes = gen.New_measurementNodeHqArray(defaultNodeSize)
+	if rp.forceScan {
+		rp.logger.Warn("forcing a scan regardless of the motor rotation, the data is invalid unless the motor is spinning")
+	}
+	if err := rp.device.startScan(rp.expressProtocol, rp.forceScan); err != nil {
+		return err
+	}
+	rp.logger.Infof("scanning in %v mode using the %v protocol",
+		rp.device.currentScanMode(), rp.device.currentExpressProtocol())
 
-	goutils.SelectContextOrWait(ctx, defaultWarmUpTimeout)
-	if _, err := rp.scan(ctx, defaultWarmupNumDiscardedScans); err != nil {
+	clockOrReal(rp.clock).Wait(ctx, defaultWarmUpTimeout)
+	rp.logger.Debugf("discarding the first %d scans", rp.discardFirstScans)
+	if _, _, err := rp.scan(ctx, rp.discardFirstScans); err != nil {
 		return err
 	}
+	rp.recordLastGood()
 
 	return nil
 }
 
+// controlsMotor reports whether the motor is started and stopped through the SDK. S1 RPLiDARs do not need the
+// motor to be started before scanning or stopped during closeout, and an externally controlled motor is never
+// touched.
+func (rp *rplidar) controlsMotor() bool {
+	return rp.motorControl != motorControlExternal && rp.device.rplidarModel() != S1
+}
+
 // cachePointCloudLoop is a background process that repeatedly gets point cloud data from the RPLiDAR
-// and caches it for later access.
-func (rp *rplidar) cachePointCloudLoop(ctx context.Context) {
+// and caches it for later access, until ctx is done or the rplidar enters standby. If resumedAt is set, the time
+// until the first point cloud is cached is recorded as the latency of resuming from standby.
+func (rp *rplidar) cachePointCloudLoop(ctx context.Context, resumedAt time.Time) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case reply := <-rp.standbyRequests:
+			err := rp.enterStandby()
+			reply <- err
+			if err == nil {
+				return
+			}
 		default:
-			pc, err := rp.scan(ctx, defaultNumScans)
+			pc, info, err := rp.scan(ctx, defaultNumScans)
+			scanTime := clockOrReal(rp.clock).Now()
+			var pointCount int
+			var interArrival, jitter time.Duration
+			rp.stats.setLastError(err)
 			if err != nil {
 				rp.logger.Debugf("issue getting pointcloud to cache: %v", err)
+				rp.logResultCode(err)
+				rp.arrivals.reset()
+			} else {
+				interArrival, jitter = rp.arrivals.arrive(scanTime)
+				if pc != nil {
+					pointCount = pc.Size()
+				}
+				rp.stats.addScan(scanTime, pointCount)
+			}
+
+			coverage, blocked := info.coverage.fractions()
+			if err == nil {
+				rp.checkCoverage(coverage, blocked)
+			}
+			rp.checkDegradation(err, scanTime)
+			rp.checkReconnect(ctx, err)
+
+			// A scan the robot's motion did not allow is dropped, keeping the last allowed one cached.
+			if err == nil && !rp.scanGate.allows() {
+				rp.stats.addGatedScan()
+				continue
 			}
 
 			rp.cache.mutex.Lock()
 			rp.cache.pointCloud = pc
+			rp.cache.rawPointCloud = info.raw
+			rp.cache.previewPointCloud = info.preview
+			rp.cache.measurements = info.measurements
+			if pc != nil && info.samples > 0 {
+				rp.cache.meta = ScanMeta{
+					Seq:                  rp.cache.meta.Seq + 1,
+					Timestamp:            scanTime,
+					PointCount:           pointCount,
+					AngularResolutionDeg: 360 * defaultNumScans / float64(info.samples),
+					Coverage:             coverage,
+					BlockedFraction:      blocked,
+					DroppedPoints:        info.dropped,
+					InterArrival:         interArrival,
+					ArrivalJitter:        jitter,
+				}
+				rp.cache.notifyUpdated()
+				rp.scanTrigger.offer(pc, rp.revolutionStart(scanTime, interArrival))
+				if !resumedAt.IsZero() {
+					rp.recordResume(resumedAt, scanTime)
+					resumedAt = time.Time{}
+				}
+			}
 			rp.cache.mutex.Unlock()
 		}
 	}
 }
 
-// scan uses the serial connection to the RPLiDAR to get data and create a pointcloud from it
-func (rp *rplidar) scan(ctx context.Context, numScans int) (pointcloud.PointCloud, error) {
+// scanInfo describes the raw data a scan was built from, before any filtering.
+type scanInfo struct {
+	// The number of samples the device reported across all revolutions.
+	samples  int
+	coverage scanCoverage
+	// The number of samples removed by each stage, keyed as in ScanMeta.DroppedPoints.
+	dropped map[string]int
+	// The point cloud of every valid return before filtering, only built when keep_raw_scans is enabled.
+	raw pointcloud.PointCloud
+	// The point cloud of every preview_decimation-th point, only built when preview_decimation is set.
+	preview pointcloud.PointCloud
+	// The valid returns of the last revolution before filtering.
+	measurements []Measurement
+}
+
+// checkCoverage warns when the coverage of the scans drops below the configured minimum, telling apart a blocked
+// lens from open surroundings, and notes when it recovers. It is only called from the scan loop.
+func (rp *rplidar) checkCoverage(coverage, blocked float64) {
+	if rp.minCoverage <= 0 {
+		return
+	}
+	state := coverageState(coverage, blocked, rp.minCoverage)
+	if state == rp.coverageState {
+		return
+	}
+	switch {
+	case state == "blocked":
+		rp.logger.Warnf("scan coverage dropped to %.0f%%, %.0f%% of the directions only see returns within %vmm, "+
+			"the lens may be blocked", coverage*100, blocked*100, rp.coverageNearMM)
+	case state == "open":
+		rp.logger.Warnf("scan coverage dropped to %.0f%%, most uncovered directions see no returns at all",
+			coverage*100)
+	case rp.coverageState != "":
+		rp.logger.Infof("scan coverage recovered to %.0f%%", coverage*100)
+	}
+	rp.coverageState = state
+}
+
+// scan uses the serial connection to the RPLiDAR to get data and create a pointcloud from it. It also returns
+// information on the raw data of all revolutions.
+func (rp *rplidar) scan(ctx context.Context, numScans int) (pointcloud.PointCloud, scanInfo, error) {
+	ctx, span := trace.StartSpan(ctx, "rplidar::scan")
+	defer span.End()
+
 	rp.device.mutex.Lock()
 	defer rp.device.mutex.Unlock()
 
-	pc := pointcloud.New()
+	pc := newPointCloud(rp.pointCloudBackend)
+
+	var nodeCount int64
+	info := scanInfo{dropped: map[string]int{}}
+	if rp.keepRawScans {
+		info.raw = newPointCloud(rp.pointCloudBackend)
+	}
+	if rp.previewDecimation > 0 {
+		info.preview = newPointCloud(rp.pointCloudBackend)
+	}
+	notifyPartialScans := rp.partialScans.active()
+	for i, overflowRetries := 0, 0; i < numScans; i++ {
+		_, grabSpan := trace.StartSpan(ctx, "rplidar::scan::grab")
+		nodeCount = int64(defaultNodeSize)
+		result := rp.device.driver.GrabScanDataHq(rp.nodes, &nodeCount, rp.device.timeoutMs)
+		grabSpan.End()
+
+		// When the node buffer overflowed the grabbed data may be incomplete, so discard it and try again with
+		// the next grab rather than failing the whole scan.
+		if isOverflow(Result(result), nodeCount) {
+			rp.stats.addOverflow()
+			if overflowRetries++; overflowRetries > defaultMaxOverflowRetries {
+				rp.startAligner.reset()
+				rp.overlongTrimmer.reset()
+				return nil, scanInfo{}, fmt.Errorf("bad scan: %d consecutive buffer overflows", overflowRetries)
+			}
+			rp.logger.Debug("discarding grabbed scan data after a buffer overflow")
+			i--
+			continue
+		}
+		overflowRetries = 0
 
-	var dropCount int
-	nodeCount := int64(defaultNodeSize)
-	for i := 0; i < numScans; i++ {
-		result := rp.device.driver.GrabScanDataHq(rp.nodes, &nodeCount, defaultDeviceTimeoutMs)
 		if Result(result) != ResultOk {
-			return nil, fmt.Errorf("bad scan: %w", Result(result).Failed())
+			rp.startAligner.reset()
+			rp.overlongTrimmer.reset()
+			return nil, scanInfo{}, fmt.Errorf("bad scan: %w", Result(result).Failed())
+		}
+
+		_, filterSpan := trace.StartSpan(ctx, "rplidar::scan::filter")
+		samples := rp.decodeSamples(nodeCount)
+		if rp.overlongTrimmer != nil {
+			before := len(samples)
+			var trimmed bool
+			if samples, trimmed = rp.overlongTrimmer.trim(samples, rp.expectedRevolutionSamples()); trimmed {
+				rp.stats.addOverlongScan()
+			}
+			// Samples held back from the previous grab are part of this revolution, the ones trimmed are not.
+			nodeCount += int64(len(samples) - before)
+		}
+		measurements := validMeasurements(samples)
+		if rp.rawMeasurements.active() {
+			// Grabs are delivered sorted but before out_of_order_policy, so the delivered one is sorted separately.
+			rp.rawMeasurements.notify(exportMeasurements(rp.ascend(append([]measurement(nil), measurements...))))
+		}
+		invalid := int(nodeCount) - len(measurements)
+		// A sample stepping backward can only be told apart before the grab is sorted.
+		measurements, outOfOrder := orderAngles(measurements, rp.outOfOrderPolicy)
+		measurements = rp.ascend(measurements)
+		var complete bool
+		if measurements, complete = rp.startAligner.align(measurements); !complete {
+			// The first grab only provides the arc the next revolution starts with.
+			filterSpan.End()
+			i--
+			continue
+		}
+		info.samples += int(nodeCount)
+		info.dropped["invalid"] += invalid
+		if rp.outOfOrderPolicy == "" || rp.outOfOrderPolicy == outOfOrderDrop {
+			info.dropped["out_of_order_policy"] += outOfOrder
+		}
+		rp.stats.addOutOfOrder(outOfOrder)
+		info.coverage.add(measurements, rp.coverageNearMM)
+		rp.blackBox.record(measurements, clockOrReal(rp.clock).Now())
+		if i == numScans-1 {
+			info.measurements = exportMeasurements(measurements)
+		}
+		// The filters reuse the measurements' storage, so the raw scan has to be built first.
+		if info.raw != nil {
+			if err := rp.addMeasurements(info.raw, measurements, false); err != nil {
+				filterSpan.End()
+				return nil, scanInfo{}, err
+			}
+		}
+		measurements = rp.filterMeasurements(measurements, info.dropped)
+		if rp.rateThinner != nil {
+			before := len(measurements)
+			samplesPerSec, _ := rp.ModeSampleRate(ScanMode(rp.device.currentScanMode()))
+			measurements = rp.rateThinner.thin(measurements, int(nodeCount), samplesPerSec, clockOrReal(rp.clock).Now())
+			info.dropped["target_points_per_sec"] += before - len(measurements)
+		}
+		if rp.fractionThinner != nil {
+			before := len(measurements)
+			measurements = rp.fractionThinner.thin(measurements)
+			info.dropped["keep_fraction"] += before - len(measurements)
+		}
+		filterSpan.End()
+
+		_, convertSpan := trace.StartSpan(ctx, "rplidar::scan::convert")
+		err := rp.addMeasurements(pc, measurements, notifyPartialScans)
+		if err == nil && info.preview != nil {
+			err = rp.addMeasurements(info.preview, decimate(measurements, rp.previewDecimation), false)
 		}
-		rp.device.driver.AscendScanData(rp.nodes, nodeCount)
+		convertSpan.End()
+		if err != nil {
+			return nil, scanInfo{}, err
+		}
+	}
+	if pc.Size() == 0 {
+		return nil, info, nil
+	}
+	return pc, info, nil
+}
 
-		for pos := 0; pos < int(nodeCount); pos++ {
+// decodeNodes converts the first nodeCount grabbed nodes into measurements sorted by ascending angle. Missing
+// angles are interpolated from neighboring nodes before nodes without a valid distance are skipped.
+func (rp *rplidar) decodeNodes(nodeCount int64) []measurement {
+	return rp.ascend(validMeasurements(rp.decodeSamples(nodeCount)))
+}
 
-			node := gen.MeasurementNodeHqArray_getitem(rp.nodes, rputils.CastInt(pos))
+// decodeSamples converts the first nodeCount grabbed nodes into measurements in the order they were taken,
+// interpolating missing angles from neighboring nodes, and keeps the ones without a valid distance.
+func (rp *rplidar) decodeSamples(nodeCount int64) []measurement {
+	measurements := make([]measurement, 0, nodeCount)
+	for pos := 0; pos < int(nodeCount); pos++ {
+		node := gen.MeasurementNodeHqArray_getitem(rp.nodes, rputils.CastInt(pos))
+		measurements = append(measurements, measurement{
+			angleDeg:   float64(node.GetAngle_z_q14()) * 90 / (1 << 14),
+			distanceMM: float64(node.GetDist_mm_q2()) / 4,
+			quality:    node.GetQuality(),
+		})
+	}
+	// Nodes without a distance still carry the angle of their slot, so interpolate before dropping them.
+	return interpolateMissingAngles(measurements)
+}
 
-			if node.GetDist_mm_q2() == 0 {
-				dropCount++
-				continue // TODO(erd): okay to skip?
-			}
+// ascend sorts the measurements of a grab by ascending angle in place, in the normalized rotation sense. It takes
+// the place of the SDK's AscendScanData, which sorts the node buffer before the steps relying on the order the
+// samples were taken in could run on it.
+func (rp *rplidar) ascend(measurements []measurement) []measurement {
+	sortByAngle(measurements)
+	return normalizeRotation(measurements, rp.nativeRotationSense)
+}
 
-			nodeAngle := (float64(node.GetAngle_z_q14()) * 90 / (1 << 14))
-			nodeDistance := float64(node.GetDist_mm_q2()) / 4
+// validMeasurements drops the measurements without a valid distance, reusing their storage.
+func validMeasurements(measurements []measurement) []measurement {
+	valid := measurements[:0]
+	for _, m := range measurements {
+		if m.distanceMM == 0 {
+			continue // TODO(erd): okay to skip?
+		}
+		valid = append(valid, m)
+	}
+	return valid
+}
 
-			// Filter out points below minRange
-			if nodeDistance < rp.minRangeMM {
-				continue
+// filterMeasurements applies the configured filters to the measurements of a single revolution, adding the
+// number of measurements each filter removed to dropped, keyed by the filter's attribute.
+func (rp *rplidar) filterMeasurements(measurements []measurement, dropped map[string]int) []measurement {
+	if rp.dropZeroQuality {
+		before := len(measurements)
+		nonZero := measurements[:0]
+		for _, m := range measurements {
+			if m.quality > 0 {
+				nonZero = append(nonZero, m)
+			}
+		}
+		measurements = nonZero
+		dropped["keep_zero_quality"] += before - len(measurements)
+	}
+	// Filter out points below minRange
+	if rp.minRangeMM > 0 {
+		before := len(measurements)
+		inRange := measurements[:0]
+		for _, m := range measurements {
+			if m.distanceMM >= rp.minRangeMM {
+				inRange = append(inRange, m)
 			}
+		}
+		measurements = inRange
+		dropped["min_range_mm"] += before - len(measurements)
+	}
+	if rp.blankBelowMM > 0 {
+		before := len(measurements)
+		measurements = blankNearField(measurements, rp.blankBelowMM, rp.blankSectors)
+		dropped["blank_below_mm"] += before - len(measurements)
+	}
+	if rp.smoothingBins > 0 {
+		// A window wider than a bin can yield more measurements than it was given.
+		before := len(measurements)
+		measurements = smoothPerBin(measurements, rp.smoothingBins, rp.smoothingWindowDeg, rp.smoothingMethod)
+		if before > len(measurements) {
+			dropped["smoothing_bins"] += before - len(measurements)
+		}
+	}
+	if rp.nearestPerSector > 0 {
+		before := len(measurements)
+		measurements = nearestPerSector(measurements, rp.nearestPerSector)
+		dropped["nearest_per_sector"] += before - len(measurements)
+	}
+	if rp.sortByAngle {
+		sortByAngle(measurements)
+	}
+	return measurements
+}
 
-			err := pc.Set(pointFrom(utils.DegToRad(nodeAngle), utils.DegToRad(0), nodeDistance/1000, 255))
-			if err != nil {
-				return nil, err
+// addMeasurements converts the measurements of a single revolution to points and adds them to pc. If
+// notifyPartialScans is set, the points are also handed to the partial scan callback in arcs. The revolution was
+// grabbed whole, so its arcs lag the device by up to a full revolution.
+func (rp *rplidar) addMeasurements(pc pointcloud.PointCloud, measurements []measurement, notifyPartialScans bool) error {
+	var arc pointcloud.PointCloud
+	var arcStartAngle float64
+	for _, m := range measurements {
+		// Without a quality encoding, every point keeps the full-scale intensity.
+		reflectivity := uint8(255)
+		if rp.qualityEncoding == qualityEncodingIntensity {
+			reflectivity = m.quality
+		}
+		p, d := pointFrom(utils.DegToRad(m.angleDeg+rp.angleOffsetDeg), utils.DegToRad(0), m.distanceMM/1000, reflectivity, rp.handedness)
+		p = rp.axisMap.apply(p).Add(rp.originOffset)
+		setQuality(d, m.quality, rp.qualityEncoding)
+		if err := pc.Set(p, d); err != nil {
+			return err
+		}
+
+		// Hand off completed arcs of the scan to the partial scan callback
+		if notifyPartialScans {
+			if arc == nil {
+				arc = newPointCloud(rp.pointCloudBackend)
+				arcStartAngle = m.angleDeg
+			}
+			if err := arc.Set(p, d); err != nil {
+				return err
+			}
+			if m.angleDeg-arcStartAngle >= defaultPartialScanArcDeg {
+				rp.partialScans.notify(arc)
+				arc = nil
 			}
 		}
 	}
-	if pc.Size() == 0 {
-		return nil, nil
+	if arc != nil {
+		rp.partialScans.notify(arc)
 	}
-	return pc, nil
+	return nil
+}
+
+// isOverflow reports whether a grab overflowed the node buffer. GrabScanDataHq in SDK 1.12 never fails with
+// ResultInsufficientMemory, it copies as many nodes of the revolution as fit and reports that count, so a grab
+// that filled the whole buffer might have been truncated.
+func isOverflow(result Result, nodeCount int64) bool {
+	return result == ResultOk && nodeCount >= defaultNodeSize
 }
 
 // NextPointCloud returns the current cached point cloud. If no pointcloud has been added to the cache at the
-// point this call is made, it will return an error
+// point this call is made, it will return an error. If a minimum scan interval is configured, calls arriving
+// within it of the previous call get the previous point cloud again or block, depending on the policy. If
+// trigger_mode is external, it returns the revolution aligned to the most recent sync trigger instead.
 func (rp *rplidar) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, error) {
+	ctx, span := trace.StartSpan(ctx, "rplidar::NextPointCloud")
+	defer span.End()
+
+	if err := rp.ensureStarted(ctx); err != nil {
+		return nil, err
+	}
+	if err := rp.checkScanning(); err != nil {
+		return nil, err
+	}
+	if err := rp.faults.nextPointCloudFault(ctx); err != nil {
+		return nil, err
+	}
+	if rp.scanInterval != nil {
+		return rp.scanInterval.next(ctx, rp.cache)
+	}
+	if rp.scanTrigger != nil {
+		return rp.scanTrigger.next(ctx)
+	}
+
 	rp.cache.mutex.RLock()
 	defer rp.cache.mutex.RUnlock()
 
@@ -296,15 +1497,284 @@ func (rp *rplidar) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, e
 	return rp.cache.pointCloud, nil
 }
 
-// Images is a part of the camera interface but is not implemented for the RPLiDAR.
+// NextPointCloudPair returns the current cached point cloud together with the unfiltered point cloud of the same
+// revolutions, for comparing the output of the filters with their input. The raw point cloud contains every valid
+// return, before min_range_mm, blank_below_mm, smoothing_bins, nearest_per_sector, target_points_per_sec and keep_fraction are
+// applied. It returns an error unless keep_raw_scans is enabled or if no point cloud has been saved yet. The filtered point cloud
+// is nil if the filters removed every point.
+func (rp *rplidar) NextPointCloudPair(ctx context.Context) (raw, filtered pointcloud.PointCloud, err error) {
+	if !rp.keepRawScans {
+		return nil, nil, errors.New("keep_raw_scans must be enabled to get raw point clouds")
+	}
+	if err := rp.ensureStarted(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	rp.cache.mutex.RLock()
+	defer rp.cache.mutex.RUnlock()
+
+	if rp.cache.rawPointCloud == nil {
+		return nil, nil, errors.New("pointcloud has not been saved yet")
+	}
+	return rp.cache.rawPointCloud, rp.cache.pointCloud, nil
+}
+
+// NextPreviewPointCloud returns the decimated preview of the current cached point cloud, built from every
+// preview_decimation-th point of the same revolutions without grabbing them again, e.g. for streaming to a remote
+// viewer while the full point cloud is logged locally. It returns an error unless preview_decimation is set or if
+// no point cloud has been saved yet.
+func (rp *rplidar) NextPreviewPointCloud(ctx context.Context) (pointcloud.PointCloud, error) {
+	if rp.previewDecimation <= 0 {
+		return nil, errors.New("preview_decimation must be set to get preview point clouds")
+	}
+	if err := rp.ensureStarted(ctx); err != nil {
+		return nil, err
+	}
+
+	rp.cache.mutex.RLock()
+	defer rp.cache.mutex.RUnlock()
+
+	if rp.cache.previewPointCloud == nil {
+		return nil, errors.New("pointcloud has not been saved yet")
+	}
+	return rp.cache.previewPointCloud, nil
+}
+
+// Stats returns the counters of notable events since the camera was created, which are logged when it is closed.
+func (rp *rplidar) Stats() DeviceStats {
+	stats := rp.stats.session(clockOrReal(rp.clock).Now())
+	stats.BlackBoxDrops = rp.blackBox.droppedCount()
+	stats.MeasurementDrops = rp.rawMeasurements.droppedCount()
+	stats.PartialScanDrops = rp.partialScans.droppedCount()
+	stats.CallbackStalls = rp.rawMeasurements.stallCount() + rp.partialScans.stallCount()
+	stats.SDKVersion = SDKVersion()
+	return stats
+}
+
+// LastScanMeta returns the metadata of the most recent point cloud stored in the cache. It returns an error if no
+// scan has been stored yet.
+func (rp *rplidar) LastScanMeta(ctx context.Context) (ScanMeta, error) {
+	rp.cache.mutex.RLock()
+	defer rp.cache.mutex.RUnlock()
+
+	if rp.cache.meta.Seq == 0 {
+		return ScanMeta{}, errors.New("pointcloud has not been saved yet")
+	}
+	return rp.cache.meta, nil
+}
+
+// AngularResolutionDeg returns the angle in degrees between consecutive samples of the most recent scan, as
+// measured from the number of samples per revolution rather than taken from the nominal scan mode spec.
+func (rp *rplidar) AngularResolutionDeg(ctx context.Context) (float64, error) {
+	meta, err := rp.LastScanMeta(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return meta.AngularResolutionDeg, nil
+}
+
+// OnMeasurements registers fn to be called with the valid returns of every grab from the device. See
+// MeasurementNotifier.
+func (rp *rplidar) OnMeasurements(fn func([]Measurement)) {
+	rp.rawMeasurements.register(fn)
+}
+
+// OnPartialScan registers fn to be called with every partial arc of new points as a scan is assembled.
+// See PartialScanNotifier.
+func (rp *rplidar) OnPartialScan(fn func(pointcloud.PointCloud)) {
+	rp.partialScans.register(fn)
+}
+
+// ExpressProtocol returns the express scan protocol the device is currently scanning with: "legacy",
+// "extended" or "standard" if no express protocol is in use. It reflects any step down made by
+// degrade_after_errors.
+func (rp *rplidar) ExpressProtocol() string {
+	return rp.device.currentExpressProtocol()
+}
+
+// Readings returns a summary of the rplidar's status: the device's health when it was last connected, its serial
+// number, the active scan mode, the measured scan rate, the number of points in the last scan and the number of
+// reconnects after scan errors. All values are cached, so calling Readings never queries the device.
+func (rp *rplidar) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
+	scanRateHz, pointCount := rp.stats.lastScan()
+	return map[string]interface{}{
+		"health":                healthStatusToString(rp.device.currentHealthStatus()),
+		"serial_number":         rp.device.info().SerialNumber,
+		"scan_mode":             rp.device.currentScanMode(),
+		"scan_rate_hz":          scanRateHz,
+		"last_scan_point_count": pointCount,
+		"reconnect_count":       rp.Stats().Reconnects,
+	}, nil
+}
+
+// DeviceInfo returns the identity of the connected rplidar. It is read once when the device is connected and
+// served from that cache afterwards, so calling DeviceInfo never contends with scanning for the serial line. The
+// cache is refreshed whenever the device is connected again: when the camera is reconfigured or rebuilt, and when
+// the background scan loop reconnects after repeated scan errors, which may find another unit on the port.
+func (rp *rplidar) DeviceInfo() DeviceInfo {
+	return rp.device.info()
+}
+
+// RotationPeriod returns the time the rplidar takes for a revolution, averaged over the recent revolutions selected
+// by rotation_period_window. It is measured from the start of consecutive revolutions, so it is steadier than the
+// speed reported for a single revolution. It returns an error until two consecutive revolutions were scanned.
+func (rp *rplidar) RotationPeriod(ctx context.Context) (time.Duration, error) {
+	return rp.stats.rotationPeriod()
+}
+
+// LastError returns the most recent error the background scan loop encountered, such as a failed or
+// overflowing scan, without causing a new call to fail. It is cleared by the next successful scan.
+func (rp *rplidar) LastError() error {
+	return rp.stats.lastError()
+}
+
+// DoCommand runs the rplidar specific command named by the "command" key of cmd. Supported commands are:
+//   - "standby": puts the rplidar in a warm standby until the next scan request, see Standby.
+//   - "get_overflow_count": returns the number of grabs discarded because of a buffer overflow.
+//   - "get_readings": returns the same status summary as Readings.
+//   - "get_device_info": returns the cached identity of the device, see DeviceInfo.
+//   - "get_last_error": returns the error returned by LastError, or an empty string if there is none.
+//   - "get_scan_modes": returns the samples per second of each scan mode the device supports.
+//   - "get_coverage": returns the coverage of the most recent scan and how much of it looks blocked or open.
+//   - "get_dropped_points": returns the number of samples each filter removed from the most recent scan.
+//   - "get_timing_health": returns the arrival timing of the most recent scan and whether its jitter is too high.
+//   - "measure_at_angle": returns the return nearest to "angle_deg" within "tolerance_deg", see MeasureAtAngle.
+//   - "get_state": returns the current state, see State, and when it was entered.
+func (rp *rplidar) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := cmd["command"].(string)
+	if !ok {
+		return nil, errors.New("the \"command\" key must be set to the name of the command to run")
+	}
+
+	switch name {
+	case "start":
+		if err := rp.ensureStarted(ctx); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{}, nil
+	case "standby":
+		if err := rp.Standby(ctx); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{}, nil
+	case "get_overflow_count":
+		return map[string]interface{}{"overflow_count": rp.stats.overflowCount()}, nil
+	case "get_readings":
+		return rp.Readings(ctx, nil)
+	case "get_device_info":
+		info := rp.DeviceInfo()
+		return map[string]interface{}{
+			"model":             info.Model,
+			"model_id":          info.ModelID,
+			"family":            string(info.Family),
+			"serial_number":     info.SerialNumber,
+			"firmware_version":  info.FirmwareVersion,
+			"hardware_revision": info.HardwareRevision,
+		}, nil
+	case "measure_at_angle":
+		angleDeg, ok := cmd["angle_deg"].(float64)
+		if !ok {
+			return nil, errors.New("measure_at_angle requires a numeric \"angle_deg\"")
+		}
+		toleranceDeg, ok := cmd["tolerance_deg"].(float64)
+		if !ok {
+			toleranceDeg = defaultMeasureToleranceDeg
+		}
+		m, err := rp.MeasureAtAngle(ctx, angleDeg, toleranceDeg)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"angle_deg":   m.AngleDeg,
+			"distance_mm": m.DistanceMM,
+			"quality":     int(m.Quality),
+		}, nil
+	case "get_state":
+		state, since := rp.state.current()
+		var sinceStr string
+		if !since.IsZero() {
+			sinceStr = since.Format(time.RFC3339Nano)
+		}
+		return map[string]interface{}{"state": string(state), "since": sinceStr}, nil
+	case "set_motion_ok":
+		motionOK, ok := cmd["motion_ok"].(bool)
+		if !ok {
+			return nil, errors.New("set_motion_ok requires a boolean \"motion_ok\"")
+		}
+		rp.scanGate.setMotionOK(motionOK)
+		return map[string]interface{}{}, nil
+	case "trigger":
+		if rp.scanTrigger == nil {
+			return nil, errors.New("trigger requires trigger_mode external")
+		}
+		at := clockOrReal(rp.clock).Now()
+		if atStr, ok := cmd["time"].(string); ok {
+			var err error
+			if at, err = time.Parse(time.RFC3339Nano, atStr); err != nil {
+				return nil, errors.Wrap(err, "trigger requires \"time\" to be an RFC 3339 time")
+			}
+		}
+		rp.scanTrigger.trigger(at)
+		return map[string]interface{}{}, nil
+	case "get_last_error":
+		var lastError string
+		if err := rp.LastError(); err != nil {
+			lastError = err.Error()
+		}
+		return map[string]interface{}{"last_error": lastError}, nil
+	case "get_coverage":
+		meta, err := rp.LastScanMeta(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"coverage":         meta.Coverage,
+			"blocked_fraction": meta.BlockedFraction,
+			"open_fraction":    1 - meta.Coverage - meta.BlockedFraction,
+		}, nil
+	case "get_dropped_points":
+		meta, err := rp.LastScanMeta(ctx)
+		if err != nil {
+			return nil, err
+		}
+		dropped := make(map[string]interface{}, len(meta.DroppedPoints))
+		for stage, count := range meta.DroppedPoints {
+			dropped[stage] = count
+		}
+		return map[string]interface{}{"dropped_points": dropped}, nil
+	case "get_timing_health":
+		meta, err := rp.LastScanMeta(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return rp.timingHealth(meta), nil
+	case "get_scan_modes":
+		infos := rp.device.supportedScanModes()
+		sampleRates := make(map[string]interface{}, len(infos))
+		for _, info := range infos {
+			sampleRates[string(info.mode)] = info.samplesPerSec
+		}
+		return map[string]interface{}{"scan_modes": sampleRates}, nil
+	default:
+		return nil, errors.Errorf("unknown command %q", name)
+	}
+}
+
+// Images returns a top-down render of the current cached point cloud. See RenderTopDown.
 func (rp *rplidar) Images(ctx context.Context) ([]camera.NamedImage, resource.ResponseMetadata, error) {
-	return nil, resource.ResponseMetadata{}, errors.New("images unimplemented")
+	img, capturedAt, err := rp.renderCachedPointCloud(ctx)
+	if err != nil {
+		return nil, resource.ResponseMetadata{}, err
+	}
+	return []camera.NamedImage{{Image: img, SourceName: rp.Name().Name}}, resource.ResponseMetadata{CapturedAt: capturedAt}, nil
 }
 
-// Properties returns information regarding the output of the RPLiDAR, in this case that it returns PCDs.
+// Properties returns information regarding the output of the RPLiDAR, in this case that it returns PCDs and
+// top-down renders of them as images.
 func (rp *rplidar) Properties(ctx context.Context) (camera.Properties, error) {
 	props := camera.Properties{
 		SupportsPCD: true,
+		ImageType:   camera.ColorStream,
 	}
 	return props, nil
 }
@@ -314,17 +1784,43 @@ func (rp *rplidar) Projector(ctx context.Context) (transform.Projector, error) {
 	return nil, errors.New("projector unimplemented")
 }
 
-// Stream is a part of the Camera interface but is not implemented for the RPLiDAR.
+// Stream returns a stream of top-down renders of the cached point cloud. See RenderTopDown.
 func (rp *rplidar) Stream(ctx context.Context, errHandlers ...gostream.ErrorHandler) (gostream.VideoStream, error) {
-	return nil, errors.New("stream unimplemented")
+	return gostream.NewEmbeddedVideoStreamFromReader(gostream.VideoReaderFunc(
+		func(ctx context.Context) (image.Image, func(), error) {
+			img, _, err := rp.renderCachedPointCloud(ctx)
+			return img, func() {}, err
+		})), nil
+}
+
+// renderCachedPointCloud renders the current cached point cloud top-down and returns the time it was captured.
+func (rp *rplidar) renderCachedPointCloud(ctx context.Context) (image.Image, time.Time, error) {
+	pc, err := rp.NextPointCloud(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	img, err := RenderTopDown(pc, defaultRenderPxPerMeter, defaultRenderSize)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	rp.cache.mutex.RLock()
+	capturedAt := rp.cache.meta.Timestamp
+	rp.cache.mutex.RUnlock()
+	return img, capturedAt, nil
 }
 
-// Close stops the RPLiDAR and disposes of the driver.
+// Close stops the RPLiDAR, releases its serial port and removes the lock file, so that the device can be opened
+// again right away, by this or another process.
 func (rp *rplidar) Close(ctx context.Context) error {
 
-	// Close background process
+	// Close background process, after a scan request that is starting the rplidar finished starting it
+	rp.startMutex.Lock()
 	rp.cancelFunc()
+	rp.startMutex.Unlock()
 	rp.cacheBackgroundWorkers.Wait()
+	rp.rawMeasurements.register(nil)
+	rp.setState(StateClosed)
 	rp.cache.mutex.Lock()
 	defer rp.cache.mutex.Unlock()
 
@@ -341,16 +1837,26 @@ func (rp *rplidar) Close(ctx context.Context) error {
 		}
 		rp.device.driver.Stop()
 		// Stop the motor
-		// Note: S1 RPLiDAR do not require the motor to be stopped during closeout
-		if rplidarModelByteMap[rp.device.model] != S1 {
+		if rp.controlsMotor() {
 			rp.logger.Debug("stopping motor")
 			rp.device.driver.StopMotor()
 		}
 
+		// Close the serial port before disposing of the driver so the OS handle is released right away
+		rp.device.driver.Disconnect()
 		gen.RPlidarDriverDisposeDriver(rp.device.driver)
 		rp.device.driver = nil
 	}
 
+	stats := rp.Stats()
+	rp.logger.Infof("closing after %v: %d scans, %d failed scans, %d buffer overflows, %d protocol switches, "+
+		"%d reconnects, %d USB resets", stats.Uptime.Round(time.Second), stats.Scans, stats.FailedScans,
+		stats.Overflows, stats.ProtocolSwitches, stats.Reconnects, stats.USBResets)
+
+	if err := rp.blackBox.close(); err != nil {
+		rp.logger.Errorf("failed to close the black box: %v", err)
+	}
+
 	if _, err := os.Stat(rp.lockFilePath); err == nil {
 		if err := os.Remove(rp.lockFilePath); err != nil {
 			return err
@@ -360,7 +1866,34 @@ func (rp *rplidar) Close(ctx context.Context) error {
 	return nil
 }
 
-func pointFrom(yaw, pitch, distance float64, reflectivity uint8) (r3.Vector, pointcloud.Data) {
+// OriginOffset is the position of the sensor in the frame of the point clouds, in millimeters.
+type OriginOffset struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// finite reports whether all coordinates of the offset are finite numbers.
+func (o OriginOffset) finite() bool {
+	for _, v := range []float64{o.X, o.Y, o.Z} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// vector returns the offset as a vector, which is zero if no offset is configured.
+func (o *OriginOffset) vector() r3.Vector {
+	if o == nil {
+		return r3.Vector{}
+	}
+	return r3.Vector{X: o.X, Y: o.Y, Z: o.Z}
+}
+
+// pointFrom converts a polar measurement into a point in millimeters. The handedness is applied as the very
+// last step, after the point has been rotated into the sensor frame.
+func pointFrom(yaw, pitch, distance float64, reflectivity uint8, handedness string) (r3.Vector, pointcloud.Data) {
 	ea := spatialmath.NewEulerAngles()
 	ea.Yaw = yaw
 	ea.Pitch = pitch
@@ -373,6 +1906,11 @@ func pointFrom(yaw, pitch, distance float64, reflectivity uint8) (r3.Vector, poi
 	// about the Z value.
 	p.X = -p.X
 
+	// Mirror across the X axis to switch from the default right-handed to a left-handed convention.
+	if handedness == leftHanded {
+		p.Y = -p.Y
+	}
+
 	pos := pointcloud.NewVector(p.X*1000, p.Y*1000, p.Z*1000)
 	d := pointcloud.NewBasicData()
 	d.SetIntensity(uint16(reflectivity) * 255)
@@ -383,6 +1921,7 @@ func pointFrom(yaw, pitch, distance float64, reflectivity uint8) (r3.Vector, poi
 // checkLockFiles compares the current process and device_path to rplidar.lock files to see if any ongoing
 // sessions for that device path still exist
 func checkLockFiles(devicePath string) (string, error) {
+	deviceName := lockFileDeviceName(devicePath)
 
 	// Get rplidar related processes
 	rplidarProcesses, err := getRplidarProcesses()
@@ -411,10 +1950,10 @@ func checkLockFiles(devicePath string) (string, error) {
 		for _, oldProc := range oldProcesses {
 			if strings.Contains(lockFileName, fmt.Sprintf("pid%v", oldProc)) {
 				matchFound = true
-				if strings.Contains(lockFileName, fmt.Sprintf("dv%v", devicePath[devicePathPrefixOffset:])) {
-					return "", errors.Errorf("another rplidar-module process using the same serial_path has been found, "+
+				if strings.Contains(lockFileName, fmt.Sprintf("dv%v", deviceName)) {
+					return "", fmt.Errorf("%w: another rplidar-module process using the same serial_path has been found, "+
 						"possibly from an incomplete closure of a previous session. To use this serial path again, kill "+
-						"the old process by running 'sudo kill -9 <PID>' (PID(s): %v)", oldProc)
+						"the old process by running 'sudo kill -9 <PID>' (PID(s): %v)", ErrDeviceBusy, oldProc)
 				}
 
 			}
@@ -428,7 +1967,7 @@ func checkLockFiles(devicePath string) (string, error) {
 	}
 
 	// Create lock file for current session
-	newLockFile := rplidarModuleLockDir + fmt.Sprintf(rplidarModuleLockFileName, currentProcess, devicePath[devicePathPrefixOffset:])
+	newLockFile := rplidarModuleLockDir + fmt.Sprintf(rplidarModuleLockFileName, currentProcess, deviceName)
 	f, err := os.Create(newLockFile)
 	if err != nil {
 		return "", errors.Wrapf(err, "could not create lock file")
@@ -441,6 +1980,13 @@ func checkLockFiles(devicePath string) (string, error) {
 	return newLockFile, nil
 }
 
+// lockFileDeviceName returns the name used to identify a device in lock file names. Links are resolved first,
+// so that different paths to the same device (ex. a by-id path and /dev/ttyUSB0) share the same lock.
+func lockFileDeviceName(devicePath string) string {
+	name := strings.TrimPrefix(resolveDeviceLink(devicePath), "/dev/")
+	return strings.ReplaceAll(name, "/", "_")
+}
+
 // getRplidarProcesses returns the PIDs of the rplidar-module processes
 func getRplidarProcesses() ([]int, error) {
 	allProcesses, err := ps.Processes()
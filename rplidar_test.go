@@ -1,16 +1,24 @@
 package rplidar
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"math"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/golang/geo/r3"
+	"go.opencensus.io/trace"
 	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/data"
+	"go.viam.com/rdk/logging"
 	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils"
 	"go.viam.com/rplidar/gen"
 	"go.viam.com/rplidar/inject"
 	"go.viam.com/test"
@@ -45,44 +53,1015 @@ func TestValidate(t *testing.T) {
 		test.That(t, err.Error(), test.ShouldEqual, "min_range must be positive")
 		test.That(t, deps, test.ShouldBeNil)
 	})
+	t.Run("valid handedness", func(t *testing.T) {
+		for _, handedness := range []string{"", "right", "left"} {
+			cfg := Config{
+				Handedness: handedness,
+			}
+
+			deps, err := cfg.Validate("")
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, deps, test.ShouldBeNil)
+		}
+	})
+	t.Run("negative device index", func(t *testing.T) {
+		cfg := Config{
+			DeviceIndex: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "device_index must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("device index with serial path", func(t *testing.T) {
+		cfg := Config{
+			SerialPath:  "/dev/ttyUSB0",
+			DeviceIndex: 1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "device_index cannot be combined with serial_path")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("invalid handedness", func(t *testing.T) {
+		cfg := Config{
+			Handedness: "up",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "handedness must be either")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative nearest per sector", func(t *testing.T) {
+		cfg := Config{
+			NearestPerSector: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "nearest_per_sector must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative target points per sec", func(t *testing.T) {
+		cfg := Config{
+			TargetPointsPerSec: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "target_points_per_sec must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("invalid express protocol", func(t *testing.T) {
+		cfg := Config{
+			ExpressProtocol: "boost",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "express_protocol must be one of")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("force scan with an express protocol", func(t *testing.T) {
+		cfg := Config{
+			ForceScan:       true,
+			ExpressProtocol: expressProtocolExtended,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "force_scan cannot be combined with an express_protocol")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative discard first scans", func(t *testing.T) {
+		discardFirstScans := -1
+		cfg := Config{
+			DiscardFirstScans: &discardFirstScans,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "discard_first_scans must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("unknown model policies", func(t *testing.T) {
+		for _, policy := range []string{"", "warn", "error", "assume A3", "assume  S1"} {
+			cfg := Config{OnUnknownModel: policy}
+			_, err := cfg.Validate("")
+			test.That(t, err, test.ShouldBeNil)
+		}
+		for _, policy := range []string{"ignore", "assume", "assume C1", "warn A1"} {
+			cfg := Config{OnUnknownModel: policy}
+			_, err := cfg.Validate("")
+			test.That(t, err, test.ShouldNotBeNil)
+			test.That(t, err.Error(), test.ShouldContainSubstring, "on_unknown_model must be one of")
+		}
+	})
+	t.Run("unknown motor control", func(t *testing.T) {
+		cfg := Config{
+			MotorControl: "pwm",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, `motor_control must be either "sdk" or "external"`)
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("degrade after errors with force scan", func(t *testing.T) {
+		cfg := Config{
+			DegradeAfterErrors: 3,
+			ForceScan:          true,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "degrade_after_errors cannot be combined with force_scan")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("invalid out of order policy", func(t *testing.T) {
+		cfg := Config{
+			OutOfOrderPolicy: "sort",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, `out_of_order_policy must be one of "drop", "clamp" or "keep"`)
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("start angle out of range", func(t *testing.T) {
+		startAngleDeg := 360.
+		cfg := Config{
+			StartAngleDeg: &startAngleDeg,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "start_angle_deg must be at least 0 and below 360")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("invalid axis map", func(t *testing.T) {
+		cfg := Config{
+			AxisMap: "x,x,z",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, `axis_map must use each of x, y and z once, got "x,x,z"`)
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("invalid min firmware version", func(t *testing.T) {
+		cfg := Config{
+			MinFirmwareVersion: "1.5",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual,
+			`min_firmware_version must be major.minor with a two digit minor version, e.g. "1.29", got "1.5"`)
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("invalid keep fraction", func(t *testing.T) {
+		for _, keepFraction := range []float64{0, 1.5} {
+			keepFraction := keepFraction
+			cfg := Config{
+				KeepFraction: &keepFraction,
+			}
+
+			deps, err := cfg.Validate("")
+			test.That(t, err, test.ShouldNotBeNil)
+			test.That(t, err.Error(), test.ShouldEqual, "keep_fraction must be above 0 and at most 1")
+			test.That(t, deps, test.ShouldBeNil)
+		}
+	})
+	t.Run("invalid trigger mode", func(t *testing.T) {
+		cfg := Config{
+			TriggerMode: "gpio",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, `trigger_mode must be either "free_running" or "external"`)
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("external trigger with min scan interval", func(t *testing.T) {
+		cfg := Config{
+			TriggerMode:       triggerModeExternal,
+			MinScanIntervalMs: 100,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "trigger_mode external cannot be combined with min_scan_interval_ms")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative overlong scan margin", func(t *testing.T) {
+		cfg := Config{
+			OverlongScanMargin: -0.1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "overlong_scan_margin cannot be negative")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("keep fraction with target points per sec", func(t *testing.T) {
+		keepFraction := 0.5
+		cfg := Config{
+			KeepFraction:       &keepFraction,
+			TargetPointsPerSec: 1000,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "keep_fraction and target_points_per_sec cannot be set together")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative max arrival jitter", func(t *testing.T) {
+		cfg := Config{
+			MaxArrivalJitterMs: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "max_arrival_jitter_ms must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative grabber cpu", func(t *testing.T) {
+		cfg := Config{
+			GrabberCPUs: []int{0, -1},
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "grabber_cpus must not be negative")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("invalid rotation sense", func(t *testing.T) {
+		cfg := Config{
+			RotationSense: "cw",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, `rotation_sense must be "clockwise" or "counterclockwise"`)
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative preview decimation", func(t *testing.T) {
+		cfg := Config{
+			PreviewDecimation: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "preview_decimation cannot be negative")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("non finite origin offset", func(t *testing.T) {
+		cfg := Config{
+			OriginOffset: &OriginOffset{X: math.Inf(1)},
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "origin_offset must be finite")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("probe modes with force scan", func(t *testing.T) {
+		cfg := Config{
+			ProbeModes: true,
+			ForceScan:  true,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "probe_modes cannot be combined with force_scan")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative reconnect after errors", func(t *testing.T) {
+		cfg := Config{
+			ReconnectAfterErrors: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "reconnect_after_errors must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("usb reset without reconnect", func(t *testing.T) {
+		cfg := Config{
+			USBResetOnFailure: true,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "usb_reset_on_failure requires reconnect_after_errors")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("usb reset attempts without usb reset", func(t *testing.T) {
+		cfg := Config{
+			ReconnectAfterErrors:  3,
+			USBResetAfterAttempts: 2,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "usb_reset_after_attempts requires usb_reset_on_failure")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative smoothing bins", func(t *testing.T) {
+		cfg := Config{
+			SmoothingBins: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "smoothing_bins must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("smoothing window out of range", func(t *testing.T) {
+		cfg := Config{
+			SmoothingBins:      4,
+			SmoothingWindowDeg: 361,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "smoothing_window_deg must be between 0 and 360")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("invalid smoothing method", func(t *testing.T) {
+		cfg := Config{
+			SmoothingBins:   4,
+			SmoothingMethod: "mode",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "smoothing_method must be either \"median\" or \"mean\"")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("smoothing window without bins", func(t *testing.T) {
+		cfg := Config{
+			SmoothingWindowDeg: 5,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "smoothing_window_deg and smoothing_method require smoothing_bins")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative rotation period window", func(t *testing.T) {
+		cfg := Config{
+			RotationPeriodWindow: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "rotation_period_window must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("blank below without blank sectors", func(t *testing.T) {
+		cfg := Config{
+			BlankBelowMM: 100,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "blank_below_mm and blank_sectors must be set together")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("blank sector out of range", func(t *testing.T) {
+		cfg := Config{
+			BlankBelowMM: 100,
+			BlankSectors: []BlankSector{{StartDeg: 10, EndDeg: 360}},
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "blank_sectors angles must be at least 0 and less than 360")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("black box size without a path", func(t *testing.T) {
+		cfg := Config{
+			BlackBoxSizeMB: 4,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "black_box_size_mb requires black_box_path")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("angle offset out of range", func(t *testing.T) {
+		cfg := Config{
+			AngleOffsetDeg: 181,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "angle_offset_deg must be between -180 and 180")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("unknown point cloud backend", func(t *testing.T) {
+		cfg := Config{
+			PointCloudBackend: "octree",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, `pointcloud_backend must be one of "basic", "kdtree" or "rounding"`)
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative motor ramp", func(t *testing.T) {
+		cfg := Config{
+			MotorRampMs: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "motor_ramp_ms must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative serial timeout", func(t *testing.T) {
+		cfg := Config{
+			SerialTimeoutMs: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "serial_timeout_ms must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative construct timeout", func(t *testing.T) {
+		cfg := Config{
+			ConstructTimeoutMs: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "construct_timeout_ms must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("no scans discarded", func(t *testing.T) {
+		discardFirstScans := 0
+		cfg := Config{
+			DiscardFirstScans: &discardFirstScans,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("min coverage out of range", func(t *testing.T) {
+		cfg := Config{
+			MinCoverage: 1.5,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "min_coverage must be between 0 and 1")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("negative min scan interval", func(t *testing.T) {
+		cfg := Config{
+			MinScanIntervalMs: -1,
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "min_scan_interval_ms must be positive")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("invalid scan interval policy", func(t *testing.T) {
+		cfg := Config{
+			ScanIntervalPolicy: "drop",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "scan_interval_policy must be either")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("invalid buffer policy", func(t *testing.T) {
+		cfg := Config{
+			BufferPolicy: "newest",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, `buffer_policy must be one of "oldest", "latest" or "block"`)
+		test.That(t, deps, test.ShouldBeNil)
+	})
+	t.Run("invalid quality encoding", func(t *testing.T) {
+		cfg := Config{
+			QualityEncoding: "float",
+		}
+
+		deps, err := cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "quality_encoding must be one of")
+		test.That(t, deps, test.ShouldBeNil)
+	})
+}
+
+func TestPointFrom(t *testing.T) {
+	// A return 1m away at 90 degrees from the sensor's zero angle.
+	yaw := utils.DegToRad(90)
+
+	t.Run("default handedness matches right-handed output", func(t *testing.T) {
+		defaultPos, _ := pointFrom(yaw, 0, 1, 255, "")
+		rightPos, _ := pointFrom(yaw, 0, 1, 255, rightHanded)
+		test.That(t, defaultPos, test.ShouldResemble, rightPos)
+	})
+
+	t.Run("right-handed", func(t *testing.T) {
+		pos, data := pointFrom(yaw, 0, 1, 255, rightHanded)
+		test.That(t, pos.X, test.ShouldAlmostEqual, 0)
+		test.That(t, pos.Y, test.ShouldAlmostEqual, 1000)
+		test.That(t, pos.Z, test.ShouldAlmostEqual, 0)
+		test.That(t, data.Intensity(), test.ShouldEqual, uint16(255*255))
+	})
+
+	t.Run("left-handed", func(t *testing.T) {
+		pos, _ := pointFrom(yaw, 0, 1, 255, leftHanded)
+		test.That(t, pos.X, test.ShouldAlmostEqual, 0)
+		test.That(t, pos.Y, test.ShouldAlmostEqual, -1000)
+		test.That(t, pos.Z, test.ShouldAlmostEqual, 0)
+	})
+
+	t.Run("left-handed only mirrors Y", func(t *testing.T) {
+		yaw := utils.DegToRad(30)
+		rightPos, _ := pointFrom(yaw, 0, 2, 255, rightHanded)
+		leftPos, _ := pointFrom(yaw, 0, 2, 255, leftHanded)
+		test.That(t, leftPos.X, test.ShouldAlmostEqual, rightPos.X)
+		test.That(t, leftPos.Y, test.ShouldAlmostEqual, -rightPos.Y)
+		test.That(t, leftPos.Z, test.ShouldAlmostEqual, rightPos.Z)
+	})
+}
+
+func TestScan(t *testing.T) {
+	ctx := context.Background()
+
+	// Create injected rplidar driver
+	injectedRPlidarDriver := inject.NewRPLiDARDriver()
+
+	injectedRPlidarDriver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+		return uint(gen.RESULT_OPERATION_FAIL)
+	}
+
+	injectedRplidarDevice := rplidarDevice{
+		driver: &injectedRPlidarDriver,
+	}
+
+	// Create injected node
+	injectedNode := inject.NewRPLiDARNodes()
+
+	rp := &rplidar{
+		device: &injectedRplidarDevice,
+		nodes:  &injectedNode,
+	}
+
+	t.Run("invalid rplidar driver with zero scan count", func(t *testing.T) {
+		pc, info, err := rp.scan(ctx, 0)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldEqual, nil)
+		test.That(t, info.samples, test.ShouldEqual, 0)
+	})
+
+	t.Run("invalid rplidar driver with non-zero scan count", func(t *testing.T) {
+		pc, _, err := rp.scan(ctx, 1)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "bad scan")
+		test.That(t, pc, test.ShouldEqual, nil)
+	})
+
+	t.Run("buffer overflow is discarded and the scan continues", func(t *testing.T) {
+		overflowingRPlidarDriver := inject.NewRPLiDARDriver()
+		var grabCount int
+		overflowingRPlidarDriver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+			grabCount++
+			// The first grab fills the whole node buffer, the next is empty so no nodes need to be read back.
+			*(a[0].([]interface{})[1].(*int64)) = 0
+			if grabCount == 1 {
+				*(a[0].([]interface{})[1].(*int64)) = defaultNodeSize
+			}
+			return uint(gen.RESULT_OK)
+		}
+
+		rp := &rplidar{
+			device: &rplidarDevice{driver: &overflowingRPlidarDriver},
+			nodes:  &injectedNode,
+			logger: logging.NewTestLogger(t),
+		}
+
+		pc, _, err := rp.scan(ctx, 1)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldEqual, nil)
+		test.That(t, grabCount, test.ShouldEqual, 2)
+		test.That(t, rp.stats.overflowCount(), test.ShouldEqual, 1)
+	})
+
+	t.Run("persistent buffer overflows fail the scan", func(t *testing.T) {
+		overflowingRPlidarDriver := inject.NewRPLiDARDriver()
+		overflowingRPlidarDriver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+			*(a[0].([]interface{})[1].(*int64)) = defaultNodeSize
+			return uint(gen.RESULT_OK)
+		}
+
+		rp := &rplidar{
+			device: &rplidarDevice{driver: &overflowingRPlidarDriver},
+			nodes:  &injectedNode,
+			logger: logging.NewTestLogger(t),
+		}
+
+		pc, _, err := rp.scan(ctx, 1)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "consecutive buffer overflows")
+		test.That(t, pc, test.ShouldEqual, nil)
+		test.That(t, rp.stats.overflowCount(), test.ShouldEqual, defaultMaxOverflowRetries+1)
+	})
+
+	t.Run("timed out grab uses the serial timeout", func(t *testing.T) {
+		timingOutRPlidarDriver := inject.NewRPLiDARDriver()
+		var timeoutMs uint
+		timingOutRPlidarDriver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+			timeoutMs = a[0].([]interface{})[2].(uint)
+			return uint(gen.RESULT_OPERATION_TIMEOUT)
+		}
+
+		rp := &rplidar{
+			device: &rplidarDevice{driver: &timingOutRPlidarDriver, timeoutMs: 250},
+			nodes:  &injectedNode,
+			logger: logging.NewTestLogger(t),
+		}
+
+		pc, _, err := rp.scan(ctx, 1)
+		test.That(t, errors.Is(err, ErrSerialTimeout), test.ShouldBeTrue)
+		test.That(t, pc, test.ShouldEqual, nil)
+		test.That(t, timeoutMs, test.ShouldEqual, 250)
+	})
+
+	t.Run("grabs are traced", func(t *testing.T) {
+		exporter := &spanRecorder{}
+		trace.RegisterExporter(exporter)
+		defer trace.UnregisterExporter(exporter)
+
+		ctx, span := trace.StartSpan(ctx, "test", trace.WithSampler(trace.AlwaysSample()))
+		_, _, err := rp.scan(ctx, 1)
+		span.End()
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, exporter.names(), test.ShouldResemble, []string{"rplidar::scan::grab", "rplidar::scan", "test"})
+	})
+}
+
+// spanRecorder records the names of the spans it exports, in the order they ended.
+type spanRecorder struct {
+	mutex sync.Mutex
+	spans []string
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.spans = append(r.spans, s.Name)
+}
+
+func (r *spanRecorder) names() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.spans...)
+}
+
+func TestFilterMeasurements(t *testing.T) {
+	measurements := []measurement{
+		{angleDeg: 10, distanceMM: 50},
+		{angleDeg: 20, distanceMM: 500},
+		{angleDeg: 30, distanceMM: 600},
+		{angleDeg: 200, distanceMM: 700},
+	}
+
+	t.Run("no filters", func(t *testing.T) {
+		rp := rplidar{}
+		dropped := map[string]int{}
+		filtered := rp.filterMeasurements(append([]measurement(nil), measurements...), dropped)
+		test.That(t, filtered, test.ShouldResemble, measurements)
+		test.That(t, dropped, test.ShouldBeEmpty)
+	})
+
+	t.Run("every filter counts what it removed", func(t *testing.T) {
+		rp := rplidar{minRangeMM: 100, nearestPerSector: 2}
+		dropped := map[string]int{}
+		filtered := rp.filterMeasurements(append([]measurement(nil), measurements...), dropped)
+		test.That(t, filtered, test.ShouldResemble, []measurement{
+			{angleDeg: 20, distanceMM: 500},
+			{angleDeg: 200, distanceMM: 700},
+		})
+		test.That(t, dropped, test.ShouldResemble, map[string]int{"min_range_mm": 1, "nearest_per_sector": 1})
+	})
+
+	t.Run("zero quality returns are only dropped when not kept", func(t *testing.T) {
+		withQuality := []measurement{
+			{angleDeg: 10, distanceMM: 500, quality: 0},
+			{angleDeg: 20, distanceMM: 500, quality: 4},
+		}
+		rp := rplidar{}
+		dropped := map[string]int{}
+		filtered := rp.filterMeasurements(append([]measurement(nil), withQuality...), dropped)
+		test.That(t, filtered, test.ShouldResemble, withQuality)
+
+		rp.dropZeroQuality = true
+		filtered = rp.filterMeasurements(append([]measurement(nil), withQuality...), dropped)
+		test.That(t, filtered, test.ShouldResemble, withQuality[1:])
+		test.That(t, dropped, test.ShouldResemble, map[string]int{"keep_zero_quality": 1})
+	})
+
+	t.Run("near returns are only blanked within the blank sectors", func(t *testing.T) {
+		rp := rplidar{blankBelowMM: 650, blankSectors: []BlankSector{{StartDeg: 350, EndDeg: 25}}}
+		dropped := map[string]int{}
+		filtered := rp.filterMeasurements(append([]measurement(nil), measurements...), dropped)
+		test.That(t, filtered, test.ShouldResemble, []measurement{
+			{angleDeg: 30, distanceMM: 600},
+			{angleDeg: 200, distanceMM: 700},
+		})
+		test.That(t, dropped, test.ShouldResemble, map[string]int{"blank_below_mm": 2})
+	})
 }
 
-func TestScan(t *testing.T) {
-	ctx := context.Background()
+func TestLockFileDeviceName(t *testing.T) {
+	test.That(t, lockFileDeviceName("/dev/ttyUSB0"), test.ShouldEqual, "ttyUSB0")
+	test.That(t, lockFileDeviceName("/dev/serial/by-id/usb-rplidar-port0"), test.ShouldEqual, "serial_by-id_usb-rplidar-port0")
 
-	// Create injected rplidar driver
-	injectedRPlidarDriver := inject.NewRPLiDARDriver()
+	dir := t.TempDir()
+	device := filepath.Join(dir, "ttyUSB0")
+	f, err := os.Create(device)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, f.Close(), test.ShouldBeNil)
+	link := filepath.Join(dir, "usb-rplidar-port0")
+	test.That(t, os.Symlink(device, link), test.ShouldBeNil)
+	test.That(t, lockFileDeviceName(link), test.ShouldEqual, lockFileDeviceName(device))
+}
 
-	injectedRPlidarDriver.GrabScanDataHqFunc = func(a ...interface{}) uint {
-		return uint(gen.RESULT_OPERATION_FAIL)
-	}
-	injectedRPlidarDriver.AscendScanDataFunc = func(a ...interface{}) uint {
-		return 0
-	}
+func TestIsOverflow(t *testing.T) {
+	test.That(t, isOverflow(ResultOk, 100), test.ShouldBeFalse)
+	test.That(t, isOverflow(ResultOk, defaultNodeSize), test.ShouldBeTrue)
+	test.That(t, isOverflow(ResultInsufficientMemory, 0), test.ShouldBeFalse)
+	test.That(t, isOverflow(ResultOpTimeout, 0), test.ShouldBeFalse)
+}
 
-	injectedRplidarDevice := rplidarDevice{
-		driver: &injectedRPlidarDriver,
+func TestReadings(t *testing.T) {
+	ctx := context.Background()
+	rp := rplidar{
+		device: &rplidarDevice{
+			serialNumber: "ABC123",
+			scanModeName: "Sensitivity",
+			healthStatus: gen.RPLIDAR_STATUS_OK,
+		},
 	}
 
-	// Create injected node
-	injectedNode := inject.NewRPLiDARNodes()
+	t.Run("before the first scan", func(t *testing.T) {
+		readings, err := rp.Readings(ctx, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, readings, test.ShouldResemble, map[string]interface{}{
+			"health":                "ok",
+			"serial_number":         "ABC123",
+			"scan_mode":             "Sensitivity",
+			"scan_rate_hz":          0.,
+			"last_scan_point_count": 0,
+			"reconnect_count":       0,
+		})
+	})
 
-	rp := &rplidar{
-		device: &injectedRplidarDevice,
-		nodes:  &injectedNode,
+	t.Run("after scans", func(t *testing.T) {
+		startTime := time.Now()
+		rp.stats.addScan(startTime, 100)
+		rp.stats.addScan(startTime.Add(100*time.Millisecond), 120)
+
+		readings, err := rp.Readings(ctx, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, readings["scan_rate_hz"], test.ShouldAlmostEqual, 10.)
+		test.That(t, readings["last_scan_point_count"], test.ShouldEqual, 120)
+		test.That(t, readings["reconnect_count"], test.ShouldEqual, 0)
+	})
+
+	t.Run("after reconnects", func(t *testing.T) {
+		rp.stats.addReconnect()
+		rp.stats.addReconnect()
+
+		readings, err := rp.Readings(ctx, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, readings["reconnect_count"], test.ShouldEqual, 2)
+	})
+}
+
+func TestRotationPeriod(t *testing.T) {
+	ctx := context.Background()
+	rp := rplidar{stats: scanStats{periodWindow: 3}}
+
+	_, err := rp.RotationPeriod(ctx)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	startTime := time.Now()
+	scanTime := startTime
+	for _, period := range []time.Duration{100, 110, 90, 130} {
+		scanTime = scanTime.Add(period * time.Millisecond)
+		rp.stats.addScan(scanTime, 100)
 	}
+	// The first scan has no revolution before it, leaving 110, 90 and 130ms in the window.
+	period, err := rp.RotationPeriod(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, period, test.ShouldEqual, 110*time.Millisecond)
+	scanRateHz, _ := rp.stats.lastScan()
+	test.That(t, scanRateHz, test.ShouldAlmostEqual, 1/0.11)
 
-	t.Run("invalid rplidar driver with zero scan count", func(t *testing.T) {
-		pc, err := rp.scan(ctx, 0)
+	// The time across a failed scan is not a rotation period.
+	rp.stats.setLastError(errors.New("bad scan"))
+	rp.stats.addScan(scanTime.Add(time.Second), 100)
+	period, err = rp.RotationPeriod(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, period, test.ShouldEqual, 110*time.Millisecond)
+}
+
+func TestDoCommand(t *testing.T) {
+	ctx := context.Background()
+	rp := rplidar{device: &rplidarDevice{}}
+
+	t.Run("missing command", func(t *testing.T) {
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, resp, test.ShouldBeNil)
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "fly"})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "unknown command")
+		test.That(t, resp, test.ShouldBeNil)
+	})
+
+	t.Run("start when already started", func(t *testing.T) {
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "start"})
 		test.That(t, err, test.ShouldBeNil)
-		test.That(t, pc, test.ShouldEqual, nil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{})
 	})
 
-	t.Run("invalid rplidar driver with non-zero scan count", func(t *testing.T) {
-		pc, err := rp.scan(ctx, 1)
+	t.Run("standby when not started", func(t *testing.T) {
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "standby"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{})
+	})
+
+	t.Run("get overflow count", func(t *testing.T) {
+		rp.stats.addOverflow()
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_overflow_count"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{"overflow_count": 1})
+	})
+
+	t.Run("set motion ok", func(t *testing.T) {
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "set_motion_ok", "motion_ok": false})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{})
+		test.That(t, rp.scanGate.allows(), test.ShouldBeFalse)
+
+		_, err = rp.DoCommand(ctx, map[string]interface{}{"command": "set_motion_ok", "motion_ok": true})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, rp.scanGate.allows(), test.ShouldBeTrue)
+
+		_, err = rp.DoCommand(ctx, map[string]interface{}{"command": "set_motion_ok"})
 		test.That(t, err, test.ShouldNotBeNil)
-		test.That(t, err.Error(), test.ShouldContainSubstring, "bad scan")
-		test.That(t, pc, test.ShouldEqual, nil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "requires a boolean")
+	})
+
+	t.Run("trigger", func(t *testing.T) {
+		_, err := rp.DoCommand(ctx, map[string]interface{}{"command": "trigger"})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "trigger requires trigger_mode external")
+
+		rp := rplidar{device: &rplidarDevice{}, scanTrigger: newScanTrigger(triggerModeExternal)}
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "trigger", "time": "2026-10-15T12:00:00.5Z"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{})
+		test.That(t, rp.scanTrigger.latest.Equal(time.Date(2026, 10, 15, 12, 0, 0, 5e8, time.UTC)), test.ShouldBeTrue)
+
+		_, err = rp.DoCommand(ctx, map[string]interface{}{"command": "trigger", "time": "noon"})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "RFC 3339")
+	})
+
+	t.Run("get state", func(t *testing.T) {
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_state"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{"state": "connecting", "since": ""})
+
+		since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		rp.state.transition(StateScanning, since)
+		resp, err = rp.DoCommand(ctx, map[string]interface{}{"command": "get_state"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{"state": "scanning", "since": "2024-05-01T12:00:00Z"})
+	})
+
+	t.Run("get last error", func(t *testing.T) {
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_last_error"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{"last_error": ""})
+
+		rp.stats.setLastError(errors.New("bad scan: operation timed out"))
+		resp, err = rp.DoCommand(ctx, map[string]interface{}{"command": "get_last_error"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{"last_error": "bad scan: operation timed out"})
+
+		rp.stats.setLastError(nil)
+		test.That(t, rp.LastError(), test.ShouldBeNil)
+	})
+
+	t.Run("get coverage", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{}}
+		_, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_coverage"})
+		test.That(t, err, test.ShouldNotBeNil)
+
+		rp.cache.meta = ScanMeta{Seq: 1, Coverage: 0.5, BlockedFraction: 0.25}
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_coverage"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{
+			"coverage": 0.5, "blocked_fraction": 0.25, "open_fraction": 0.25,
+		})
+	})
+
+	t.Run("get dropped points", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{}}
+		_, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_dropped_points"})
+		test.That(t, err, test.ShouldNotBeNil)
+
+		rp.cache.meta = ScanMeta{Seq: 1, DroppedPoints: map[string]int{"invalid": 12, "min_range_mm": 40}}
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_dropped_points"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{
+			"dropped_points": map[string]interface{}{"invalid": 12, "min_range_mm": 40},
+		})
+	})
+
+	t.Run("get timing health", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{}, maxArrivalJitterMs: 10}
+		_, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_timing_health"})
+		test.That(t, err, test.ShouldNotBeNil)
+
+		rp.cache.meta = ScanMeta{Seq: 1, InterArrival: 100 * time.Millisecond, ArrivalJitter: 2500 * time.Microsecond}
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_timing_health"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{
+			"inter_arrival_ms": 100., "jitter_ms": 2.5, "max_jitter_ms": 10., "healthy": true,
+		})
+
+		rp.cache.meta.ArrivalJitter = 12 * time.Millisecond
+		resp, err = rp.DoCommand(ctx, map[string]interface{}{"command": "get_timing_health"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["healthy"], test.ShouldEqual, false)
+	})
+
+	t.Run("get scan modes", func(t *testing.T) {
+		rp := rplidar{device: &rplidarDevice{scanModes: []scanModeInfo{{mode: "Standard", samplesPerSec: 4000}}}}
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_scan_modes"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{
+			"scan_modes": map[string]interface{}{"Standard": 4000.},
+		})
+	})
+
+	t.Run("get device info", func(t *testing.T) {
+		rp := rplidar{device: &rplidarDevice{
+			model:            97,
+			serialNumber:     "ABC123",
+			firmwareVersion:  "1.29",
+			hardwareRevision: 18,
+		}}
+		test.That(t, rp.DeviceInfo(), test.ShouldResemble, DeviceInfo{
+			Model:            "S1",
+			ModelID:          97,
+			Family:           FamilyS,
+			SerialNumber:     "ABC123",
+			FirmwareVersion:  "1.29",
+			HardwareRevision: 18,
+		})
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_device_info"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{
+			"model":             "S1",
+			"model_id":          97,
+			"family":            "S-series",
+			"serial_number":     "ABC123",
+			"firmware_version":  "1.29",
+			"hardware_revision": 18,
+		})
+	})
+
+	t.Run("get readings", func(t *testing.T) {
+		resp, err := rp.DoCommand(ctx, map[string]interface{}{"command": "get_readings"})
+		test.That(t, err, test.ShouldBeNil)
+		readings, err := rp.Readings(ctx, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, readings)
 	})
 }
 
@@ -121,23 +1100,304 @@ func TestNextPointCloud(t *testing.T) {
 	})
 }
 
+func TestNextPointCloudPair(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("requires keep_raw_scans", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{rawPointCloud: pointcloud.New()}}
+		_, _, err := rp.NextPointCloudPair(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "keep_raw_scans")
+	})
+
+	t.Run("returns nil pointclouds before a scan is cached", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{}, keepRawScans: true}
+		raw, filtered, err := rp.NextPointCloudPair(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "pointcloud has not been saved yet")
+		test.That(t, raw, test.ShouldBeNil)
+		test.That(t, filtered, test.ShouldBeNil)
+	})
+
+	t.Run("returns the raw and filtered pointclouds of the same scan", func(t *testing.T) {
+		raw := pointcloud.New()
+		test.That(t, raw.Set(r3.Vector{X: 1}, nil), test.ShouldBeNil)
+		test.That(t, raw.Set(r3.Vector{X: 2}, nil), test.ShouldBeNil)
+		filtered := pointcloud.New()
+		test.That(t, filtered.Set(r3.Vector{X: 2}, nil), test.ShouldBeNil)
+		rp := rplidar{cache: &dataCache{pointCloud: filtered, rawPointCloud: raw}, keepRawScans: true}
+
+		gotRaw, gotFiltered, err := rp.NextPointCloudPair(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, gotRaw, test.ShouldEqual, raw)
+		test.That(t, gotFiltered, test.ShouldEqual, filtered)
+	})
+}
+
+func TestDataCapture(t *testing.T) {
+	// The data manager captures NextPointCloud of every camera, including this one, as binary PCD.
+	test.That(t, data.CollectorLookup(data.MethodMetadata{API: camera.API, MethodName: "NextPointCloud"}), test.ShouldNotBeNil)
+
+	scan := pointcloud.New()
+	for _, point := range []r3.Vector{{X: 1200, Y: -300}, {X: -50, Y: 4000}} {
+		d := pointcloud.NewBasicData()
+		setQuality(d, 47, qualityEncodingValue)
+		test.That(t, scan.Set(point, d), test.ShouldBeNil)
+	}
+	rp := rplidar{cache: &dataCache{pointCloud: scan}}
+	pc, err := rp.NextPointCloud(context.Background())
+	test.That(t, err, test.ShouldBeNil)
+
+	var buf bytes.Buffer
+	test.That(t, pointcloud.ToPCD(pc, &buf, pointcloud.PCDBinary), test.ShouldBeNil)
+	captured, err := pointcloud.ReadPCD(&buf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, captured.Size(), test.ShouldEqual, 2)
+	_, found := captured.At(1200, -300, 0)
+	test.That(t, found, test.ShouldBeTrue)
+}
+
+func TestPreviewPointCloud(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("requires preview_decimation", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{previewPointCloud: pointcloud.New()}}
+		_, err := rp.NextPreviewPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "preview_decimation")
+	})
+
+	t.Run("a single grab builds the full and the preview point cloud", func(t *testing.T) {
+		nodes := make([]rawNode, 0, 7)
+		for i := 0; i < 7; i++ {
+			nodes = append(nodes, rawNode{angleQ14: uint16(1000 * (i + 1)), distQ2: 4000, quality: 60})
+		}
+		driver := inject.NewRPLiDARDriver()
+		var grabCount int
+		driver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+			grabCount++
+			*(a[0].([]interface{})[1].(*int64)) = int64(len(nodes))
+			return uint(gen.RESULT_OK)
+		}
+		rp := &rplidar{
+			device:            &rplidarDevice{driver: &driver},
+			nodes:             nodesOf(nodes),
+			cache:             &dataCache{},
+			previewDecimation: 3,
+		}
+		defer gen.Delete_measurementNodeHqArray(rp.nodes)
+
+		pc, info, err := rp.scan(ctx, 1)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, grabCount, test.ShouldEqual, 1)
+		test.That(t, pc.Size(), test.ShouldEqual, 7)
+		test.That(t, info.preview.Size(), test.ShouldEqual, 3)
+
+		rp.cache.previewPointCloud = info.preview
+		preview, err := rp.NextPreviewPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, preview, test.ShouldEqual, info.preview)
+	})
+}
+
+func TestScanMissingAngles(t *testing.T) {
+	// A revolution as grabbed, before sorting: it starts with a genuine return at 0 degrees, and the SDK left the
+	// angle of the return at 135 degrees unset.
+	nodes := make([]rawNode, 0, 8)
+	for i := 0; i < 8; i++ {
+		nodes = append(nodes, rawNode{angleQ14: uint16(i * 8192), distQ2: uint(4000 + 4*i)})
+	}
+	nodes[3].angleQ14 = 0
+	driver := inject.NewRPLiDARDriver()
+	driver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+		*(a[0].([]interface{})[1].(*int64)) = int64(len(nodes))
+		return uint(gen.RESULT_OK)
+	}
+	rp := &rplidar{
+		device: &rplidarDevice{driver: &driver},
+		nodes:  nodesOf(nodes),
+		cache:  &dataCache{},
+	}
+	defer gen.Delete_measurementNodeHqArray(rp.nodes)
+
+	pc, info, err := rp.scan(context.Background(), 1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 8)
+	test.That(t, info.dropped["invalid"], test.ShouldEqual, 0)
+	for i, m := range info.measurements {
+		test.That(t, m.AngleDeg, test.ShouldAlmostEqual, float64(i*45))
+		test.That(t, m.DistanceMM, test.ShouldEqual, 1000+i)
+	}
+}
+
+func TestScanOutOfOrderSamples(t *testing.T) {
+	// The fourth sample jitters backward within the revolution.
+	nodes := []rawNode{
+		{angleQ14: 1000, distQ2: 4000},
+		{angleQ14: 2000, distQ2: 4000},
+		{angleQ14: 3000, distQ2: 4000},
+		{angleQ14: 2900, distQ2: 4000},
+		{angleQ14: 4000, distQ2: 4000},
+	}
+	driver := inject.NewRPLiDARDriver()
+	driver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+		*(a[0].([]interface{})[1].(*int64)) = int64(len(nodes))
+		return uint(gen.RESULT_OK)
+	}
+	rp := &rplidar{
+		device: &rplidarDevice{driver: &driver},
+		nodes:  nodesOf(nodes),
+		cache:  &dataCache{},
+	}
+	defer gen.Delete_measurementNodeHqArray(rp.nodes)
+
+	pc, info, err := rp.scan(context.Background(), 1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 4)
+	test.That(t, info.dropped["out_of_order_policy"], test.ShouldEqual, 1)
+	test.That(t, rp.Stats().OutOfOrderSamples, test.ShouldEqual, 1)
+}
+
+func TestOnMeasurements(t *testing.T) {
+	// The second sample has no distance and the fourth one jitters backward.
+	nodes := []rawNode{
+		{angleQ14: 1000, distQ2: 4000, quality: 10},
+		{angleQ14: 2000, distQ2: 0},
+		{angleQ14: 3000, distQ2: 8000, quality: 20},
+		{angleQ14: 2900, distQ2: 4000, quality: 30},
+	}
+	driver := inject.NewRPLiDARDriver()
+	driver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+		*(a[0].([]interface{})[1].(*int64)) = int64(len(nodes))
+		return uint(gen.RESULT_OK)
+	}
+	rp := &rplidar{
+		device:          &rplidarDevice{driver: &driver},
+		nodes:           nodesOf(nodes),
+		cache:           &dataCache{},
+		minRangeMM:      1500,
+		rawMeasurements: newAsyncNotifier[[]Measurement](defaultMeasurementQueueSize, bufferPolicyOldest),
+	}
+	defer gen.Delete_measurementNodeHqArray(rp.nodes)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rp.rawMeasurements.run(ctx)
+	grabs := make(chan []Measurement, 2)
+	rp.OnMeasurements(func(measurements []Measurement) {
+		grabs <- measurements
+	})
+
+	// Both revolutions keep only the return at 2000mm, at the same position.
+	pc, _, err := rp.scan(ctx, 2)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 1)
+
+	// Every grab is delivered sorted, before the out of order policy and min_range_mm drop returns from it.
+	angle := func(q14 uint16) float64 { return float64(q14) * 90 / (1 << 14) }
+	expected := []Measurement{
+		{AngleDeg: angle(1000), DistanceMM: 1000, Quality: 10},
+		{AngleDeg: angle(2900), DistanceMM: 1000, Quality: 30},
+		{AngleDeg: angle(3000), DistanceMM: 2000, Quality: 20},
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case measurements := <-grabs:
+			test.That(t, measurements, test.ShouldResemble, expected)
+		case <-time.After(time.Second):
+			t.Fatalf("grab %d was not delivered", i)
+		}
+	}
+	test.That(t, rp.Stats().MeasurementDrops, test.ShouldEqual, 0)
+}
+
+func TestAngularResolutionDeg(t *testing.T) {
+	ctx := context.Background()
+	rp := rplidar{
+		cache: &dataCache{},
+	}
+
+	t.Run("no scan stored yet", func(t *testing.T) {
+		_, err := rp.AngularResolutionDeg(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "pointcloud has not been saved yet")
+
+		_, err = rp.LastScanMeta(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("resolution of the stored scan", func(t *testing.T) {
+		meta := ScanMeta{Seq: 1, Timestamp: time.Now(), PointCount: 700, AngularResolutionDeg: 0.5}
+		rp.cache.meta = meta
+
+		resolution, err := rp.AngularResolutionDeg(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resolution, test.ShouldEqual, 0.5)
+
+		lastMeta, err := rp.LastScanMeta(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, lastMeta, test.ShouldResemble, meta)
+	})
+}
+
 func TestProperties(t *testing.T) {
 	ctx := context.Background()
 	rp := rplidar{}
 
 	prop, err := rp.Properties(ctx)
 	test.That(t, err, test.ShouldBeNil)
-	test.That(t, prop, test.ShouldResemble, camera.Properties{SupportsPCD: true})
+	test.That(t, prop, test.ShouldResemble, camera.Properties{SupportsPCD: true, ImageType: camera.ColorStream})
+}
+
+func TestParseUnknownModelPolicy(t *testing.T) {
+	policy, model, err := parseUnknownModelPolicy("")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, policy, test.ShouldEqual, unknownModelWarn)
+	test.That(t, model, test.ShouldEqual, A1)
+
+	policy, model, err = parseUnknownModelPolicy("assume S1")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, policy, test.ShouldEqual, unknownModelAssume)
+	test.That(t, model, test.ShouldEqual, S1)
+
+	// An assumed model drives the capabilities of an unknown device, while known models ignore it.
+	device := rplidarDevice{model: 200, assumedModel: model}
+	test.That(t, device.rplidarModel(), test.ShouldEqual, S1)
+	test.That(t, device.info().Model, test.ShouldEqual, "S1")
+	test.That(t, device.info().ModelID, test.ShouldEqual, 200)
+	rp := rplidar{device: &device}
+	test.That(t, rp.controlsMotor(), test.ShouldBeFalse)
+	device.model = 49
+	test.That(t, device.rplidarModel(), test.ShouldEqual, A3)
+}
+
+func TestControlsMotor(t *testing.T) {
+	for _, tc := range []struct {
+		model        byte
+		motorControl string
+		expected     bool
+	}{
+		{model: 24, motorControl: "", expected: true},
+		{model: 24, motorControl: motorControlSDK, expected: true},
+		{model: 24, motorControl: motorControlExternal, expected: false},
+		{model: 97, motorControl: motorControlSDK, expected: false},
+		{model: 97, motorControl: motorControlExternal, expected: false},
+	} {
+		rp := rplidar{device: &rplidarDevice{model: tc.model}, motorControl: tc.motorControl}
+		test.That(t, rp.controlsMotor(), test.ShouldEqual, tc.expected)
+	}
 }
 
 func TestClose(t *testing.T) {
 
 	ctx := context.Background()
+	logger, logs := logging.NewObservedTestLogger(t)
 	rp := rplidar{
 		device:                 &rplidarDevice{},
 		cache:                  &dataCache{},
 		cancelFunc:             func() {},
 		cacheBackgroundWorkers: sync.WaitGroup{},
+		logger:                 logger,
 	}
 	t.Run("no active background workers and or mutex blocking", func(t *testing.T) {
 		err := rp.Close(ctx)
@@ -181,19 +1441,89 @@ func TestClose(t *testing.T) {
 		test.That(t, err, test.ShouldBeNil)
 		test.That(t, time.Since(startTime).Milliseconds(), test.ShouldBeGreaterThanOrEqualTo, 10)
 	})
+
+	t.Run("logs the session stats", func(t *testing.T) {
+		rp.stats.addScan(time.Now(), 100)
+		rp.stats.setLastError(errors.New("bad scan"))
+		rp.stats.addOverflow()
+		rp.stats.addReconnect()
+		rp.stats.addUSBReset()
+
+		err := rp.Close(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		closing := logs.FilterMessageSnippet("closing after").All()
+		test.That(t, closing, test.ShouldNotBeEmpty)
+		test.That(t, closing[len(closing)-1].Message, test.ShouldContainSubstring,
+			"1 scans, 1 failed scans, 1 buffer overflows, 0 protocol switches, 1 reconnects, 1 USB resets")
+	})
 }
 
-func TestUnimplementedFunctions(t *testing.T) {
+func TestStats(t *testing.T) {
+	clk := newFakeClock()
+	rp := rplidar{clock: clk, stats: scanStats{startTime: clk.Now()}}
+	test.That(t, rp.Stats(), test.ShouldResemble, DeviceStats{SDKVersion: SDKVersion()})
+	test.That(t, SDKVersion(), test.ShouldNotBeEmpty)
+
+	rp.stats.addScan(clk.Now(), 100)
+	rp.stats.addScan(clk.Now(), 100)
+	rp.stats.setLastError(errors.New("bad scan"))
+	rp.stats.setLastError(nil)
+	rp.stats.addOverflow()
+	rp.stats.addProtocolSwitch()
+	rp.stats.addOutOfOrder(3)
+	clk.Advance(time.Minute)
+	test.That(t, rp.Stats(), test.ShouldResemble, DeviceStats{
+		Scans:             2,
+		FailedScans:       1,
+		Overflows:         1,
+		ProtocolSwitches:  1,
+		OutOfOrderSamples: 3,
+		Uptime:            time.Minute,
+		SDKVersion:        SDKVersion(),
+	})
+}
+
+func TestImages(t *testing.T) {
 	ctx := context.Background()
-	rp := rplidar{}
+	rp := rplidar{
+		Named: camera.Named("rplidar").AsNamed(),
+		cache: &dataCache{},
+	}
 
-	t.Run("unimplemented Images function", func(t *testing.T) {
-		namedImage, metadata, err := rp.Images(ctx)
+	t.Run("no pointcloud cached yet", func(t *testing.T) {
+		namedImages, metadata, err := rp.Images(ctx)
 		test.That(t, err, test.ShouldNotBeNil)
-		test.That(t, err.Error(), test.ShouldContainSubstring, "unimplemented")
+		test.That(t, err.Error(), test.ShouldEqual, "pointcloud has not been saved yet")
 		test.That(t, metadata, test.ShouldResemble, resource.ResponseMetadata{})
-		test.That(t, namedImage, test.ShouldBeNil)
+		test.That(t, namedImages, test.ShouldBeNil)
+	})
+
+	t.Run("renders the cached pointcloud", func(t *testing.T) {
+		capturedAt := time.Now()
+		rp.cache.pointCloud = pointcloud.New()
+		test.That(t, rp.cache.pointCloud.Set(r3.Vector{X: 1000}, nil), test.ShouldBeNil)
+		rp.cache.meta = ScanMeta{Seq: 1, Timestamp: capturedAt, PointCount: 1}
+
+		namedImages, metadata, err := rp.Images(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, metadata.CapturedAt, test.ShouldEqual, capturedAt)
+		test.That(t, len(namedImages), test.ShouldEqual, 1)
+		test.That(t, namedImages[0].SourceName, test.ShouldEqual, "rplidar")
+		test.That(t, namedImages[0].Image.Bounds().Dx(), test.ShouldEqual, defaultRenderSize)
+
+		stream, err := rp.Stream(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		defer stream.Close(ctx)
+		img, release, err := stream.Next(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		defer release()
+		test.That(t, img, test.ShouldResemble, namedImages[0].Image)
 	})
+}
+
+func TestUnimplementedFunctions(t *testing.T) {
+	ctx := context.Background()
+	rp := rplidar{}
 
 	t.Run("unimplemented Projector function", func(t *testing.T) {
 		proj, err := rp.Projector(ctx)
@@ -202,10 +1532,59 @@ func TestUnimplementedFunctions(t *testing.T) {
 		test.That(t, proj, test.ShouldBeNil)
 	})
 
-	t.Run("unimplemented Stream function", func(t *testing.T) {
-		stream, err := rp.Stream(ctx)
-		test.That(t, err, test.ShouldNotBeNil)
-		test.That(t, err.Error(), test.ShouldContainSubstring, "unimplemented")
-		test.That(t, stream, test.ShouldBeNil)
+}
+
+func TestQualityEncodingIntensity(t *testing.T) {
+	measurements := []measurement{{angleDeg: 0, distanceMM: 1000, quality: 10}}
+	intensityOf := func(rp *rplidar) uint16 {
+		pc := pointcloud.New()
+		test.That(t, rp.addMeasurements(pc, measurements, false), test.ShouldBeNil)
+		var intensity uint16
+		pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			intensity = d.Intensity()
+			return true
+		})
+		return intensity
+	}
+
+	test.That(t, intensityOf(&rplidar{}), test.ShouldEqual, uint16(255*255))
+	test.That(t, intensityOf(&rplidar{qualityEncoding: qualityEncodingValue}), test.ShouldEqual, uint16(255*255))
+	test.That(t, intensityOf(&rplidar{qualityEncoding: qualityEncodingIntensity}), test.ShouldEqual, uint16(10*255))
+}
+
+func TestPartialScanBackend(t *testing.T) {
+	rp := &rplidar{
+		pointCloudBackend: pointCloudBackendKDTree,
+		partialScans:      newAsyncNotifier[pointcloud.PointCloud](defaultPartialScanQueueSize, ""),
+	}
+	rp.partialScans.register(func(pointcloud.PointCloud) {})
+	measurements := []measurement{
+		{angleDeg: 0, distanceMM: 1000, quality: 10},
+		{angleDeg: 50, distanceMM: 1000, quality: 10},
+		{angleDeg: 60, distanceMM: 1000, quality: 10},
+	}
+	test.That(t, rp.addMeasurements(newPointCloud(rp.pointCloudBackend), measurements, true), test.ShouldBeNil)
+
+	// The arcs are built with the configured backend, like the scans they are part of.
+	test.That(t, len(rp.partialScans.queue), test.ShouldEqual, 2)
+	for i := 0; i < 2; i++ {
+		arc := <-rp.partialScans.queue
+		_, ok := arc.(*pointcloud.KDTree)
+		test.That(t, ok, test.ShouldBeTrue)
+	}
+}
+
+func TestOriginOffset(t *testing.T) {
+	rp := rplidar{angleOffsetDeg: 90, handedness: rightHanded, originOffset: r3.Vector{X: 100, Y: 200, Z: 30}}
+	pc := pointcloud.New()
+	test.That(t, rp.addMeasurements(pc, []measurement{{angleDeg: 0, distanceMM: 1000, quality: 10}}, false), test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 1)
+
+	// The angle offset turns the return to the right-handed Y axis first, then the offset moves it unrotated.
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		test.That(t, p.X, test.ShouldAlmostEqual, 100, 1e-6)
+		test.That(t, p.Y, test.ShouldAlmostEqual, 1200, 1e-6)
+		test.That(t, p.Z, test.ShouldAlmostEqual, 30, 1e-6)
+		return true
 	})
 }
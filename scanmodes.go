@@ -0,0 +1,72 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"errors"
+	"fmt"
+
+	"go.viam.com/rplidar/gen"
+)
+
+// ErrScanModeNotSupported is returned when asking about a scan mode the connected device does not support.
+var ErrScanModeNotSupported = errors.New("scan mode not supported")
+
+// ScanMode is the name of one of the device's scan modes as reported by the SDK, e.g. "Standard", "Express",
+// "Boost" or "Sensitivity".
+type ScanMode string
+
+// scanModeInfo describes a scan mode supported by the device.
+type scanModeInfo struct {
+	// The id the SDK starts the scan mode with.
+	id            uint16
+	mode          ScanMode
+	samplesPerSec float64
+	maxDistanceM  float64
+}
+
+// querySupportedScanModes asks the device for the scan modes it supports. Devices without configuration
+// commands report the standard and, if supported, the express mode. It must be called before scanning starts.
+func (device *rplidarDevice) querySupportedScanModes() ([]scanModeInfo, error) {
+	modes := gen.NewScanModeVector()
+	defer gen.DeleteScanModeVector(modes)
+
+	if result := device.driver.GetAllSupportedScanModes(modes, device.timeoutMs); Result(result) != ResultOk {
+		return nil, fmt.Errorf("failed to get supported scan modes: %w", Result(result).Failed())
+	}
+
+	infos := make([]scanModeInfo, 0, modes.Size())
+	for i := 0; i < int(modes.Size()); i++ {
+		mode := modes.Get(i)
+		info := scanModeInfo{
+			id:           mode.GetId(),
+			mode:         ScanMode(mode.GetScan_mode()),
+			maxDistanceM: float64(mode.GetMax_distance()),
+		}
+		if usPerSample := mode.GetUs_per_sample(); usPerSample > 0 {
+			info.samplesPerSec = 1e6 / float64(usPerSample)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// ScanModes returns the scan modes supported by the connected device.
+func (rp *rplidar) ScanModes() []ScanMode {
+	infos := rp.device.supportedScanModes()
+	modes := make([]ScanMode, 0, len(infos))
+	for _, info := range infos {
+		modes = append(modes, info.mode)
+	}
+	return modes
+}
+
+// ModeSampleRate returns the number of samples per second the connected device reports for the given scan mode.
+// It returns an error wrapping ErrScanModeNotSupported if the device does not support the mode.
+func (rp *rplidar) ModeSampleRate(mode ScanMode) (float64, error) {
+	for _, info := range rp.device.supportedScanModes() {
+		if info.mode == mode {
+			return info.samplesPerSec, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrScanModeNotSupported, mode)
+}
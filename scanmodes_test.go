@@ -0,0 +1,64 @@
+package rplidar
+
+import (
+	"errors"
+	"testing"
+
+	"go.viam.com/rplidar/gen"
+	"go.viam.com/rplidar/inject"
+	"go.viam.com/test"
+)
+
+func TestScanModes(t *testing.T) {
+	injectedRPlidarDriver := inject.NewRPLiDARDriver()
+	injectedRPlidarDriver.GetAllSupportedScanModesFunc = func(a ...interface{}) uint {
+		modes := a[0].([]interface{})[0].(gen.ScanModeVector)
+		for _, m := range []struct {
+			name        string
+			usPerSample float32
+		}{{"Standard", 250}, {"Boost", 31.25}} {
+			mode := gen.NewRplidarScanMode()
+			mode.SetScan_mode(m.name)
+			mode.SetUs_per_sample(m.usPerSample)
+			mode.SetMax_distance(12)
+			modes.Add(mode)
+		}
+		return uint(gen.RESULT_OK)
+	}
+
+	device := &rplidarDevice{driver: &injectedRPlidarDriver}
+	scanModes, err := device.querySupportedScanModes()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, scanModes, test.ShouldResemble, []scanModeInfo{
+		{mode: "Standard", samplesPerSec: 4000, maxDistanceM: 12},
+		{mode: "Boost", samplesPerSec: 32000, maxDistanceM: 12},
+	})
+	device.scanModes = scanModes
+	rp := rplidar{device: device}
+
+	t.Run("supported scan modes", func(t *testing.T) {
+		test.That(t, rp.ScanModes(), test.ShouldResemble, []ScanMode{"Standard", "Boost"})
+	})
+
+	t.Run("sample rate of a supported mode", func(t *testing.T) {
+		rate, err := rp.ModeSampleRate("Boost")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, rate, test.ShouldEqual, 32000)
+	})
+
+	t.Run("sample rate of an unsupported mode", func(t *testing.T) {
+		_, err := rp.ModeSampleRate("Sensitivity")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, errors.Is(err, ErrScanModeNotSupported), test.ShouldBeTrue)
+	})
+
+	t.Run("failure to query the scan modes", func(t *testing.T) {
+		failingRPlidarDriver := inject.NewRPLiDARDriver()
+		failingRPlidarDriver.GetAllSupportedScanModesFunc = func(a ...interface{}) uint {
+			return uint(gen.RESULT_OPERATION_TIMEOUT)
+		}
+		_, err := (&rplidarDevice{driver: &failingRPlidarDriver}).querySupportedScanModes()
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "failed to get supported scan modes")
+	})
+}
@@ -0,0 +1,78 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Standby puts the rplidar in a warm standby: scanning and the motor stop, while the serial session and the SDK
+// driver stay open. The next scan request, or the "start" command, resumes scanning without connecting to the
+// device again or probing its scan modes, which only leaves spinning the motor up and the warmup, see
+// DeviceStats.LastResumeLatency. It does nothing if the rplidar is in standby already and fails unless it is
+// scanning, e.g. while it reconnects.
+func (rp *rplidar) Standby(ctx context.Context) error {
+	rp.startMutex.Lock()
+	defer rp.startMutex.Unlock()
+	if !rp.running {
+		return nil
+	}
+	if rp.backgroundCtx.Err() != nil {
+		return errors.New("the rplidar is closed")
+	}
+
+	// The scan loop stops between scans, so that it never stops in the middle of reconnecting.
+	reply := make(chan error, 1)
+	select {
+	case rp.standbyRequests <- reply:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-rp.backgroundCtx.Done():
+		return errors.New("the rplidar is closed")
+	}
+	if err := <-reply; err != nil {
+		return err
+	}
+	rp.running = false
+	rp.standbyEntered = true
+	return nil
+}
+
+// enterStandby stops scanning and the motor for Standby, unless the state does not allow it. It is only called
+// from the scan loop, which stops once it succeeded.
+func (rp *rplidar) enterStandby() error {
+	switch state := rp.State(); state {
+	case StateScanning, StateDegraded:
+	default:
+		return fmt.Errorf("the rplidar cannot enter standby while it is %v", state)
+	}
+
+	rp.device.mutex.Lock()
+	rp.device.driver.Stop()
+	if rp.controlsMotor() {
+		rp.logger.Debug("stopping motor")
+		rp.device.driver.StopMotor()
+	}
+	rp.device.mutex.Unlock()
+
+	// Scanning resumes with the configured protocol, so does stepping down from it, and the time in standby is
+	// neither a gap in the arrivals of scans nor part of a revolution.
+	if rp.degrader != nil {
+		rp.degrader = newScanDegrader(rp.degrader.afterErrors, rp.degrader.recoverAfter)
+	}
+	rp.arrivals.reset()
+	rp.startAligner.reset()
+	rp.overlongTrimmer.reset()
+	rp.setState(StateStandby)
+	return nil
+}
+
+// recordResume records that the first point cloud after resuming from standby at resumedAt was cached at now.
+func (rp *rplidar) recordResume(resumedAt, now time.Time) {
+	latency := now.Sub(resumedAt)
+	rp.stats.addResume(latency)
+	rp.logger.Infof("resumed scanning from standby in %v", latency.Round(time.Millisecond))
+}
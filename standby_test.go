@@ -0,0 +1,110 @@
+package rplidar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rplidar/gen"
+	"go.viam.com/rplidar/inject"
+	"go.viam.com/test"
+)
+
+func TestStandby(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("keeps the driver and resumes on the next scan request", func(t *testing.T) {
+		driver := inject.NewRPLiDARDriver()
+		var connects, startScans, stops, motorStarts, motorStops int
+		driver.ConnectFunc = func(a ...interface{}) uint {
+			connects++
+			return uint(gen.RESULT_OK)
+		}
+		driver.StartScanFunc = func(a ...interface{}) uint {
+			startScans++
+			return uint(gen.RESULT_OK)
+		}
+		driver.StopFunc = func(a ...interface{}) uint {
+			stops++
+			return uint(gen.RESULT_OK)
+		}
+		driver.StartMotorFunc = func() uint {
+			motorStarts++
+			return uint(gen.RESULT_OK)
+		}
+		driver.StopMotorFunc = func() uint {
+			motorStops++
+			return uint(gen.RESULT_OK)
+		}
+		driver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+			*(a[0].([]interface{})[1].(*int64)) = 4
+			return uint(gen.RESULT_OK)
+		}
+		driver.DisconnectFunc = func() {}
+
+		backgroundCtx, cancel := context.WithCancel(ctx)
+		rp := &rplidar{
+			device:          &rplidarDevice{driver: &driver},
+			nodes:           nodesOf([]rawNode{{4096, 4000, 40, 0}, {20480, 4000, 40, 0}, {36864, 4000, 40, 0}, {53248, 4000, 40, 0}}),
+			expressProtocol: expressProtocolStandard,
+			cache:           &dataCache{},
+			clock:           newFakeClock(),
+			logger:          logging.NewTestLogger(t),
+			cancelFunc:      cancel,
+			deferredStart:   true,
+			backgroundCtx:   backgroundCtx,
+			standbyRequests: make(chan chan error),
+		}
+
+		// The rplidar is not started yet, so there is nothing to stand by from.
+		test.That(t, rp.Standby(ctx), test.ShouldBeNil)
+		test.That(t, startScans, test.ShouldEqual, 0)
+
+		_, err := rp.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, rp.State(), test.ShouldEqual, StateScanning)
+
+		test.That(t, rp.Standby(ctx), test.ShouldBeNil)
+		test.That(t, rp.State(), test.ShouldEqual, StateStandby)
+		test.That(t, stops, test.ShouldEqual, 1)
+		test.That(t, motorStops, test.ShouldEqual, 1)
+		test.That(t, rp.Standby(ctx), test.ShouldBeNil)
+		test.That(t, stops, test.ShouldEqual, 1)
+
+		meta, err := rp.LastScanMeta(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		_, err = rp.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, rp.State(), test.ShouldEqual, StateScanning)
+		resumedMeta, err := rp.LastScanMeta(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resumedMeta.Seq, test.ShouldBeGreaterThan, meta.Seq)
+
+		// Resuming restarts the motor and scanning over the same serial session.
+		test.That(t, connects, test.ShouldEqual, 0)
+		test.That(t, motorStarts, test.ShouldEqual, 2)
+		test.That(t, startScans, test.ShouldEqual, 2)
+		stats := rp.Stats()
+		test.That(t, stats.Resumes, test.ShouldEqual, 1)
+		test.That(t, stats.LastResumeLatency, test.ShouldBeGreaterThanOrEqualTo, defaultWarmUpTimeout)
+
+		test.That(t, rp.Close(ctx), test.ShouldBeNil)
+		err = rp.Standby(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "the rplidar is closed")
+	})
+
+	t.Run("not while reconnecting", func(t *testing.T) {
+		rp := &rplidar{logger: logging.NewTestLogger(t)}
+		_, ok := rp.state.transition(StateScanning, time.Now())
+		test.That(t, ok, test.ShouldBeTrue)
+		_, ok = rp.state.transition(StateReconnecting, time.Now())
+		test.That(t, ok, test.ShouldBeTrue)
+
+		err := rp.enterStandby()
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "the rplidar cannot enter standby while it is reconnecting")
+		test.That(t, rp.State(), test.ShouldEqual, StateReconnecting)
+	})
+}
@@ -0,0 +1,77 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// start sets up the rplidar and starts caching point clouds in the background until backgroundCtx is done or the
+// rplidar is put in standby. resumedAt is when it was asked to resume from a warm standby, zero on the first start.
+func (rp *rplidar) start(ctx, backgroundCtx context.Context, resumedAt time.Time) error {
+	unpin := rp.pinGrabber()
+	err := rp.setupRPLidar(ctx, !resumedAt.IsZero())
+	unpin()
+	if err != nil {
+		rp.logResultCode(err)
+		return errors.Wrap(err, "there was a problem setting up the rplidar")
+	}
+	rp.setState(StateScanning)
+	rp.running = true
+
+	// Start background caching of pointcloud data
+	rp.cacheBackgroundWorkers.Add(1)
+	go func() {
+		defer rp.cacheBackgroundWorkers.Done()
+		defer rp.pinGrabber()()
+		rp.cachePointCloudLoop(backgroundCtx, resumedAt)
+	}()
+	return nil
+}
+
+// ensureStarted starts the rplidar if auto_start is disabled and it has not been started yet, or resumes it from a
+// warm standby, and waits for the first point cloud to be cached since it was last started. It does nothing when
+// auto_start is enabled and the rplidar is not in standby.
+func (rp *rplidar) ensureStarted(ctx context.Context) error {
+	if !rp.deferredStart && rp.State() != StateStandby {
+		return nil
+	}
+	afterSeq, err := rp.startDeferred(ctx)
+	if err != nil {
+		return err
+	}
+	return rp.cache.waitForScanAfter(ctx, afterSeq)
+}
+
+// startDeferred starts the rplidar unless it is running already, and returns the sequence number of the last
+// point cloud cached before it was last started.
+func (rp *rplidar) startDeferred(ctx context.Context) (uint64, error) {
+	rp.startMutex.Lock()
+	defer rp.startMutex.Unlock()
+	if rp.running {
+		return rp.startedAfterSeq, nil
+	}
+	if rp.backgroundCtx.Err() != nil {
+		return 0, errors.New("the rplidar is closed")
+	}
+
+	rp.cache.mutex.RLock()
+	lastSeq := rp.cache.meta.Seq
+	rp.cache.mutex.RUnlock()
+	var resumedAt time.Time
+	if rp.standbyEntered {
+		rp.logger.Info("resuming the rplidar from standby")
+		resumedAt = clockOrReal(rp.clock).Now()
+	} else {
+		rp.logger.Info("starting the rplidar on the first request")
+	}
+	rp.setState(StateConnecting)
+	if err := rp.start(ctx, rp.backgroundCtx, resumedAt); err != nil {
+		rp.setState(StateStandby)
+		return 0, err
+	}
+	rp.startedAfterSeq = lastSeq
+	return lastSeq, nil
+}
@@ -0,0 +1,83 @@
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rplidar/gen"
+	"go.viam.com/rplidar/inject"
+	"go.viam.com/test"
+)
+
+func TestEnsureStarted(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("does nothing when auto_start is enabled", func(t *testing.T) {
+		rp := rplidar{cache: &dataCache{}}
+		test.That(t, rp.ensureStarted(ctx), test.ShouldBeNil)
+	})
+
+	t.Run("starts scanning on the first request only", func(t *testing.T) {
+		driver := inject.NewRPLiDARDriver()
+		var startScanCount int
+		driver.StartScanFunc = func(a ...interface{}) uint {
+			startScanCount++
+			return uint(gen.RESULT_OK)
+		}
+		driver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+			return uint(gen.RESULT_OPERATION_TIMEOUT)
+		}
+		driver.StopFunc = func(a ...interface{}) uint {
+			return uint(gen.RESULT_OK)
+		}
+		driver.DisconnectFunc = func() {}
+
+		backgroundCtx, cancel := context.WithCancel(ctx)
+		rp := &rplidar{
+			device:          &rplidarDevice{driver: &driver},
+			expressProtocol: expressProtocolStandard,
+			motorControl:    motorControlExternal,
+			cache:           &dataCache{},
+			clock:           newFakeClock(),
+			logger:          logging.NewTestLogger(t),
+			cancelFunc:      cancel,
+			deferredStart:   true,
+			backgroundCtx:   backgroundCtx,
+		}
+
+		// Every grab times out, so the first scan never arrives.
+		timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer timeoutCancel()
+		err := rp.ensureStarted(timeoutCtx)
+		test.That(t, errors.Is(err, context.DeadlineExceeded), test.ShouldBeTrue)
+		test.That(t, startScanCount, test.ShouldEqual, 1)
+
+		timeoutCtx, timeoutCancel = context.WithTimeout(ctx, 20*time.Millisecond)
+		defer timeoutCancel()
+		err = rp.ensureStarted(timeoutCtx)
+		test.That(t, errors.Is(err, context.DeadlineExceeded), test.ShouldBeTrue)
+		test.That(t, startScanCount, test.ShouldEqual, 1)
+
+		test.That(t, rp.Close(ctx), test.ShouldBeNil)
+	})
+
+	t.Run("fails once closed", func(t *testing.T) {
+		backgroundCtx, cancel := context.WithCancel(ctx)
+		rp := rplidar{
+			device:        &rplidarDevice{},
+			cache:         &dataCache{},
+			logger:        logging.NewTestLogger(t),
+			cancelFunc:    cancel,
+			deferredStart: true,
+			backgroundCtx: backgroundCtx,
+		}
+		test.That(t, rp.Close(ctx), test.ShouldBeNil)
+
+		_, err := rp.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "the rplidar is closed")
+	})
+}
@@ -0,0 +1,56 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+// startAngleAligner moves the boundary between consecutive revolutions to a fixed angle, so that every scan runs
+// from startDeg of one grab to startDeg of the next instead of from wherever the device's revolution ends. The arc
+// of a grab from startDeg onwards is held back and starts the next revolution. A nil startAngleAligner leaves the
+// revolutions as they are. It is only used by the scan loop.
+type startAngleAligner struct {
+	startDeg float64
+	// The arc from startDeg to the end of the previous grab, and whether there is a previous grab.
+	pending []measurement
+	primed  bool
+}
+
+// newStartAngleAligner creates an aligner starting every revolution at startDeg, or returns nil if startDeg is nil.
+func newStartAngleAligner(startDeg *float64) *startAngleAligner {
+	if startDeg == nil {
+		return nil
+	}
+	return &startAngleAligner{startDeg: *startDeg}
+}
+
+// align returns the revolution ending with the arc of measurements below startDeg, after the arc held back from
+// the previous grab, and holds back the rest of measurements for the next one. The measurements of a grab are
+// ascending in angle. It reports false for the first grab, which only completes the next revolution.
+func (a *startAngleAligner) align(measurements []measurement) ([]measurement, bool) {
+	if a == nil {
+		return measurements, true
+	}
+	split := len(measurements)
+	for i, m := range measurements {
+		if m.angleDeg >= a.startDeg {
+			split = i
+			break
+		}
+	}
+
+	revolution := append(a.pending, measurements[:split]...)
+	// The filters reuse the storage of the revolution, so the held back arc needs storage of its own.
+	a.pending = append([]measurement(nil), measurements[split:]...)
+	if !a.primed {
+		a.primed = true
+		return nil, false
+	}
+	return revolution, true
+}
+
+// reset forgets the held back arc after a failed grab, which breaks the continuity of the revolutions, so that the
+// next revolution does not join arcs from before and after it.
+func (a *startAngleAligner) reset() {
+	if a == nil {
+		return
+	}
+	a.pending = nil
+	a.primed = false
+}
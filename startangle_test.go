@@ -0,0 +1,110 @@
+package rplidar
+
+import (
+	"context"
+	"testing"
+
+	"go.viam.com/rplidar/gen"
+	"go.viam.com/rplidar/inject"
+	"go.viam.com/test"
+)
+
+func TestStartAngleAligner(t *testing.T) {
+	grab := func(seq float64) []measurement {
+		return []measurement{
+			{angleDeg: 10, distanceMM: seq},
+			{angleDeg: 90, distanceMM: seq},
+			{angleDeg: 180, distanceMM: seq},
+			{angleDeg: 270, distanceMM: seq},
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		aligner := newStartAngleAligner(nil)
+		revolution, complete := aligner.align(grab(1))
+		test.That(t, complete, test.ShouldBeTrue)
+		test.That(t, revolution, test.ShouldResemble, grab(1))
+		aligner.reset()
+	})
+
+	t.Run("every revolution starts at the start angle", func(t *testing.T) {
+		startDeg := 180.
+		aligner := newStartAngleAligner(&startDeg)
+		_, complete := aligner.align(grab(1))
+		test.That(t, complete, test.ShouldBeFalse)
+
+		for seq := 2.; seq <= 3; seq++ {
+			revolution, complete := aligner.align(grab(seq))
+			test.That(t, complete, test.ShouldBeTrue)
+			test.That(t, revolution, test.ShouldResemble, []measurement{
+				{angleDeg: 180, distanceMM: seq - 1},
+				{angleDeg: 270, distanceMM: seq - 1},
+				{angleDeg: 10, distanceMM: seq},
+				{angleDeg: 90, distanceMM: seq},
+			})
+		}
+
+		// After a failed grab, the arc held back from before it is not joined with the next grab.
+		aligner.reset()
+		_, complete = aligner.align(grab(5))
+		test.That(t, complete, test.ShouldBeFalse)
+		revolution, complete := aligner.align(grab(6))
+		test.That(t, complete, test.ShouldBeTrue)
+		test.That(t, revolution[0], test.ShouldResemble, measurement{angleDeg: 180, distanceMM: 5})
+	})
+
+	t.Run("a start angle of 0 keeps the grabs", func(t *testing.T) {
+		startDeg := 0.
+		aligner := newStartAngleAligner(&startDeg)
+		aligner.align(grab(1))
+		revolution, complete := aligner.align(grab(2))
+		test.That(t, complete, test.ShouldBeTrue)
+		test.That(t, revolution, test.ShouldResemble, grab(1))
+	})
+
+	t.Run("a start angle past every return", func(t *testing.T) {
+		startDeg := 300.
+		aligner := newStartAngleAligner(&startDeg)
+		aligner.align(grab(1))
+		revolution, complete := aligner.align(grab(2))
+		test.That(t, complete, test.ShouldBeTrue)
+		test.That(t, revolution, test.ShouldResemble, grab(2))
+	})
+}
+
+func TestScanStartAngle(t *testing.T) {
+	nodes := []rawNode{
+		{angleQ14: 1 << 12, distQ2: 4000},
+		{angleQ14: 5 << 12, distQ2: 4000},
+		{angleQ14: 9 << 12, distQ2: 4000},
+		{angleQ14: 13 << 12, distQ2: 4000},
+	}
+	driver := inject.NewRPLiDARDriver()
+	var grabCount int
+	driver.GrabScanDataHqFunc = func(a ...interface{}) uint {
+		grabCount++
+		*(a[0].([]interface{})[1].(*int64)) = int64(len(nodes))
+		return uint(gen.RESULT_OK)
+	}
+	startDeg := 45.
+	rp := &rplidar{
+		device:       &rplidarDevice{driver: &driver},
+		nodes:        nodesOf(nodes),
+		cache:        &dataCache{},
+		startAligner: newStartAngleAligner(&startDeg),
+	}
+	defer gen.Delete_measurementNodeHqArray(rp.nodes)
+
+	// The first revolution takes an extra grab to start at the start angle, the next ones do not.
+	pc, info, err := rp.scan(context.Background(), 1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, grabCount, test.ShouldEqual, 2)
+	test.That(t, pc.Size(), test.ShouldEqual, 4)
+	test.That(t, info.samples, test.ShouldEqual, 4)
+	test.That(t, info.measurements[0].AngleDeg, test.ShouldEqual, 112.5)
+	test.That(t, info.measurements[3].AngleDeg, test.ShouldEqual, 22.5)
+
+	_, _, err = rp.scan(context.Background(), 1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, grabCount, test.ShouldEqual, 3)
+}
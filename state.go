@@ -0,0 +1,117 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State is a stage of the camera's lifecycle, see State.
+type State string
+
+// The states of the camera. The transitions between them are listed in stateTransitions.
+const (
+	// StateConnecting is the state while the device is connected to and warmed up, both while the camera is
+	// constructed and, without auto_start, while it starts on the first scan request.
+	StateConnecting State = "connecting"
+	// StateStandby is the state of a camera whose auto_start is disabled until the first scan request starts it,
+	// and of one put in a warm standby by Standby until the next scan request resumes it.
+	StateStandby State = "standby"
+	// StateScanning is the state while scans are taken with the configured protocol.
+	StateScanning State = "scanning"
+	// StateDegraded is the state while scans are taken with a protocol degrade_after_errors stepped down to.
+	StateDegraded State = "degraded"
+	// StateReconnecting is the state from the moment reconnect_after_errors reconnects to the device until a
+	// reconnect succeeds, including while waiting for the next attempt.
+	StateReconnecting State = "reconnecting"
+	// StateResetting is the state while usb_reset_on_failure resets the USB device of the rplidar.
+	StateResetting State = "resetting"
+	// StateClosed is the state of a closed camera, or of one that failed to construct.
+	StateClosed State = "closed"
+)
+
+// stateTransitions lists the states each state can transition to. Staying in a state is always allowed.
+var stateTransitions = map[State][]State{
+	StateConnecting:   {StateScanning, StateStandby, StateClosed},
+	StateStandby:      {StateConnecting, StateClosed},
+	StateScanning:     {StateDegraded, StateReconnecting, StateStandby, StateClosed},
+	StateDegraded:     {StateScanning, StateReconnecting, StateStandby, StateClosed},
+	StateReconnecting: {StateScanning, StateResetting, StateClosed},
+	StateResetting:    {StateReconnecting, StateClosed},
+	StateClosed:       {},
+}
+
+// ErrNotScanning is wrapped by the errors returned for point clouds requested while the camera is reconnecting,
+// resetting or closed, instead of returning a point cloud of before the device failed.
+var ErrNotScanning = errors.New("the rplidar is not scanning")
+
+// stateMachine holds the state of the camera and enforces the transitions between states. The zero value is in
+// StateConnecting.
+type stateMachine struct {
+	mutex sync.Mutex
+	state State
+	since time.Time
+}
+
+// current returns the current state and the time it was entered, which is zero if it was never left.
+func (m *stateMachine) current() (State, time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.state == "" {
+		return StateConnecting, m.since
+	}
+	return m.state, m.since
+}
+
+// transition moves to state to at now if the current state allows it and returns the state it moved from. It
+// reports false and stays in the current state otherwise.
+func (m *stateMachine) transition(to State, now time.Time) (State, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	from := m.state
+	if from == "" {
+		from = StateConnecting
+	}
+	if from == to {
+		return from, true
+	}
+	for _, allowed := range stateTransitions[from] {
+		if allowed == to {
+			m.state = to
+			m.since = now
+			return from, true
+		}
+	}
+	return from, false
+}
+
+// State returns the current stage of the camera's lifecycle. NextPointCloud starts a camera in StateStandby,
+// returns the cached point cloud in StateConnecting, StateScanning and StateDegraded, and fails with an error
+// wrapping ErrNotScanning in the other states.
+func (rp *rplidar) State() State {
+	state, _ := rp.state.current()
+	return state
+}
+
+// setState moves the camera to state to and logs the change, unless the current state does not allow it.
+func (rp *rplidar) setState(to State) {
+	from, ok := rp.state.transition(to, clockOrReal(rp.clock).Now())
+	switch {
+	case !ok:
+		rp.logger.Debugf("ignoring the transition from the %v to the %v state", from, to)
+	case from != to:
+		rp.logger.Infof("the rplidar changed from the %v to the %v state", from, to)
+	}
+}
+
+// checkScanning returns an error wrapping ErrNotScanning if the camera is in a state without point clouds.
+func (rp *rplidar) checkScanning() error {
+	switch state := rp.State(); state {
+	case StateReconnecting, StateResetting, StateClosed:
+		return fmt.Errorf("%w: the rplidar is %v", ErrNotScanning, state)
+	default:
+		return nil
+	}
+}
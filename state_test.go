@@ -0,0 +1,86 @@
+package rplidar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestStateMachine(t *testing.T) {
+	now := time.Now()
+
+	t.Run("starts connecting", func(t *testing.T) {
+		var m stateMachine
+		state, since := m.current()
+		test.That(t, state, test.ShouldEqual, StateConnecting)
+		test.That(t, since.IsZero(), test.ShouldBeTrue)
+	})
+
+	t.Run("follows the allowed transitions", func(t *testing.T) {
+		var m stateMachine
+		for _, to := range []State{StateScanning, StateDegraded, StateReconnecting, StateResetting, StateReconnecting, StateScanning} {
+			from, ok := m.transition(to, now)
+			test.That(t, ok, test.ShouldBeTrue)
+			test.That(t, from, test.ShouldNotEqual, to)
+		}
+		state, since := m.current()
+		test.That(t, state, test.ShouldEqual, StateScanning)
+		test.That(t, since, test.ShouldEqual, now)
+
+		// Staying in a state is always allowed and does not restart it.
+		_, ok := m.transition(StateScanning, now.Add(time.Second))
+		test.That(t, ok, test.ShouldBeTrue)
+		_, since = m.current()
+		test.That(t, since, test.ShouldEqual, now)
+	})
+
+	t.Run("rejects other transitions", func(t *testing.T) {
+		var m stateMachine
+		from, ok := m.transition(StateReconnecting, now)
+		test.That(t, ok, test.ShouldBeFalse)
+		test.That(t, from, test.ShouldEqual, StateConnecting)
+
+		_, ok = m.transition(StateClosed, now)
+		test.That(t, ok, test.ShouldBeTrue)
+		for _, to := range []State{StateConnecting, StateScanning, StateStandby} {
+			_, ok = m.transition(to, now)
+			test.That(t, ok, test.ShouldBeFalse)
+		}
+		state, _ := m.current()
+		test.That(t, state, test.ShouldEqual, StateClosed)
+	})
+
+	t.Run("every state is listed", func(t *testing.T) {
+		for _, to := range stateTransitions {
+			for _, state := range to {
+				_, ok := stateTransitions[state]
+				test.That(t, ok, test.ShouldBeTrue)
+			}
+		}
+	})
+}
+
+func TestNextPointCloudByState(t *testing.T) {
+	ctx := context.Background()
+	rp := &rplidar{cache: &dataCache{pointCloud: pointcloud.New()}, logger: logging.NewTestLogger(t)}
+
+	for _, state := range []State{StateConnecting, StateScanning, StateDegraded} {
+		rp.setState(state)
+		test.That(t, rp.State(), test.ShouldEqual, state)
+		pc, err := rp.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldNotBeNil)
+	}
+	for _, state := range []State{StateReconnecting, StateResetting, StateClosed} {
+		rp.setState(state)
+		test.That(t, rp.State(), test.ShouldEqual, state)
+		_, err := rp.NextPointCloud(ctx)
+		test.That(t, errors.Is(err, ErrNotScanning), test.ShouldBeTrue)
+		test.That(t, err.Error(), test.ShouldContainSubstring, string(state))
+	}
+}
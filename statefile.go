@@ -0,0 +1,153 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/logging"
+	goutils "go.viam.com/utils"
+)
+
+// lastGoodState is what the camera last scanned successfully with, persisted to the state file so that the next
+// construction can go straight there instead of detecting the device, trying both baud rates and stepping the
+// protocol down again.
+type lastGoodState struct {
+	DevicePath   string `json:"device_path"`
+	SerialNumber string `json:"serial_number"`
+	BaudRate     uint   `json:"baud_rate"`
+	// The scan mode is only recorded for reference, it is selected again through the express protocol.
+	ScanMode        string `json:"scan_mode"`
+	ExpressProtocol string `json:"express_protocol"`
+}
+
+// defaultStateFilePath returns the state file of the camera called name in the user's cache directory, or an empty
+// path if there is none.
+func defaultStateFilePath(name string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "rplidar", name+".json")
+}
+
+// stateFilePath returns the state file configured for the camera called name, or an empty path if persisting the
+// last good state is disabled.
+func stateFilePath(conf *Config, name string) string {
+	switch {
+	case conf.DisableStateFile:
+		return ""
+	case conf.StateFile != "":
+		return conf.StateFile
+	default:
+		return defaultStateFilePath(name)
+	}
+}
+
+// loadLastGoodState reads the state file at path. A missing file or one that cannot be read or parsed yields an
+// empty state, the latter with a warning, so that a corrupt state file never prevents the camera from starting.
+func loadLastGoodState(path string, logger logging.Logger) lastGoodState {
+	var state lastGoodState
+	if path == "" {
+		return state
+	}
+	//nolint:gosec
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warnf("ignoring the state file %v: %v", path, err)
+		}
+		return state
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		logger.Warnf("ignoring the corrupt state file %v: %v", path, err)
+		return lastGoodState{}
+	}
+	return state
+}
+
+// saveLastGoodState writes state to the state file at path. The file is replaced atomically, so that it is never
+// left half written if the process dies while saving.
+func saveLastGoodState(path string, state lastGoodState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return errors.Wrap(err, "failed to create the directory of the state file")
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the state file")
+	}
+	defer func() {
+		if _, statErr := os.Stat(tmp.Name()); statErr == nil {
+			goutils.UncheckedError(os.Remove(tmp.Name()))
+		}
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		goutils.UncheckedErrorFunc(tmp.Close)
+		return errors.Wrap(err, "failed to write the state file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to write the state file")
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lastGoodDevicePath returns the serial path the rplidar last scanned at according to state, to try first when
+// neither serial_path nor device_index select a device, or an empty path if there is none or it no longer exists.
+// The same path can lead to another rplidar after replugging, so the serial number of the rplidar connected there
+// still has to be checked against the one saved with it.
+func lastGoodDevicePath(state lastGoodState, conf *Config) string {
+	if conf.SerialPath != "" || conf.DeviceIndex != 0 || state.DevicePath == "" || state.SerialNumber == "" {
+		return ""
+	}
+	if _, err := os.Stat(state.DevicePath); err != nil {
+		return ""
+	}
+	return state.DevicePath
+}
+
+// preferredProtocol returns the express protocol to start scanning with: the one the device last scanned with
+// according to state, if the same device is connected and no protocol is configured, and configured otherwise.
+func preferredProtocol(state lastGoodState, serialNumber, configured string, force bool) string {
+	if force || (configured != "" && configured != expressProtocolAuto) {
+		return configured
+	}
+	if state.ExpressProtocol == "" || state.SerialNumber != serialNumber {
+		return configured
+	}
+	return state.ExpressProtocol
+}
+
+// recordLastGood saves what the device scans with now to the state file, if it changed since it was last saved.
+// While the protocol is stepped down because of sustained scan errors, the scan mode and protocol saved before are
+// kept, since the next construction would otherwise start out degraded with nothing to step back up to. Failing to
+// save it is only logged. It is called after scanning started, switched protocols or reconnected, which never
+// happens concurrently.
+func (rp *rplidar) recordLastGood() {
+	if rp.stateFilePath == "" {
+		return
+	}
+	state := lastGoodState{
+		DevicePath:      rp.devicePath,
+		SerialNumber:    rp.device.serialNumber,
+		BaudRate:        rp.device.baudRate,
+		ScanMode:        rp.device.currentScanMode(),
+		ExpressProtocol: rp.device.currentExpressProtocol(),
+	}
+	if rp.degrader.degraded() {
+		state.ScanMode, state.ExpressProtocol = rp.lastGood.ScanMode, rp.lastGood.ExpressProtocol
+	}
+	if state == rp.lastGood {
+		return
+	}
+	if err := saveLastGoodState(rp.stateFilePath, state); err != nil {
+		rp.logger.Warnf("failed to save the last good state to %v: %v", rp.stateFilePath, err)
+		return
+	}
+	rp.lastGood = state
+}
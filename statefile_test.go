@@ -0,0 +1,134 @@
+package rplidar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+func TestLastGoodState(t *testing.T) {
+	state := lastGoodState{
+		DevicePath:      "/dev/ttyUSB1",
+		SerialNumber:    "0123456789ABCDEF",
+		BaudRate:        115200,
+		ScanMode:        "Standard",
+		ExpressProtocol: expressProtocolStandard,
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rplidar", "lidar.json")
+		test.That(t, saveLastGoodState(path, state), test.ShouldBeNil)
+		test.That(t, loadLastGoodState(path, logging.NewTestLogger(t)), test.ShouldResemble, state)
+
+		// Saving again replaces the file without leaving temporary files behind.
+		state.BaudRate = 256000
+		test.That(t, saveLastGoodState(path, state), test.ShouldBeNil)
+		test.That(t, loadLastGoodState(path, logging.NewTestLogger(t)), test.ShouldResemble, state)
+		entries, err := os.ReadDir(filepath.Dir(path))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(entries), test.ShouldEqual, 1)
+	})
+
+	t.Run("missing or corrupt file", func(t *testing.T) {
+		logger, logs := logging.NewObservedTestLogger(t)
+		path := filepath.Join(t.TempDir(), "lidar.json")
+		test.That(t, loadLastGoodState(path, logger), test.ShouldResemble, lastGoodState{})
+		test.That(t, logs.Len(), test.ShouldEqual, 0)
+
+		test.That(t, os.WriteFile(path, []byte(`{"device_path": "/dev/tty`), 0o600), test.ShouldBeNil)
+		test.That(t, loadLastGoodState(path, logger), test.ShouldResemble, lastGoodState{})
+		test.That(t, logs.FilterMessageSnippet("ignoring the corrupt state file").Len(), test.ShouldEqual, 1)
+
+		test.That(t, loadLastGoodState("", logger), test.ShouldResemble, lastGoodState{})
+	})
+
+	t.Run("state file path", func(t *testing.T) {
+		test.That(t, stateFilePath(&Config{StateFile: "/var/lib/lidar.json"}, "lidar"), test.ShouldEqual, "/var/lib/lidar.json")
+		test.That(t, stateFilePath(&Config{StateFile: "/var/lib/lidar.json", DisableStateFile: true}, "lidar"), test.ShouldBeEmpty)
+		if dir, err := os.UserCacheDir(); err == nil {
+			test.That(t, stateFilePath(&Config{}, "lidar"), test.ShouldEqual, filepath.Join(dir, "rplidar", "lidar.json"))
+		}
+	})
+
+	t.Run("preferred protocol", func(t *testing.T) {
+		serial := state.SerialNumber
+		test.That(t, preferredProtocol(state, serial, "", false), test.ShouldEqual, expressProtocolStandard)
+		test.That(t, preferredProtocol(state, serial, expressProtocolAuto, false), test.ShouldEqual, expressProtocolStandard)
+		test.That(t, preferredProtocol(state, serial, expressProtocolLegacy, false), test.ShouldEqual, expressProtocolLegacy)
+		test.That(t, preferredProtocol(state, serial, "", true), test.ShouldEqual, "")
+		test.That(t, preferredProtocol(state, "FEDCBA9876543210", "", false), test.ShouldEqual, "")
+		test.That(t, preferredProtocol(lastGoodState{}, serial, "", false), test.ShouldEqual, "")
+	})
+
+	t.Run("last good device path", func(t *testing.T) {
+		existing := filepath.Join(t.TempDir(), "ttyUSB0")
+		test.That(t, os.WriteFile(existing, nil, 0o600), test.ShouldBeNil)
+		saved := state
+		saved.DevicePath = existing
+		test.That(t, lastGoodDevicePath(saved, &Config{}), test.ShouldEqual, existing)
+
+		// A configured serial path or device index selects the device instead.
+		test.That(t, lastGoodDevicePath(saved, &Config{SerialPath: "/dev/ttyUSB1"}), test.ShouldBeEmpty)
+		test.That(t, lastGoodDevicePath(saved, &Config{DeviceIndex: 1}), test.ShouldBeEmpty)
+
+		// Without a serial number, the rplidar connected there cannot be told to be the same one.
+		withoutSerial := saved
+		withoutSerial.SerialNumber = ""
+		test.That(t, lastGoodDevicePath(withoutSerial, &Config{}), test.ShouldBeEmpty)
+
+		gone := saved
+		gone.DevicePath = filepath.Join(t.TempDir(), "ttyUSB0")
+		test.That(t, lastGoodDevicePath(gone, &Config{}), test.ShouldBeEmpty)
+		test.That(t, lastGoodDevicePath(lastGoodState{}, &Config{}), test.ShouldBeEmpty)
+	})
+
+	t.Run("baud rates", func(t *testing.T) {
+		test.That(t, baudRatesPreferring(0), test.ShouldResemble, []uint{256000, 115200})
+		test.That(t, baudRatesPreferring(115200), test.ShouldResemble, []uint{115200, 256000})
+		test.That(t, baudRatesPreferring(9600), test.ShouldResemble, []uint{256000, 115200})
+	})
+
+	t.Run("records changes only", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "lidar.json")
+		rp := &rplidar{
+			device:        &rplidarDevice{serialNumber: state.SerialNumber, baudRate: 115200, expressProtocol: expressProtocolLegacy},
+			devicePath:    "/dev/ttyUSB0",
+			stateFilePath: path,
+			logger:        logging.NewTestLogger(t),
+		}
+		rp.recordLastGood()
+		saved := loadLastGoodState(path, rp.logger)
+		test.That(t, saved.ExpressProtocol, test.ShouldEqual, expressProtocolLegacy)
+		test.That(t, saved.DevicePath, test.ShouldEqual, "/dev/ttyUSB0")
+		test.That(t, rp.lastGood, test.ShouldResemble, saved)
+
+		test.That(t, os.Remove(path), test.ShouldBeNil)
+		rp.recordLastGood()
+		_, err := os.Stat(path)
+		test.That(t, os.IsNotExist(err), test.ShouldBeTrue)
+	})
+
+	t.Run("a stepped down protocol is not saved", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "lidar.json")
+		rp := &rplidar{
+			device:        &rplidarDevice{serialNumber: state.SerialNumber, baudRate: 115200, expressProtocol: expressProtocolLegacy},
+			devicePath:    "/dev/ttyUSB0",
+			stateFilePath: path,
+			logger:        logging.NewTestLogger(t),
+			degrader:      newScanDegrader(1, 0),
+		}
+		rp.recordLastGood()
+
+		rp.degrader.switched(expressProtocolLegacy, expressProtocolStandard, time.Now())
+		rp.device.expressProtocol = expressProtocolStandard
+		rp.devicePath = "/dev/ttyUSB1"
+		rp.recordLastGood()
+		saved := loadLastGoodState(path, rp.logger)
+		test.That(t, saved.ExpressProtocol, test.ShouldEqual, expressProtocolLegacy)
+		test.That(t, saved.DevicePath, test.ShouldEqual, "/dev/ttyUSB1")
+	})
+}
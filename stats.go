@@ -0,0 +1,247 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The number of recent revolutions the rotation period is averaged over, unless configured otherwise.
+const defaultRotationPeriodWindow = 10
+
+// scanStats holds counters of notable events encountered while scanning. The zero value is ready to use
+// and all methods are safe for concurrent use.
+type scanStats struct {
+	mutex          sync.Mutex
+	startTime      time.Time
+	scans          int
+	failedScans    int
+	overflows      int
+	protocolSwaps  int
+	outOfOrder     int
+	overlongScans  int
+	reconnects     int
+	usbResets      int
+	gatedScans     int
+	resumes        int
+	resumeLatency  time.Duration
+	lastScanTime   time.Time
+	scanRateHz     float64
+	lastPointCount int
+	lastErr        error
+	// The number of recent revolutions the rotation period is averaged over, the default if zero.
+	periodWindow int
+	// The durations of the most recent revolutions, the newest last.
+	periods []time.Duration
+}
+
+// addOverflow records a grab that was discarded because it overflowed the node buffer.
+func (s *scanStats) addOverflow() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.overflows++
+}
+
+// overflowCount returns the number of grabs discarded because they overflowed the node buffer.
+func (s *scanStats) overflowCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.overflows
+}
+
+// addProtocolSwitch records that scanning was restarted with another express protocol.
+func (s *scanStats) addProtocolSwitch() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.protocolSwaps++
+}
+
+// addReconnect records that the scan loop reconnected to the device.
+func (s *scanStats) addReconnect() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reconnects++
+}
+
+// addUSBReset records that the USB device of the rplidar was reset.
+func (s *scanStats) addUSBReset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.usbResets++
+}
+
+// addGatedScan records a scan that was not cached because the scan gate did not allow it.
+func (s *scanStats) addGatedScan() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.gatedScans++
+}
+
+// addResume records that scanning resumed from standby, taking latency until the first point cloud was cached.
+func (s *scanStats) addResume(latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.resumes++
+	s.resumeLatency = latency
+}
+
+// addOutOfOrder records n samples whose angle stepped backward within a revolution.
+func (s *scanStats) addOutOfOrder(n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.outOfOrder += n
+}
+
+// addOverlongScan records a revolution whose trailing samples were moved to the next one because it was longer
+// than its scan mode allows.
+func (s *scanStats) addOverlongScan() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.overlongScans++
+}
+
+// addScan records a successful scan with the given number of points, completed at t. Every scan ends when the
+// next revolution starts, so the time between consecutive scans is the rotation period, and the scan rate is
+// measured from its moving average.
+func (s *scanStats) addScan(t time.Time, pointCount int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.scans++
+	if !s.lastScanTime.IsZero() {
+		if interval := t.Sub(s.lastScanTime); interval > 0 {
+			window := s.periodWindow
+			if window <= 0 {
+				window = defaultRotationPeriodWindow
+			}
+			s.periods = append(s.periods, interval)
+			if len(s.periods) > window {
+				s.periods = s.periods[len(s.periods)-window:]
+			}
+			s.scanRateHz = 1 / s.averagePeriod().Seconds()
+		}
+	}
+	s.lastScanTime = t
+	s.lastPointCount = pointCount
+}
+
+// averagePeriod returns the average of the recent rotation periods. It must be called with the mutex held and at
+// least one period recorded.
+func (s *scanStats) averagePeriod() time.Duration {
+	var sum time.Duration
+	for _, period := range s.periods {
+		sum += period
+	}
+	return sum / time.Duration(len(s.periods))
+}
+
+// rotationPeriod returns the moving average of the recent rotation periods.
+func (s *scanStats) rotationPeriod() (time.Duration, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.periods) == 0 {
+		return 0, errors.New("rotation period has not been measured yet")
+	}
+	return s.averagePeriod(), nil
+}
+
+// lastScan returns the measured scan rate and the number of points of the most recent scan.
+func (s *scanStats) lastScan() (scanRateHz float64, pointCount int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.scanRateHz, s.lastPointCount
+}
+
+// setLastError records err as the most recent error encountered while scanning. A nil err clears it. A failed
+// scan breaks the sequence of consecutive revolutions, so the time across it is not counted as a rotation period.
+func (s *scanStats) setLastError(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastErr = err
+	if err != nil {
+		s.failedScans++
+		s.lastScanTime = time.Time{}
+	}
+}
+
+// lastError returns the most recent error encountered while scanning, or nil if the last scan succeeded.
+func (s *scanStats) lastError() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastErr
+}
+
+// DeviceStats summarizes the reliability of the rplidar over the lifetime of the camera.
+type DeviceStats struct {
+	// Scans is the number of scans that succeeded, including the ones the scan gate skipped.
+	Scans int
+	// FailedScans is the number of scans that failed, for example because of a serial timeout.
+	FailedScans int
+	// Overflows is the number of grabs discarded because they filled the whole node buffer and might have been
+	// truncated.
+	Overflows int
+	// ProtocolSwitches is the number of times scanning was restarted with another express protocol after
+	// degrading or recovering, see degrade_after_errors.
+	ProtocolSwitches int
+	// Reconnects is the number of times the device was reconnected after scanning kept failing, see
+	// reconnect_after_errors.
+	Reconnects int
+	// USBResets is the number of times the USB device of the rplidar was reset because reconnecting kept
+	// failing, see usb_reset_on_failure.
+	USBResets int
+	// GatedScans is the number of scans that were not cached because the robot's motion did not allow them, see
+	// ScanGate.
+	GatedScans int
+	// Resumes is the number of times scanning resumed from a warm standby, see Standby.
+	Resumes int
+	// LastResumeLatency is the time the most recent resume from standby took, from the request resuming it to
+	// the first point cloud cached, zero if scanning never resumed.
+	LastResumeLatency time.Duration
+	// OutOfOrderSamples is the number of samples whose angle stepped backward within a revolution, handled
+	// according to out_of_order_policy.
+	OutOfOrderSamples int
+	// OverlongScans is the number of revolutions whose trailing samples were moved to the next scan because they
+	// had more samples than their scan mode allows, see overlong_scan_margin.
+	OverlongScans int
+	// BlackBoxDrops is the number of revolutions that were not written to the black box because the disk could
+	// not keep up.
+	BlackBoxDrops int
+	// MeasurementDrops is the number of grabs that were not handed to the OnMeasurements callback because it
+	// could not keep up: the new grabs under the "oldest" buffer_policy, the superseded ones under "latest".
+	MeasurementDrops int
+	// PartialScanDrops is the number of partial scans that were not handed to the OnPartialScan callback because
+	// it could not keep up, counted like MeasurementDrops.
+	PartialScanDrops int
+	// CallbackStalls is the number of times the scan loop waited for the OnMeasurements or OnPartialScan callback
+	// to catch up under the "block" buffer_policy.
+	CallbackStalls int
+	// Uptime is the time since the camera was created.
+	Uptime time.Duration
+	// SDKVersion is the version of the rplidar SDK the camera is built against, see SDKVersion.
+	SDKVersion string
+}
+
+// session returns a summary of the counters, with the uptime measured up to now.
+func (s *scanStats) session(now time.Time) DeviceStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var uptime time.Duration
+	if !s.startTime.IsZero() {
+		uptime = now.Sub(s.startTime)
+	}
+	return DeviceStats{
+		Scans:             s.scans,
+		FailedScans:       s.failedScans,
+		Overflows:         s.overflows,
+		ProtocolSwitches:  s.protocolSwaps,
+		Reconnects:        s.reconnects,
+		USBResets:         s.usbResets,
+		GatedScans:        s.gatedScans,
+		Resumes:           s.resumes,
+		LastResumeLatency: s.resumeLatency,
+		OutOfOrderSamples: s.outOfOrder,
+		OverlongScans:     s.overlongScans,
+		Uptime:            uptime,
+	}
+}
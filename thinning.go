@@ -0,0 +1,128 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"math/rand"
+	"time"
+)
+
+// rateThinningSmoothing is the weight given to the newest scan when smoothing the measured input rate. Lower
+// values ramp the decimation more slowly but are less affected by individual scans.
+const rateThinningSmoothing = 0.2
+
+// rateThinner decimates scans so that the sustained output across scans approaches a target number of points per
+// second, regardless of the scan rate or the number of points per scan.
+//
+// It is a feed-forward controller: it measures the input rate, the points per scan divided by the time the scan
+// took, smooths it with an exponential moving average and keeps the fraction of points that brings the smoothed
+// input rate down to the target. As the fraction only depends on the input, never on its own output, it ramps
+// smoothly with the input instead of oscillating. Points are kept evenly spaced within a scan by carrying the
+// fractional remainder of kept points from one point, and one scan, to the next. The initial remainder is drawn
+// from the decimation seed, which picks the phase of the kept points.
+//
+// The time a scan took is derived from the number of samples the device reported for it and the sample rate of
+// the scan mode, so the same seed and input always keep the same points. Only if the sample rate is unknown is it
+// the time since the previous scan on the host's clock, which varies with when scans were grabbed.
+//
+// A nil rateThinner keeps every point.
+type rateThinner struct {
+	targetPointsPerSec float64
+
+	lastScanTime time.Time
+	inputRate    float64
+	carry        float64
+}
+
+// newRateThinner creates a rateThinner targeting the given number of points per second, drawing from rng to
+// pick which points are kept. A non-positive target disables thinning and returns nil.
+func newRateThinner(targetPointsPerSec float64, rng *rand.Rand) *rateThinner {
+	if targetPointsPerSec <= 0 {
+		return nil
+	}
+	return &rateThinner{targetPointsPerSec: targetPointsPerSec, carry: rng.Float64()}
+}
+
+// newDecimationRand returns the source of randomness for decimating scans. All decimation draws from it so that
+// the points kept only depend on the seed and the input.
+func newDecimationRand(seed int64) *rand.Rand {
+	//nolint:gosec
+	return rand.New(rand.NewSource(seed))
+}
+
+// thin returns the measurements to keep of the scan completed at t, for which the device reported samples
+// samples, valid or not, in a scan mode of samplesPerSec samples per second, 0 if unknown. The returned slice
+// shares its backing array with measurements.
+func (th *rateThinner) thin(measurements []measurement, samples int, samplesPerSec float64, t time.Time) []measurement {
+	if th == nil {
+		return measurements
+	}
+
+	var interval float64
+	if samplesPerSec > 0 {
+		interval = float64(samples) / samplesPerSec
+	} else if !th.lastScanTime.IsZero() {
+		interval = t.Sub(th.lastScanTime).Seconds()
+	}
+	th.lastScanTime = t
+	if interval > 0 {
+		rate := float64(len(measurements)) / interval
+		if th.inputRate == 0 {
+			th.inputRate = rate
+		} else {
+			th.inputRate += rateThinningSmoothing * (rate - th.inputRate)
+		}
+	}
+
+	return keepEvenly(measurements, th.keepFraction(), &th.carry)
+}
+
+// keepFraction returns the fraction of points currently kept. Until the input rate has been measured every
+// point is kept.
+func (th *rateThinner) keepFraction() float64 {
+	if th.inputRate <= th.targetPointsPerSec {
+		return 1
+	}
+	return th.targetPointsPerSec / th.inputRate
+}
+
+// fractionThinner keeps a fixed fraction of the points of every scan, evenly spread like those a rateThinner keeps,
+// starting at a phase drawn from the decimation seed. A nil fractionThinner keeps every point.
+type fractionThinner struct {
+	fraction float64
+	carry    float64
+}
+
+// newFractionThinner creates a fractionThinner keeping the given fraction of points, drawing from rng to pick
+// which points are kept. It returns nil if keepFraction is unset or keeps every point.
+func newFractionThinner(keepFraction *float64, rng *rand.Rand) *fractionThinner {
+	if keepFraction == nil || *keepFraction >= 1 {
+		return nil
+	}
+	return &fractionThinner{fraction: *keepFraction, carry: rng.Float64()}
+}
+
+// thin returns the measurements to keep. The returned slice shares its backing array with measurements.
+func (th *fractionThinner) thin(measurements []measurement) []measurement {
+	if th == nil {
+		return measurements
+	}
+	return keepEvenly(measurements, th.fraction, &th.carry)
+}
+
+// keepEvenly keeps keepFraction of measurements, evenly spaced by carrying the fractional remainder of kept points
+// in carry from one point, and one call, to the next. The returned slice shares its backing array with
+// measurements.
+func keepEvenly(measurements []measurement, keepFraction float64, carry *float64) []measurement {
+	if keepFraction >= 1 {
+		return measurements
+	}
+	kept := measurements[:0]
+	for _, m := range measurements {
+		*carry += keepFraction
+		if *carry >= 1 {
+			*carry--
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,142 @@
+package rplidar
+
+import (
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+// scanOf returns a revolution of n returns at evenly spaced angles.
+func scanOf(n int) []measurement {
+	measurements := make([]measurement, n)
+	for i := range measurements {
+		measurements[i] = measurement{angleDeg: 360 * float64(i) / float64(n), distanceMM: 1000}
+	}
+	return measurements
+}
+
+func TestRateThinner(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		th := newRateThinner(0, newDecimationRand(0))
+		test.That(t, th, test.ShouldBeNil)
+		test.That(t, len(th.thin(scanOf(100), 0, 0, time.Now())), test.ShouldEqual, 100)
+	})
+
+	t.Run("without a sample rate the first scan is kept", func(t *testing.T) {
+		th := newRateThinner(1000, newDecimationRand(0))
+		test.That(t, len(th.thin(scanOf(5000), 0, 0, time.Now())), test.ShouldEqual, 5000)
+	})
+
+	t.Run("below the target keeps every point", func(t *testing.T) {
+		th := newRateThinner(10000, newDecimationRand(0))
+		start := time.Now()
+		for i := 0; i < 10; i++ {
+			kept := th.thin(scanOf(500), 0, 0, start.Add(time.Duration(i)*100*time.Millisecond))
+			test.That(t, len(kept), test.ShouldEqual, 500)
+		}
+	})
+
+	t.Run("holds the target rate", func(t *testing.T) {
+		// 10 scans per second of 1000 points each is 10000 points per second, four times the target.
+		th := newRateThinner(2500, newDecimationRand(0))
+		start := time.Now()
+		var kept int
+		for i := 0; i < 20; i++ {
+			kept = len(th.thin(scanOf(1000), 0, 0, start.Add(time.Duration(i)*100*time.Millisecond)))
+		}
+		test.That(t, kept, test.ShouldAlmostEqual, 250, 1)
+	})
+
+	t.Run("ramps smoothly when the scan rate changes", func(t *testing.T) {
+		th := newRateThinner(2500, newDecimationRand(0))
+		scanTime := time.Now()
+		for i := 0; i < 20; i++ {
+			scanTime = scanTime.Add(100 * time.Millisecond)
+			th.thin(scanOf(1000), 0, 0, scanTime)
+		}
+
+		// Doubling the scan rate doubles the input rate, so the kept points per scan should fall steadily from 250
+		// towards 125 without overshooting. The carried remainder allows for a single point of rounding per scan.
+		previous := 250
+		for i := 0; i < 40; i++ {
+			scanTime = scanTime.Add(50 * time.Millisecond)
+			kept := len(th.thin(scanOf(1000), 0, 0, scanTime))
+			test.That(t, kept, test.ShouldBeLessThanOrEqualTo, previous+1)
+			test.That(t, kept, test.ShouldBeGreaterThanOrEqualTo, 124)
+			previous = kept
+		}
+		test.That(t, previous, test.ShouldAlmostEqual, 125, 1)
+	})
+
+	t.Run("same seed keeps the same points", func(t *testing.T) {
+		thinScans := func(seed int64) [][]measurement {
+			th := newRateThinner(2500, newDecimationRand(seed))
+			start := time.Now()
+			scans := make([][]measurement, 0, 10)
+			for i := 0; i < 10; i++ {
+				scans = append(scans, th.thin(scanOf(1000), 0, 0, start.Add(time.Duration(i)*100*time.Millisecond)))
+			}
+			return scans
+		}
+		test.That(t, thinScans(42), test.ShouldResemble, thinScans(42))
+		test.That(t, thinScans(42), test.ShouldNotResemble, thinScans(7))
+	})
+
+	t.Run("the sample rate makes the kept points independent of the timing", func(t *testing.T) {
+		// 1000 samples at 10000 samples per second take 100ms, however late each scan was grabbed.
+		thinScans := func(jitter time.Duration) [][]measurement {
+			th := newRateThinner(2500, newDecimationRand(42))
+			scanTime := time.Now()
+			scans := make([][]measurement, 0, 10)
+			for i := 0; i < 10; i++ {
+				scanTime = scanTime.Add(100*time.Millisecond + time.Duration(i%2)*jitter)
+				scans = append(scans, th.thin(scanOf(1000), 1000, 10000, scanTime))
+			}
+			return scans
+		}
+		scans := thinScans(0)
+		test.That(t, scans, test.ShouldResemble, thinScans(30*time.Millisecond))
+		test.That(t, len(scans[0]), test.ShouldAlmostEqual, 250, 1)
+		test.That(t, len(scans[9]), test.ShouldAlmostEqual, 250, 1)
+	})
+
+	t.Run("kept points are evenly spread", func(t *testing.T) {
+		th := &rateThinner{targetPointsPerSec: 1, inputRate: 4, lastScanTime: time.Now()}
+		kept := th.thin(scanOf(8), 0, 0, th.lastScanTime)
+		test.That(t, len(kept), test.ShouldEqual, 2)
+		test.That(t, kept[1].angleDeg-kept[0].angleDeg, test.ShouldAlmostEqual, 180)
+	})
+}
+
+func TestFractionThinner(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		one := 1.
+		for _, keepFraction := range []*float64{nil, &one} {
+			th := newFractionThinner(keepFraction, newDecimationRand(0))
+			test.That(t, th, test.ShouldBeNil)
+			test.That(t, len(th.thin(scanOf(100))), test.ShouldEqual, 100)
+		}
+	})
+
+	t.Run("keeps the fraction evenly spread", func(t *testing.T) {
+		quarter := 0.25
+		th := newFractionThinner(&quarter, newDecimationRand(0))
+		for i := 0; i < 3; i++ {
+			kept := th.thin(scanOf(400))
+			test.That(t, len(kept), test.ShouldEqual, 100)
+			for j := 1; j < len(kept); j++ {
+				test.That(t, kept[j].angleDeg-kept[j-1].angleDeg, test.ShouldAlmostEqual, 3.6)
+			}
+		}
+	})
+
+	t.Run("same seed keeps the same points", func(t *testing.T) {
+		thinScan := func(seed int64) []measurement {
+			third := 1. / 3
+			return newFractionThinner(&third, newDecimationRand(seed)).thin(scanOf(30))
+		}
+		test.That(t, thinScan(42), test.ShouldResemble, thinScan(42))
+		test.That(t, thinScan(42), test.ShouldNotResemble, thinScan(7))
+	})
+}
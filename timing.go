@@ -0,0 +1,74 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// The number of recent inter-arrival times the arrival jitter of scans is computed over.
+	arrivalJitterWindow = 32
+	// The default arrival jitter above which get_timing_health reports the timing of scans as unhealthy.
+	defaultMaxArrivalJitterMs = 10.
+)
+
+// arrivalTimer tracks the wall clock time between the arrivals of consecutive scans and its standard deviation
+// over the last arrivalJitterWindow of them. The zero value is ready to use. It is only used from the scan loop.
+type arrivalTimer struct {
+	last      time.Time
+	intervals [arrivalJitterWindow]time.Duration
+	next      int
+	count     int
+}
+
+// arrive records a scan arriving at now and returns the time since the previous arrival and the standard
+// deviation of the recent inter-arrival times. Both are zero for the first arrival and the first one after reset.
+func (t *arrivalTimer) arrive(now time.Time) (interArrival, jitter time.Duration) {
+	last := t.last
+	t.last = now
+	if last.IsZero() {
+		return 0, 0
+	}
+	interArrival = now.Sub(last)
+	t.intervals[t.next] = interArrival
+	t.next = (t.next + 1) % arrivalJitterWindow
+	if t.count < arrivalJitterWindow {
+		t.count++
+	}
+	return interArrival, t.stdDev()
+}
+
+// reset forgets the previous arrival, so that a gap in the arrivals, e.g. across a failed scan or a reconnect, is
+// not counted as jitter. The recent inter-arrival times are kept.
+func (t *arrivalTimer) reset() {
+	t.last = time.Time{}
+}
+
+// stdDev returns the standard deviation of the recent inter-arrival times, zero for fewer than two of them.
+func (t *arrivalTimer) stdDev() time.Duration {
+	if t.count < 2 {
+		return 0
+	}
+	var sum float64
+	for _, interval := range t.intervals[:t.count] {
+		sum += float64(interval)
+	}
+	mean := sum / float64(t.count)
+	var sumSq float64
+	for _, interval := range t.intervals[:t.count] {
+		sumSq += (float64(interval) - mean) * (float64(interval) - mean)
+	}
+	return time.Duration(math.Sqrt(sumSq / float64(t.count)))
+}
+
+// timingHealth returns the get_timing_health summary of the most recent scan's arrival timing.
+func (rp *rplidar) timingHealth(meta ScanMeta) map[string]interface{} {
+	jitterMs := float64(meta.ArrivalJitter) / float64(time.Millisecond)
+	return map[string]interface{}{
+		"inter_arrival_ms": float64(meta.InterArrival) / float64(time.Millisecond),
+		"jitter_ms":        jitterMs,
+		"max_jitter_ms":    rp.maxArrivalJitterMs,
+		"healthy":          jitterMs <= rp.maxArrivalJitterMs,
+	}
+}
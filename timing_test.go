@@ -0,0 +1,48 @@
+package rplidar
+
+import (
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func TestArrivalTimer(t *testing.T) {
+	var timer arrivalTimer
+	now := time.Unix(1000, 0)
+
+	interArrival, jitter := timer.arrive(now)
+	test.That(t, interArrival, test.ShouldEqual, time.Duration(0))
+	test.That(t, jitter, test.ShouldEqual, time.Duration(0))
+
+	// Even arrivals have no jitter.
+	for i := 0; i < 3; i++ {
+		now = now.Add(100 * time.Millisecond)
+		interArrival, jitter = timer.arrive(now)
+		test.That(t, interArrival, test.ShouldEqual, 100*time.Millisecond)
+		test.That(t, jitter, test.ShouldEqual, time.Duration(0))
+	}
+
+	// Alternating 90 and 110 milliseconds deviate by 10 milliseconds once they fill the window.
+	for i := 0; i < arrivalJitterWindow; i++ {
+		step := 90 * time.Millisecond
+		if i%2 == 1 {
+			step = 110 * time.Millisecond
+		}
+		now = now.Add(step)
+		interArrival, jitter = timer.arrive(now)
+		test.That(t, interArrival, test.ShouldEqual, step)
+	}
+	test.That(t, jitter, test.ShouldEqual, 10*time.Millisecond)
+
+	t.Run("a reset skips the gap", func(t *testing.T) {
+		timer.reset()
+		interArrival, jitter := timer.arrive(now.Add(time.Minute))
+		test.That(t, interArrival, test.ShouldEqual, time.Duration(0))
+		test.That(t, jitter, test.ShouldEqual, time.Duration(0))
+
+		interArrival, jitter = timer.arrive(now.Add(time.Minute + 90*time.Millisecond))
+		test.That(t, interArrival, test.ShouldEqual, 90*time.Millisecond)
+		test.That(t, jitter, test.ShouldBeLessThan, 11*time.Millisecond)
+	})
+}
@@ -0,0 +1,163 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// The modes deciding which revolution NextPointCloud returns.
+const (
+	// triggerModeFreeRunning returns the most recent revolution.
+	triggerModeFreeRunning = "free_running"
+	// triggerModeExternal returns the revolution whose start is closest to the most recent sync trigger.
+	triggerModeExternal = "external"
+)
+
+// SyncTrigger is implemented by the rplidar camera. It approximates a hardware sync input for multi-sensor setups
+// by aligning scans to an external sync pulse, e.g. from a GPIO or a network message, when trigger_mode is
+// "external". The rplidar keeps spinning continuously and cannot start a revolution on demand, so the alignment is
+// only as good as half a rotation period, about 50ms at 10Hz, plus the jitter of the host timestamping revolutions.
+type SyncTrigger interface {
+	// Trigger records a sync pulse at the given time. From then on, NextPointCloud returns the revolution whose
+	// start is closest to it, waiting for the first revolution starting after it if needed, and drops the others.
+	// The time should be taken from the same clock the pulse was timestamped with on the host.
+	Trigger(at time.Time)
+}
+
+// triggeredScan is a revolution offered to the scanTrigger, along with the time it started.
+type triggeredScan struct {
+	pointCloud pointcloud.PointCloud
+	start      time.Time
+}
+
+// The number of recent revolutions kept to pick from for a trigger that arrives late, e.g. over the network.
+const triggerHistory = 8
+
+// scanTrigger picks the revolution whose start is closest to the most recent sync trigger. A nil scanTrigger is
+// free running. All methods are safe for concurrent use.
+type scanTrigger struct {
+	mutex sync.Mutex
+	// The time of the most recent trigger, zero before the first one.
+	latest time.Time
+	// The most recently offered revolutions, the newest last.
+	recent []triggeredScan
+	// The revolution picked for the latest trigger, nil until one started after it.
+	picked *triggeredScan
+	// Closed and reset whenever a revolution is picked, to wake up callers waiting for it.
+	updated chan struct{}
+}
+
+// newScanTrigger creates a scanTrigger for the given trigger mode, or returns nil if the mode is free running.
+func newScanTrigger(mode string) *scanTrigger {
+	if mode != triggerModeExternal {
+		return nil
+	}
+	return &scanTrigger{}
+}
+
+// trigger records a sync trigger at the given time, replacing the previous one. If a revolution already started
+// after it, the trigger arrived late and a revolution is picked right away.
+func (t *scanTrigger) trigger(at time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.latest = at
+	t.picked = nil
+	t.pick()
+}
+
+// offer hands the scanTrigger a new revolution that started at start.
+func (t *scanTrigger) offer(pc pointcloud.PointCloud, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.recent = append(t.recent, triggeredScan{pointCloud: pc, start: start})
+	if len(t.recent) > triggerHistory {
+		t.recent = t.recent[len(t.recent)-triggerHistory:]
+	}
+	if t.picked == nil {
+		t.pick()
+	}
+}
+
+// pick picks the recent revolution whose start is closest to the latest trigger, once the newest one started at
+// or after it, and wakes up the callers waiting for it. It must be called with the mutex held.
+func (t *scanTrigger) pick() {
+	if t.latest.IsZero() || len(t.recent) == 0 || t.recent[len(t.recent)-1].start.Before(t.latest) {
+		return
+	}
+	closest := 0
+	for i, scan := range t.recent {
+		if absDuration(scan.start.Sub(t.latest)) < absDuration(t.recent[closest].start.Sub(t.latest)) {
+			closest = i
+		}
+	}
+	picked := t.recent[closest]
+	t.picked = &picked
+	if t.updated != nil {
+		close(t.updated)
+		t.updated = nil
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// next returns the revolution picked for the latest trigger, waiting until one is picked or ctx is done.
+func (t *scanTrigger) next(ctx context.Context) (pointcloud.PointCloud, error) {
+	for {
+		t.mutex.Lock()
+		if t.latest.IsZero() {
+			t.mutex.Unlock()
+			return nil, errors.New("no sync trigger received yet")
+		}
+		if t.picked != nil {
+			pc := t.picked.pointCloud
+			t.mutex.Unlock()
+			return pc, nil
+		}
+		if t.updated == nil {
+			t.updated = make(chan struct{})
+		}
+		updated := t.updated
+		t.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-updated:
+		}
+	}
+}
+
+// Trigger records a sync pulse at the given time, see SyncTrigger. It has no effect unless trigger_mode is
+// "external".
+func (rp *rplidar) Trigger(at time.Time) {
+	if rp.scanTrigger == nil {
+		rp.logger.Debug("ignoring sync trigger, trigger_mode is not external")
+		return
+	}
+	rp.scanTrigger.trigger(at)
+}
+
+// revolutionStart returns the time the revolution completed at scanTime started: the completion of the previous
+// revolution if it arrived interArrival before, or else one measured rotation period before.
+func (rp *rplidar) revolutionStart(scanTime time.Time, interArrival time.Duration) time.Time {
+	if interArrival > 0 {
+		return scanTime.Add(-interArrival)
+	}
+	if period, err := rp.stats.rotationPeriod(); err == nil {
+		return scanTime.Add(-period)
+	}
+	return scanTime
+}
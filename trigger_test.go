@@ -0,0 +1,78 @@
+package rplidar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+)
+
+func TestScanTrigger(t *testing.T) {
+	start := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	period := 100 * time.Millisecond
+	revolutions := make([]pointcloud.PointCloud, 4)
+	for i := range revolutions {
+		revolutions[i] = pointcloud.New()
+	}
+
+	t.Run("free running", func(t *testing.T) {
+		test.That(t, newScanTrigger(""), test.ShouldBeNil)
+		test.That(t, newScanTrigger(triggerModeFreeRunning), test.ShouldBeNil)
+		var trigger *scanTrigger
+		trigger.offer(revolutions[0], start)
+	})
+
+	t.Run("no trigger yet", func(t *testing.T) {
+		trigger := newScanTrigger(triggerModeExternal)
+		trigger.offer(revolutions[0], start)
+		_, err := trigger.next(context.Background())
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldEqual, "no sync trigger received yet")
+	})
+
+	t.Run("picks the revolution starting closest to the trigger", func(t *testing.T) {
+		trigger := newScanTrigger(triggerModeExternal)
+		trigger.offer(revolutions[0], start)
+		trigger.trigger(start.Add(period + 30*time.Millisecond))
+
+		// The revolution in progress started before the trigger, so the one after it has to be waited for.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		_, err := trigger.next(ctx)
+		test.That(t, err, test.ShouldBeError, context.DeadlineExceeded)
+
+		trigger.offer(revolutions[1], start.Add(period))
+		trigger.offer(revolutions[2], start.Add(2*period))
+		pc, err := trigger.next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldEqual, revolutions[1])
+
+		// Later revolutions are dropped until the next trigger.
+		trigger.offer(revolutions[3], start.Add(3*period))
+		pc, err = trigger.next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldEqual, revolutions[1])
+
+		trigger.trigger(start.Add(2*period + 80*time.Millisecond))
+		pc, err = trigger.next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc, test.ShouldEqual, revolutions[3])
+	})
+
+	t.Run("a waiting call gets the picked revolution", func(t *testing.T) {
+		trigger := newScanTrigger(triggerModeExternal)
+		trigger.trigger(start.Add(10 * time.Millisecond))
+
+		picked := make(chan pointcloud.PointCloud)
+		go func() {
+			pc, err := trigger.next(context.Background())
+			test.That(t, err, test.ShouldBeNil)
+			picked <- pc
+		}()
+		trigger.offer(revolutions[0], start)
+		trigger.offer(revolutions[1], start.Add(period))
+		test.That(t, <-picked, test.ShouldEqual, revolutions[0])
+	})
+}
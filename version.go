@@ -0,0 +1,10 @@
+// Package rplidar implements a general rplidar LIDAR as a camera.
+package rplidar
+
+import "go.viam.com/rplidar/gen"
+
+// SDKVersion returns the version of the Slamtec rplidar SDK the package is built against, as reported by the
+// SDK's headers.
+func SDKVersion() string {
+	return gen.RPLIDAR_SDK_VERSION
+}